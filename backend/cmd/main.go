@@ -2,14 +2,17 @@ package main
 
 import (
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"strings"
 	"time"
+	_ "time/tzdata" // the runtime image has no zoneinfo; user time zones need it
 
 	"teampulse/internal/database"
 	"teampulse/internal/handlers"
 	mw "teampulse/internal/middleware"
+	"teampulse/internal/policy"
 
 	"github.com/labstack/echo/v4"
 	echomw "github.com/labstack/echo/v4/middleware"
@@ -21,9 +24,16 @@ func main() {
 	database.Connect()
 	database.Migrate()
 
+	// Background jobs
+	go handlers.RunAutoClockOut(handlers.AutoClockOutInterval)
+
 	// Echo
 	e := echo.New()
 	e.HideBanner = true
+	e.IPExtractor = ipExtractor()
+	if os.Getenv("TRUSTED_PROXIES") == "" {
+		e.Use(warnUntrustedProxy)
+	}
 
 	// Global middleware
 	e.Use(echomw.Logger())
@@ -65,9 +75,38 @@ func main() {
 		},
 	})
 
+	// Token refreshes are routine, so they get a looser limit of their own:
+	// ~30 per minute per IP, enough for an office of browsers behind one NAT
+	refreshRateLimiter := echomw.RateLimiterWithConfig(echomw.RateLimiterConfig{
+		Store: echomw.NewRateLimiterMemoryStoreWithConfig(
+			echomw.RateLimiterMemoryStoreConfig{
+				Rate:      rate.Every(2 * time.Second),
+				Burst:     30,
+				ExpiresIn: 3 * time.Minute,
+			},
+		),
+		IdentifierExtractor: func(ctx echo.Context) (string, error) {
+			return ctx.RealIP(), nil
+		},
+		DenyHandler: func(ctx echo.Context, identifier string, err error) error {
+			return ctx.JSON(http.StatusTooManyRequests, map[string]string{
+				"error": "too many refresh attempts, please try again later",
+			})
+		},
+	})
+
 	// ─── Public Routes ────────────────────────────────────────
 	e.POST("/api/auth/login", handlers.Login, loginRateLimiter)
-	e.POST("/api/agent/auth-code", handlers.ExchangeSetupCode) // desktop agent exchanges setup code for JWT
+	e.POST("/api/auth/refresh", handlers.RefreshSession, refreshRateLimiter)
+	e.POST("/api/auth/forgot-password", handlers.ForgotPassword, loginRateLimiter)
+	e.POST("/api/auth/reset-password", handlers.ResetPassword, loginRateLimiter)
+	e.POST("/api/auth/mfa/verify", handlers.VerifyMFA, loginRateLimiter)
+	e.GET("/api/auth/oidc/config", handlers.GetSSOConfig)
+	e.GET("/api/auth/oidc/login", handlers.OIDCLogin)
+	e.GET("/api/auth/oidc/callback", handlers.OIDCCallback)
+	e.POST("/api/auth/oidc/exchange", handlers.ExchangeSSOTicket)
+	e.POST("/api/agent/auth-code", handlers.ExchangeSetupCode) // desktop agent exchanges setup code for device credentials
+	e.POST("/api/agent/token", handlers.RefreshDeviceToken)    // desktop agent trades refresh secret for access token
 	e.GET("/api/agent/download", handlers.DownloadAgent)       // public so <a> tags work without JWT
 	e.GET("/api/agent/version", handlers.GetAgentVersion)      // public: get current agent version
 
@@ -76,15 +115,51 @@ func main() {
 
 	// Auth
 	api.GET("/auth/me", handlers.GetMe)
+	api.POST("/auth/logout", handlers.Logout)
+	api.POST("/auth/logout-all", handlers.LogoutEverywhere)
+	api.GET("/auth/sessions", handlers.ListMySessions)
+	api.POST("/auth/password", handlers.ChangePassword)
+	api.POST("/auth/mfa/enroll", handlers.EnrollMFA)
+	api.POST("/auth/mfa/activate", handlers.ActivateMFA)
+	api.POST("/auth/mfa/recovery-codes", handlers.RegenerateRecoveryCodes)
+	api.POST("/auth/mfa/disable", handlers.DisableMFA)
+	api.POST("/auth/pin", handlers.SetMyPIN)
+	api.PUT("/auth/time-zone", handlers.SetMyTimeZone)
+
+	// Personal API keys (can't be managed with an API key)
+	api.GET("/keys", handlers.ListMyAPIKeys)
+	api.POST("/keys", handlers.CreateAPIKey)
+	api.DELETE("/keys/:id", handlers.RevokeAPIKey)
 
 	// Time Clock (employee self-service)
 	api.POST("/clock/in", handlers.ClockIn)
 	api.POST("/clock/out", handlers.ClockOut)
+	api.POST("/clock/break/start", handlers.StartBreak)
+	api.POST("/clock/break/end", handlers.EndBreak)
 	api.GET("/clock/status", handlers.GetClockStatus)
 	api.GET("/clock/entries", handlers.GetTimeEntries)
 	api.GET("/clock/sessions/me", handlers.GetMyClockSessions)
+	api.POST("/clock/entries/:id/corrections", handlers.RequestCorrection)
+	api.GET("/clock/entries/:id/revisions", handlers.GetEntryRevisions)
+	api.GET("/clock/corrections/me", handlers.ListMyCorrections)
 
-	// Hours chart (admin sees team, employee sees own)
+	// Timesheets (own; reviewing needs time:approve)
+	api.GET("/timesheets/me", handlers.GetMyTimesheet)
+	api.GET("/timesheets/:id", handlers.GetTimesheet)
+	api.POST("/timesheets/:id/submit", handlers.SubmitTimesheet)
+
+	// Own schedule
+	api.GET("/shifts/me", handlers.ListMyShifts)
+
+	// Own leave (reviewing needs time:approve)
+	api.GET("/leave/types", handlers.ListLeaveTypes)
+	api.GET("/leave/balances/me", handlers.GetMyLeaveBalances)
+	api.GET("/leave/requests/me", handlers.ListMyLeaveRequests)
+	api.POST("/leave/requests", handlers.RequestLeave)
+	api.POST("/leave/requests/:id/cancel", handlers.CancelLeaveRequest)
+	api.GET("/holidays/me", handlers.ListMyHolidays)
+
+	// Hours chart (admin sees everyone, manager their team, employee own)
 	api.GET("/hours/daily", handlers.GetDailyHours)
 
 	// Activity Pings (employee browser sends these)
@@ -120,28 +195,149 @@ func main() {
 	api.POST("/agent/skip-setup", handlers.SkipAgentSetup)
 
 	// Segments (v2 timeline data)
-	api.GET("/segments", handlers.GetSegments)
+	api.GET("/segments", handlers.GetSegments, mw.Require(policy.TimelineView))
 	api.GET("/segments/me", handlers.GetMySegments)
 
-	// ─── Admin Routes ─────────────────────────────────────────
-	admin := api.Group("", mw.AdminOnly)
-
-	admin.POST("/employees", handlers.RegisterEmployee)
-	admin.GET("/employees", handlers.ListEmployees)
-	admin.PUT("/employees/:id", handlers.UpdateEmployee)
-	admin.DELETE("/employees/:id", handlers.DeactivateEmployee)
-	admin.POST("/employees/:id/setup-code", handlers.AdminGenerateSetupToken)
-	admin.GET("/dashboard", handlers.GetDashboard)
-	admin.GET("/activity/stats", handlers.GetActivityStats)
-	admin.GET("/agent/monitor", handlers.GetAgentMonitor)
-	admin.GET("/agent/app-usage", handlers.GetAppUsage)
-	admin.GET("/aggregations", handlers.GetAggregations)
-	admin.GET("/clock/sessions", handlers.GetClockSessions)
-	admin.GET("/employee/:id/timeline", handlers.GetEmployeeTimeline)
-
-	// WebSocket for live monitoring (admin)
+	// ─── Permission-Gated Routes ──────────────────────────────
+	// Holders without scope:all are limited to their reporting line; see
+	// internal/policy for the built-in grants.
+	api.GET("/dashboard", handlers.GetDashboard, mw.Require(policy.DashboardView))
+	api.GET("/clock/sessions", handlers.GetClockSessions, mw.Require(policy.SessionView))
+	api.GET("/employee/:id/timeline", handlers.GetEmployeeTimeline, mw.Require(policy.TimelineView))
+
+	monitoring := api.Group("", mw.Require(policy.ActivityView))
+	monitoring.GET("/activity/stats", handlers.GetActivityStats)
+	monitoring.GET("/agent/monitor", handlers.GetAgentMonitor)
+	monitoring.GET("/agent/app-usage", handlers.GetAppUsage)
+	monitoring.GET("/aggregations", handlers.GetAggregations)
+
+	corrections := api.Group("/corrections", mw.Require(policy.TimeApprove))
+	corrections.GET("", handlers.ListCorrections)
+	corrections.POST("/:id/approve", handlers.ApproveCorrection)
+	corrections.POST("/:id/reject", handlers.RejectCorrection)
+
+	api.POST("/clock/entries/:id/network-review", handlers.ReviewOffNetworkEntry, mw.Require(policy.TimeApprove))
+
+	timesheets := api.Group("/timesheets", mw.Require(policy.TimeApprove))
+	timesheets.GET("", handlers.ListTimesheets)
+	timesheets.POST("/:id/approve", handlers.ApproveTimesheet)
+	timesheets.POST("/:id/reject", handlers.RejectTimesheet)
+	timesheets.POST("/:id/reopen", handlers.ReopenTimesheet)
+
+	leaveReview := api.Group("/leave", mw.Require(policy.TimeApprove))
+	leaveReview.GET("/requests", handlers.ListLeaveRequests)
+	leaveReview.POST("/requests/:id/approve", handlers.ApproveLeaveRequest)
+	leaveReview.POST("/requests/:id/reject", handlers.RejectLeaveRequest)
+	leaveReview.GET("/balances", handlers.GetLeaveBalances)
+	leaveReview.POST("/balances/adjust", handlers.AdjustLeaveBalance, mw.Require(policy.SettingsManage))
+	leaveReview.POST("/types", handlers.CreateLeaveType, mw.Require(policy.SettingsManage))
+	leaveReview.PUT("/types/:id", handlers.UpdateLeaveType, mw.Require(policy.SettingsManage))
+	leaveReview.DELETE("/types/:id", handlers.DeactivateLeaveType, mw.Require(policy.SettingsManage))
+
+	api.GET("/attendance", handlers.GetAttendance, mw.Require(policy.SessionView))
+
+	shifts := api.Group("/shifts", mw.Require(policy.ScheduleManage))
+	shifts.GET("", handlers.ListShifts)
+	shifts.POST("", handlers.CreateShift)
+	shifts.PUT("/:id", handlers.UpdateShift)
+	shifts.DELETE("/:id", handlers.DeleteShift)
+
+	shiftTemplates := api.Group("/shift-templates", mw.Require(policy.ScheduleManage))
+	shiftTemplates.GET("", handlers.ListShiftTemplates)
+	shiftTemplates.POST("", handlers.CreateShiftTemplate)
+	shiftTemplates.PUT("/:id", handlers.UpdateShiftTemplate)
+	shiftTemplates.DELETE("/:id", handlers.DeleteShiftTemplate)
+	shiftTemplates.POST("/:id/apply", handlers.ApplyShiftTemplate)
+
+	payroll := api.Group("/payroll", mw.Require(policy.TimeApprove))
+	payroll.GET("/export", handlers.ExportPayroll)
+	payroll.GET("/templates", handlers.ListPayrollTemplates)
+	payroll.POST("/templates", handlers.CreatePayrollTemplate, mw.Require(policy.SettingsManage))
+	payroll.PUT("/templates/:id", handlers.UpdatePayrollTemplate, mw.Require(policy.SettingsManage))
+	payroll.DELETE("/templates/:id", handlers.DeletePayrollTemplate, mw.Require(policy.SettingsManage))
+
+	api.GET("/employees", handlers.ListEmployees, mw.Require(policy.EmployeeView))
+	api.POST("/employees", handlers.RegisterEmployee, mw.Require(policy.EmployeeManage))
+
+	employee := api.Group("/employees/:id", mw.Require(policy.EmployeeManage), handlers.TargetInView)
+	employee.PUT("", handlers.UpdateEmployee)
+	employee.DELETE("", handlers.DeactivateEmployee)
+	employee.POST("/setup-code", handlers.AdminGenerateSetupToken)
+	employee.GET("/devices", handlers.ListEmployeeDevices)
+	employee.DELETE("/devices/:deviceId", handlers.RevokeDevice)
+	employee.GET("/api-keys", handlers.ListEmployeeAPIKeys)
+	employee.DELETE("/api-keys/:keyId", handlers.RevokeEmployeeAPIKey)
+	employee.POST("/logout-all", handlers.AdminLogoutEmployee)
+	employee.DELETE("/mfa", handlers.AdminResetMFA)
+
+	settings := api.Group("/settings", mw.Require(policy.SettingsManage))
+	settings.GET("", handlers.GetSettings)
+	settings.PUT("", handlers.UpdateSettings)
+	settings.POST("/scim-token", handlers.GenerateSCIMToken)
+	settings.DELETE("/scim-token", handlers.RevokeSCIMToken)
+
+	clockPolicies := api.Group("/clock-policies", mw.Require(policy.SettingsManage))
+	clockPolicies.GET("", handlers.ListClockInPolicies)
+	clockPolicies.POST("", handlers.CreateClockInPolicy)
+	clockPolicies.PUT("/:id", handlers.UpdateClockInPolicy)
+	clockPolicies.DELETE("/:id", handlers.DeleteClockInPolicy)
+
+	holidayCalendars := api.Group("/holiday-calendars", mw.Require(policy.SettingsManage))
+	holidayCalendars.GET("", handlers.ListHolidayCalendars)
+	holidayCalendars.POST("", handlers.CreateHolidayCalendar)
+	holidayCalendars.PUT("/:id", handlers.UpdateHolidayCalendar)
+	holidayCalendars.DELETE("/:id", handlers.DeleteHolidayCalendar)
+	holidayCalendars.GET("/:id/holidays", handlers.ListHolidays)
+	holidayCalendars.POST("/:id/holidays", handlers.AddHoliday)
+	holidayCalendars.DELETE("/:id/holidays/:holiday_id", handlers.DeleteHoliday)
+	holidayCalendars.POST("/:id/import", handlers.ImportHolidays)
+
+	kiosks := api.Group("/kiosks", mw.Require(policy.SettingsManage))
+	kiosks.GET("", handlers.ListKiosks)
+	kiosks.POST("", handlers.CreateKiosk)
+	kiosks.DELETE("/:id", handlers.RevokeKiosk)
+
+	roles := api.Group("/roles", mw.Require(policy.RoleManage))
+	roles.GET("", handlers.ListRoles)
+	roles.POST("", handlers.CreateRole)
+	roles.PUT("/:id", handlers.UpdateRole)
+	roles.DELETE("/:id", handlers.DeleteRole)
+
+	// ─── Platform Routes (organization management) ────────────
+	api.GET("/org", handlers.GetMyOrganization)
+
+	platform := api.Group("/orgs", mw.PlatformAdminOnly)
+	platform.GET("", handlers.ListOrganizations)
+	platform.POST("", handlers.CreateOrganization)
+	platform.PUT("/:id", handlers.UpdateOrganization)
+
+	// WebSocket for live monitoring (activity:view + scope:all)
 	e.GET("/api/ws/monitor", handlers.MonitorWebSocket, handlers.WsAuthMiddleware)
 
+	// ─── Kiosk (shared clock-in terminal, kiosk secret) ───────
+	kiosk := e.Group("/api/kiosk", handlers.KioskAuthMiddleware)
+	kiosk.GET("/employees", handlers.KioskEmployees)
+	kiosk.POST("/clock-in", handlers.KioskClockIn)
+	kiosk.POST("/clock-out", handlers.KioskClockOut)
+
+	// ─── SCIM 2.0 Provisioning (directory bearer token) ───────
+	scim := e.Group("/scim/v2", handlers.SCIMAuthMiddleware)
+
+	scim.GET("/ServiceProviderConfig", handlers.SCIMServiceProviderConfig)
+	scim.GET("/ResourceTypes", handlers.SCIMResourceTypes)
+	scim.GET("/Users", handlers.SCIMListUsers)
+	scim.POST("/Users", handlers.SCIMCreateUser)
+	scim.GET("/Users/:id", handlers.SCIMGetUser)
+	scim.PUT("/Users/:id", handlers.SCIMReplaceUser)
+	scim.PATCH("/Users/:id", handlers.SCIMPatchUser)
+	scim.DELETE("/Users/:id", handlers.SCIMDeleteUser)
+	scim.GET("/Groups", handlers.SCIMListGroups)
+	scim.POST("/Groups", handlers.SCIMCreateGroup)
+	scim.GET("/Groups/:id", handlers.SCIMGetGroup)
+	scim.PUT("/Groups/:id", handlers.SCIMReplaceGroup)
+	scim.PATCH("/Groups/:id", handlers.SCIMPatchGroup)
+	scim.DELETE("/Groups/:id", handlers.SCIMDeleteGroup)
+
 	// Serve static frontend in production
 	e.Static("/", "static")
 
@@ -153,3 +349,41 @@ func main() {
 	log.Printf("TeamPulse starting on :%s", port)
 	e.Logger.Fatal(e.Start(":" + port))
 }
+
+// ipExtractor decides where client addresses come from. Clock-in policies,
+// rate limits and audit IPs rely on them, so forwarding headers are only
+// believed from the proxies listed in TRUSTED_PROXIES (comma-separated CIDR
+// ranges); otherwise the connection's own address is used.
+func ipExtractor() echo.IPExtractor {
+	env := os.Getenv("TRUSTED_PROXIES")
+	if env == "" {
+		return echo.ExtractIPDirect()
+	}
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, r := range strings.Split(env, ",") {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(r))
+		if err != nil {
+			log.Fatalf("TRUSTED_PROXIES: invalid CIDR range %q", r)
+		}
+		opts = append(opts, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...)
+}
+
+// warnUntrustedProxy logs requests that came through a proxy missing from
+// TRUSTED_PROXIES. Their clients all share the proxy's address, so the login
+// rate limit turns into one bucket for everyone and clock-in policies only
+// ever see the proxy.
+func warnUntrustedProxy(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get(echo.HeaderXForwardedFor) != "" {
+			log.Printf("ERROR: %s %s came through a proxy at %s but TRUSTED_PROXIES is unset; set it to the proxy's range",
+				c.Request().Method, c.Request().URL.Path, c.Request().RemoteAddr)
+		}
+		return next(c)
+	}
+}
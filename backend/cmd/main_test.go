@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestIPExtractor(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted string
+		remote  string
+		xff     string
+		want    string
+	}{
+		{"no proxies trusted", "", "10.0.0.5:4000", "203.0.113.7", "10.0.0.5"},
+		{"trusted proxy", "10.0.0.0/8", "10.0.0.5:4000", "203.0.113.7", "203.0.113.7"},
+		{"client past a chain of trusted proxies", "10.0.0.0/8", "10.0.0.5:4000", "203.0.113.7, 10.1.1.1", "203.0.113.7"},
+		{"spoofed header before the real client", "10.0.0.0/8", "10.0.0.5:4000", "192.0.2.1, 203.0.113.7", "203.0.113.7"},
+		{"untrusted sender", "10.0.0.0/8", "198.51.100.1:4000", "203.0.113.7", "198.51.100.1"},
+		{"private ranges aren't trusted by default", "10.0.0.0/8", "192.168.1.1:4000", "203.0.113.7", "192.168.1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tt.trusted)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set(echo.HeaderXForwardedFor, tt.xff)
+			if got := ipExtractor()(req); got != tt.want {
+				t.Errorf("client IP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarnUntrustedProxy(t *testing.T) {
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(prev) })
+
+	handler := warnUntrustedProxy(func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	for _, xff := range []string{"", "203.0.113.7"} {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+		if xff != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, xff)
+		}
+		if err := handler(echo.New().NewContext(req, httptest.NewRecorder())); err != nil {
+			t.Fatal(err)
+		}
+		if logged := strings.Contains(buf.String(), "TRUSTED_PROXIES"); logged != (xff != "") {
+			t.Errorf("X-Forwarded-For %q: logged = %v", xff, logged)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Command mockidp is a throwaway OpenID provider for exercising TeamPulse SSO
+// locally. It signs in whoever types an email on its login form.
+//
+//	go run ./cmd/mockidp -addr :9000
+//	OIDC_ISSUER=http://localhost:9000 OIDC_CLIENT_ID=teampulse \
+//	OIDC_REDIRECT_URL=http://localhost:8080/api/auth/oidc/callback go run ./cmd/main.go
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type authCode struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	email         string
+	name          string
+	expiresAt     time.Time
+}
+
+var (
+	issuer   string
+	clientID string
+	key      *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes = map[string]authCode{}
+)
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><body style="font-family:sans-serif;max-width:360px;margin:80px auto">
+<h2>Mock IdP</h2>
+<form method="post">
+  {{range $k, $v := .Query}}<input type="hidden" name="{{$k}}" value="{{index $v 0}}">{{end}}
+  <p><label>Email<br><input name="email" value="admin@teampulse.local" style="width:100%"></label></p>
+  <p><label>Name<br><input name="name" value="Mock User" style="width:100%"></label></p>
+  <button type="submit">Sign in</button>
+</form>
+</body></html>`))
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	flag.StringVar(&issuer, "issuer", "http://localhost:9000", "issuer URL (must match OIDC_ISSUER)")
+	flag.StringVar(&clientID, "client-id", "teampulse", "accepted client_id")
+	flag.Parse()
+
+	var err error
+	key, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("generate key: %v", err)
+	}
+
+	http.HandleFunc("/.well-known/openid-configuration", discovery)
+	http.HandleFunc("/authorize", authorize)
+	http.HandleFunc("/token", token)
+	http.HandleFunc("/jwks", jwks)
+
+	log.Printf("mock IdP issuer %s listening on %s", issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func authorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		loginPage.Execute(w, map[string]interface{}{"Query": r.URL.Query()})
+		return
+	}
+
+	r.ParseForm()
+	if r.FormValue("client_id") != clientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+	if r.FormValue("code_challenge_method") != "S256" || r.FormValue("code_challenge") == "" {
+		http.Error(w, "PKCE S256 required", http.StatusBadRequest)
+		return
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	code := base64.RawURLEncoding.EncodeToString(b)
+
+	mu.Lock()
+	codes[code] = authCode{
+		clientID:      r.FormValue("client_id"),
+		redirectURI:   r.FormValue("redirect_uri"),
+		nonce:         r.FormValue("nonce"),
+		codeChallenge: r.FormValue("code_challenge"),
+		email:         strings.TrimSpace(r.FormValue("email")),
+		name:          r.FormValue("name"),
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	mu.Unlock()
+
+	redirect, err := url.Parse(r.FormValue("redirect_uri"))
+	if err != nil {
+		http.Error(w, "bad redirect_uri", http.StatusBadRequest)
+		return
+	}
+	q := redirect.Query()
+	q.Set("code", code)
+	q.Set("state", r.FormValue("state"))
+	redirect.RawQuery = q.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	mu.Lock()
+	ac, ok := codes[r.FormValue("code")]
+	delete(codes, r.FormValue("code"))
+	mu.Unlock()
+
+	if !ok || time.Now().After(ac.expiresAt) || r.FormValue("redirect_uri") != ac.redirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != ac.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            issuer,
+		"aud":            ac.clientID,
+		"sub":            "mock|" + strings.ToLower(ac.email),
+		"email":          ac.email,
+		"email_verified": true,
+		"name":           ac.name,
+		"nonce":          ac.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = "mock"
+	idToken, err := t.SignedString(key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": idToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": "mock",
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
@@ -0,0 +1,101 @@
+// Package attendance compares one person's clock sessions with their
+// scheduled shifts, flagging late arrivals, early departures, no-shows and
+// work outside any shift.
+package attendance
+
+import "time"
+
+type Flag string
+
+const (
+	Late           Flag = "late"            // first clock-in after the shift start plus grace
+	EarlyDeparture Flag = "early_departure" // last clock-out before the shift end minus grace
+	NoShow         Flag = "no_show"         // shift ended without a session
+	Unscheduled    Flag = "unscheduled"     // session overlapping no shift
+)
+
+// Shift is a scheduled block of work
+type Shift struct {
+	ID    uint
+	Start time.Time
+	End   time.Time
+}
+
+// Session is a clock session; a nil Out means still clocked in
+type Session struct {
+	ID  uint
+	In  time.Time
+	Out *time.Time
+}
+
+// ShiftResult is how one shift was worked
+type ShiftResult struct {
+	ShiftID     uint
+	SessionIDs  []uint
+	Flags       []Flag
+	LateBy      time.Duration
+	LeftEarlyBy time.Duration
+}
+
+// Analyze matches sessions to the shifts they overlap and judges each shift
+// as of now. A shift still in progress is only ever flagged late; it can't be
+// left early or missed yet. It also returns the sessions that overlap no shift.
+func Analyze(shifts []Shift, sessions []Session, grace time.Duration, now time.Time) ([]ShiftResult, []uint) {
+	matched := make([]bool, len(sessions))
+	results := make([]ShiftResult, 0, len(shifts))
+
+	for _, shift := range shifts {
+		result := ShiftResult{ShiftID: shift.ID}
+		var firstIn, lastOut time.Time
+		open := false
+
+		for i, s := range sessions {
+			out := now
+			if s.Out != nil {
+				out = *s.Out
+			}
+			if !s.In.Before(shift.End) || !out.After(shift.Start) {
+				continue
+			}
+			matched[i] = true
+			result.SessionIDs = append(result.SessionIDs, s.ID)
+			if firstIn.IsZero() || s.In.Before(firstIn) {
+				firstIn = s.In
+			}
+			if s.Out == nil {
+				open = true
+			} else if s.Out.After(lastOut) {
+				lastOut = *s.Out
+			}
+		}
+
+		switch {
+		case len(result.SessionIDs) == 0 && !now.Before(shift.End):
+			result.Flags = append(result.Flags, NoShow)
+		case len(result.SessionIDs) == 0:
+			// Not in yet; late once the grace period is over
+			if now.After(shift.Start.Add(grace)) {
+				result.Flags = append(result.Flags, Late)
+				result.LateBy = now.Sub(shift.Start)
+			}
+		default:
+			if firstIn.After(shift.Start.Add(grace)) {
+				result.Flags = append(result.Flags, Late)
+				result.LateBy = firstIn.Sub(shift.Start)
+			}
+			if !open && !now.Before(shift.End) && lastOut.Before(shift.End.Add(-grace)) {
+				result.Flags = append(result.Flags, EarlyDeparture)
+				result.LeftEarlyBy = shift.End.Sub(lastOut)
+			}
+		}
+		results = append(results, result)
+	}
+
+	var unscheduled []uint
+	for i, s := range sessions {
+		if !matched[i] {
+			unscheduled = append(unscheduled, s.ID)
+		}
+	}
+	return results, unscheduled
+}
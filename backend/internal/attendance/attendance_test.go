@@ -0,0 +1,154 @@
+package attendance
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 2, h, m, 0, 0, time.UTC) }
+	out := func(h, m int) *time.Time { t := at(h, m); return &t }
+	grace := 5 * time.Minute
+	shift := []Shift{{ID: 1, Start: at(9, 0), End: at(17, 0)}}
+
+	tests := []struct {
+		name        string
+		shifts      []Shift
+		sessions    []Session
+		now         time.Time
+		want        []ShiftResult
+		unscheduled []uint
+	}{
+		{
+			name:     "on time",
+			shifts:   shift,
+			sessions: []Session{{ID: 10, In: at(9, 0), Out: out(17, 0)}},
+			now:      at(20, 0),
+			want:     []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10}}},
+		},
+		{
+			name:     "within grace",
+			shifts:   shift,
+			sessions: []Session{{ID: 10, In: at(9, 5), Out: out(16, 55)}},
+			now:      at(20, 0),
+			want:     []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10}}},
+		},
+		{
+			name:     "late",
+			shifts:   shift,
+			sessions: []Session{{ID: 10, In: at(9, 20), Out: out(17, 0)}},
+			now:      at(20, 0),
+			want:     []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10}, Flags: []Flag{Late}, LateBy: 20 * time.Minute}},
+		},
+		{
+			name:     "left early",
+			shifts:   shift,
+			sessions: []Session{{ID: 10, In: at(9, 0), Out: out(16, 0)}},
+			now:      at(20, 0),
+			want:     []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10}, Flags: []Flag{EarlyDeparture}, LeftEarlyBy: time.Hour}},
+		},
+		{
+			name:     "late and left early",
+			shifts:   shift,
+			sessions: []Session{{ID: 10, In: at(10, 0), Out: out(15, 30)}},
+			now:      at(20, 0),
+			want: []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10}, Flags: []Flag{Late, EarlyDeparture},
+				LateBy: time.Hour, LeftEarlyBy: 90 * time.Minute}},
+		},
+		{
+			name:     "split sessions cover the shift",
+			shifts:   shift,
+			sessions: []Session{{ID: 10, In: at(9, 0), Out: out(12, 0)}, {ID: 11, In: at(13, 0), Out: out(17, 0)}},
+			now:      at(20, 0),
+			want:     []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10, 11}}},
+		},
+		{
+			name:   "no show",
+			shifts: shift,
+			now:    at(17, 0),
+			want:   []ShiftResult{{ShiftID: 1, Flags: []Flag{NoShow}}},
+		},
+		{
+			name:   "not in yet, within grace",
+			shifts: shift,
+			now:    at(9, 3),
+			want:   []ShiftResult{{ShiftID: 1}},
+		},
+		{
+			name:   "not in yet, past grace",
+			shifts: shift,
+			now:    at(9, 30),
+			want:   []ShiftResult{{ShiftID: 1, Flags: []Flag{Late}, LateBy: 30 * time.Minute}},
+		},
+		{
+			name:     "shift in progress",
+			shifts:   shift,
+			sessions: []Session{{ID: 10, In: at(9, 0)}},
+			now:      at(12, 0),
+			want:     []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10}}},
+		},
+		{
+			name:     "out early but shift not over",
+			shifts:   shift,
+			sessions: []Session{{ID: 10, In: at(9, 0), Out: out(11, 0)}},
+			now:      at(12, 0),
+			want:     []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10}}},
+		},
+		{
+			name:     "still clocked in after the shift",
+			shifts:   shift,
+			sessions: []Session{{ID: 10, In: at(9, 0)}},
+			now:      at(20, 0),
+			want:     []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10}}},
+		},
+		{
+			name:        "session outside the shift",
+			shifts:      shift,
+			sessions:    []Session{{ID: 10, In: at(9, 0), Out: out(17, 0)}, {ID: 11, In: at(18, 0), Out: out(19, 0)}},
+			now:         at(20, 0),
+			want:        []ShiftResult{{ShiftID: 1, SessionIDs: []uint{10}}},
+			unscheduled: []uint{11},
+		},
+		{
+			name:        "session starting at the shift end doesn't overlap",
+			shifts:      shift,
+			sessions:    []Session{{ID: 10, In: at(17, 0), Out: out(18, 0)}},
+			now:         at(20, 0),
+			want:        []ShiftResult{{ShiftID: 1, Flags: []Flag{NoShow}}},
+			unscheduled: []uint{10},
+		},
+		{
+			name:        "no shifts",
+			sessions:    []Session{{ID: 10, In: at(9, 0), Out: out(17, 0)}},
+			now:         at(20, 0),
+			want:        []ShiftResult{},
+			unscheduled: []uint{10},
+		},
+		{
+			name: "one session across two shifts",
+			shifts: []Shift{
+				{ID: 1, Start: at(9, 0), End: at(12, 0)},
+				{ID: 2, Start: at(12, 30), End: at(16, 0)},
+			},
+			sessions: []Session{{ID: 10, In: at(9, 0), Out: out(15, 0)}},
+			now:      at(20, 0),
+			want: []ShiftResult{
+				{ShiftID: 1, SessionIDs: []uint{10}},
+				{ShiftID: 2, SessionIDs: []uint{10}, Flags: []Flag{EarlyDeparture}, LeftEarlyBy: time.Hour},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unscheduled := Analyze(tt.shifts, tt.sessions, grace, tt.now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Analyze() results = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(unscheduled, tt.unscheduled) {
+				t.Errorf("Analyze() unscheduled = %v, want %v", unscheduled, tt.unscheduled)
+			}
+		})
+	}
+}
@@ -36,22 +36,48 @@ func Connect() {
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	registerTenantCallbacks(DB)
 
 	log.Println("Database connected")
 }
 
 func Migrate() {
 	err := DB.AutoMigrate(
+		&models.Organization{},
 		&models.User{},
+		&models.Session{},
+		&models.APIKey{},
+		&models.PasswordResetToken{},
+		&models.MFARecoveryCode{},
+		&models.OIDCLoginRequest{},
+		&models.OrgSettings{},
+		&models.Group{},
+		&models.CustomRole{},
 		&models.TimeEntry{},
+		&models.Break{},
+		&models.TimeEntryCorrection{},
+		&models.TimeEntryRevision{},
+		&models.ClockInPolicy{},
+		&models.Timesheet{},
+		&models.Shift{},
+		&models.ShiftTemplate{},
+		&models.LeaveType{},
+		&models.LeaveBalance{},
+		&models.LeaveRequest{},
+		&models.HolidayCalendar{},
+		&models.Holiday{},
+		&models.PayrollTemplate{},
 		&models.ActivityPing{},
 		&models.Task{},
 		&models.TaskTime{},
 		&models.KPI{},
 		&models.Standup{},
 		&models.AgentSetupToken{},
+		&models.Device{},
+		&models.Kiosk{},
 		&models.AgentHeartbeat{},
 		&models.ActivitySegment{},
+		&models.SegmentBatch{},
 		&models.DailyAggregation{},
 		&models.AuditLog{},
 	)
@@ -60,22 +86,52 @@ func Migrate() {
 	}
 	log.Println("Database migrated")
 
+	// Pre-tenant rows default to org 1, so it must exist
+	DB.FirstOrCreate(&models.Organization{}, models.Organization{ID: 1, Name: "Default", Slug: "default"})
+	// The explicit id bypassed the sequence; move it past the seeded row
+	DB.Exec("SELECT setval(pg_get_serial_sequence('organizations', 'id'), (SELECT MAX(id) FROM organizations))")
+	// Group and role names used to be globally unique; they are now per org
+	for table, index := range map[interface{}]string{&models.Group{}: "idx_groups_display_name", &models.CustomRole{}: "idx_custom_roles_name"} {
+		if DB.Migrator().HasIndex(table, index) {
+			DB.Migrator().DropIndex(table, index)
+		}
+	}
+
+	// Segments without a client_id are deduplicated on their natural key.
+	// Rows stored before the index may repeat it; the oldest copy is kept.
+	if !DB.Migrator().HasIndex(&models.ActivitySegment{}, "idx_segment_natural_key") {
+		err := DB.Exec(`DELETE FROM activity_segments a USING activity_segments b
+			WHERE COALESCE(a.client_id, '') = '' AND COALESCE(b.client_id, '') = '' AND a.id > b.id
+			AND a.user_id = b.user_id AND a.start_time = b.start_time AND a.end_time = b.end_time
+			AND a.segment_type = b.segment_type AND a.app_name = b.app_name`).Error
+		if err == nil {
+			err = DB.Exec(`CREATE UNIQUE INDEX idx_segment_natural_key ON activity_segments
+				(user_id, start_time, end_time, segment_type, app_name) WHERE COALESCE(client_id, '') = ''`).Error
+		}
+		if err != nil {
+			log.Fatalf("Failed to index activity segments: %v", err)
+		}
+	}
+
 	// Seed or update admin user from env vars
 	adminEmail := getEnv("ADMIN_EMAIL", "admin@teampulse.local")
 	adminPass := getEnv("ADMIN_PASSWORD", "admin123")
 	hash, _ := bcrypt.GenerateFromPassword([]byte(adminPass), bcrypt.DefaultCost)
 
+	// The seeded admin runs the default org and the platform
 	var admin models.User
-	result := DB.Where("role = ?", models.RoleAdmin).First(&admin)
+	result := DB.Where("role = ? AND org_id = 1", models.RoleAdmin).Order("id asc").First(&admin)
 	if result.Error != nil {
 		// No admin exists — create one
 		admin = models.User{
-			Email:    adminEmail,
-			Password: string(hash),
-			Name:     "Admin",
-			Role:     models.RoleAdmin,
-			Title:    "Administrator",
-			IsActive: true,
+			OrgID:           1,
+			Email:           adminEmail,
+			Password:        string(hash),
+			Name:            "Admin",
+			Role:            models.RoleAdmin,
+			Title:           "Administrator",
+			IsActive:        true,
+			IsPlatformAdmin: true,
 		}
 		DB.Create(&admin)
 		log.Printf("Admin user created: %s", admin.Email)
@@ -87,6 +143,17 @@ func Migrate() {
 		})
 		log.Printf("Admin user updated to: %s", adminEmail)
 	}
+	if !admin.IsPlatformAdmin {
+		DB.Model(&admin).Update("is_platform_admin", true)
+	}
+}
+
+// GetOrgSettings returns an organisation's settings row, creating it with
+// defaults on first use.
+func GetOrgSettings(orgID uint) models.OrgSettings {
+	var settings models.OrgSettings
+	DB.FirstOrCreate(&settings, models.OrgSettings{OrgID: orgID})
+	return settings
 }
 
 func getEnv(key, fallback string) string {
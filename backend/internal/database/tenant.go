@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ─── Tenant Scoping ──────────────────────────────────────────
+// Every org-owned model carries an OrgID. A handle from ForOrg filters all
+// queries, updates and deletes on such models to that organisation and
+// stamps it onto new rows, so a handler can't reach another tenant's data by
+// id. The bare DB stays unscoped for login and other cross-tenant lookups.
+
+type orgKey struct{}
+
+// ForOrg returns a DB handle confined to orgID. orgID 0 means unscoped.
+func ForOrg(orgID uint) *gorm.DB {
+	if orgID == 0 {
+		return DB
+	}
+	return DB.WithContext(context.WithValue(context.Background(), orgKey{}, orgID))
+}
+
+func orgFromStatement(db *gorm.DB) (uint, bool) {
+	if db.Statement.Context == nil || db.Statement.Schema == nil {
+		return 0, false
+	}
+	orgID, ok := db.Statement.Context.Value(orgKey{}).(uint)
+	if !ok || db.Statement.Schema.LookUpField("OrgID") == nil {
+		return 0, false
+	}
+	return orgID, true
+}
+
+// registerTenantCallbacks hooks tenant scoping into every GORM operation
+func registerTenantCallbacks(db *gorm.DB) {
+	db.Callback().Query().Before("gorm:query").Register("tenant:query", scopeToOrg)
+	db.Callback().Row().Before("gorm:row").Register("tenant:row", scopeToOrg)
+	db.Callback().Update().Before("gorm:update").Register("tenant:update", func(db *gorm.DB) {
+		stampOrg(db)
+		scopeToOrg(db)
+	})
+	db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", scopeToOrg)
+	db.Callback().Create().Before("gorm:create").Register("tenant:create", stampOrg)
+}
+
+func scopeToOrg(db *gorm.DB) {
+	orgID, ok := orgFromStatement(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "org_id"}, Value: orgID},
+	}})
+}
+
+// stampOrg forces OrgID on rows being written, whatever the request body said
+func stampOrg(db *gorm.DB) {
+	orgID, ok := orgFromStatement(db)
+	if !ok {
+		return
+	}
+
+	if updates, isMap := db.Statement.Dest.(map[string]interface{}); isMap {
+		delete(updates, "org_id")
+		delete(updates, "OrgID")
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField("OrgID")
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			field.Set(db.Statement.Context, rv.Index(i), orgID)
+		}
+	case reflect.Struct:
+		field.Set(db.Statement.Context, rv, orgID)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/resend/resend-go/v2"
 )
@@ -11,28 +12,41 @@ import (
 // SendWelcomeEmail sends a styled welcome email to a newly created employee.
 // If RESEND_API_KEY is not set, it logs a warning and returns nil.
 func SendWelcomeEmail(name, toEmail, password, loginURL string) error {
+	html := buildWelcomeHTML(name, toEmail, password, loginURL)
+	if err := send(toEmail, "Welcome to TeamPulse!", html); err != nil {
+		return fmt.Errorf("failed to send welcome email: %w", err)
+	}
+	return nil
+}
+
+// SendPasswordResetEmail sends a single-use password reset link.
+// If RESEND_API_KEY is not set, it logs a warning and returns nil.
+func SendPasswordResetEmail(name, toEmail, resetURL string, validFor time.Duration) error {
+	html := buildPasswordResetHTML(name, resetURL, int(validFor.Minutes()))
+	if err := send(toEmail, "Reset your TeamPulse password", html); err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+	return nil
+}
+
+func send(toEmail, subject, html string) error {
 	apiKey := os.Getenv("RESEND_API_KEY")
 	if apiKey == "" {
-		log.Println("WARN: RESEND_API_KEY not set, skipping welcome email")
+		log.Printf("WARN: RESEND_API_KEY not set, skipping email %q to %s", subject, toEmail)
 		return nil
 	}
 
 	client := resend.NewClient(apiKey)
 
-	html := buildWelcomeHTML(name, toEmail, password, loginURL)
-
 	params := &resend.SendEmailRequest{
 		From:    "TeamPulse <noreply@contact.clearlinemarkets.com>",
 		To:      []string{toEmail},
-		Subject: "Welcome to TeamPulse!",
+		Subject: subject,
 		Html:    html,
 	}
 
 	_, err := client.Emails.Send(params)
-	if err != nil {
-		return fmt.Errorf("failed to send welcome email: %w", err)
-	}
-	return nil
+	return err
 }
 
 func buildWelcomeHTML(name, email, password, loginURL string) string {
@@ -148,7 +162,7 @@ func buildWelcomeHTML(name, email, password, loginURL string) string {
                 </tr>
               </table>
               <p style="margin:24px 0 0;color:#475569;font-size:13px;text-align:center;">
-                We recommend changing your password after your first login.
+                You'll be asked to choose a new password when you first sign in.
               </p>
             </td>
           </tr>
@@ -165,3 +179,64 @@ func buildWelcomeHTML(name, email, password, loginURL string) string {
 </body>
 </html>`, name, email, password, loginURL)
 }
+
+func buildPasswordResetHTML(name, resetURL string, validMinutes int) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin:0;padding:0;background-color:#06060e;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;">
+  <table width="100%%" cellpadding="0" cellspacing="0" style="background-color:#06060e;padding:48px 0;">
+    <tr>
+      <td align="center">
+        <table width="600" cellpadding="0" cellspacing="0" style="background-color:#0f0f1c;border-radius:16px;overflow:hidden;border:1px solid #1a1a2e;">
+          <!-- Header -->
+          <tr>
+            <td style="padding:40px 40px 24px;text-align:center;">
+              <table width="100%%" cellpadding="0" cellspacing="0" style="margin-bottom:32px;">
+                <tr>
+                  <td style="height:3px;background:#22d3ee;border-radius:2px 0 0 2px;width:33%%;"></td>
+                  <td style="height:3px;background:#3b82f6;width:34%%;"></td>
+                  <td style="height:3px;background:#8b5cf6;border-radius:0 2px 2px 0;width:33%%;"></td>
+                </tr>
+              </table>
+              <h1 style="margin:0;font-size:28px;font-weight:800;letter-spacing:-0.5px;color:#22d3ee;">Team<span style="color:#8b5cf6;">Pulse</span></h1>
+            </td>
+          </tr>
+          <!-- Body -->
+          <tr>
+            <td style="padding:8px 40px 40px;">
+              <h2 style="margin:0 0 12px;color:#e2e8f0;font-size:22px;font-weight:700;">Hi %s,</h2>
+              <p style="margin:0 0 28px;color:#94a3b8;font-size:15px;line-height:1.7;">
+                We received a request to reset your TeamPulse password. The link below works once and expires in %d minutes.
+              </p>
+              <!-- CTA Button -->
+              <table width="100%%" cellpadding="0" cellspacing="0">
+                <tr>
+                  <td align="center">
+                    <a href="%s" style="display:inline-block;background:linear-gradient(135deg,#22d3ee,#3b82f6,#8b5cf6);color:#ffffff;text-decoration:none;font-size:16px;font-weight:700;padding:16px 48px;border-radius:12px;letter-spacing:0.3px;">
+                      Reset Password
+                    </a>
+                  </td>
+                </tr>
+              </table>
+              <p style="margin:24px 0 0;color:#475569;font-size:13px;text-align:center;">
+                If you didn't ask for this, you can ignore this email — your password won't change.
+              </p>
+            </td>
+          </tr>
+          <!-- Footer -->
+          <tr>
+            <td style="padding:24px 40px;border-top:1px solid #1a1a2e;text-align:center;">
+              <p style="margin:0;color:#475569;font-size:12px;">&copy; 2026 TeamPulse &middot; Powered by GulfBrick</p>
+            </td>
+          </tr>
+        </table>
+      </td>
+    </tr>
+  </table>
+</body>
+</html>`, name, validMinutes, resetURL)
+}
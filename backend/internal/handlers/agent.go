@@ -33,7 +33,7 @@ func GenerateSetupToken(c echo.Context) error {
 	userID := mw.GetUserID(c)
 
 	// Invalidate any existing unused tokens for this user
-	database.DB.Model(&models.AgentSetupToken{}).
+	orgDB(c).Model(&models.AgentSetupToken{}).
 		Where("user_id = ? AND used = false", userID).
 		Update("used", true)
 
@@ -42,7 +42,7 @@ func GenerateSetupToken(c echo.Context) error {
 		Code:      generateCode(),
 		ExpiresAt: time.Now().Add(15 * time.Minute),
 	}
-	database.DB.Create(&token)
+	orgDB(c).Create(&token)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"code":       token.Code,
@@ -56,12 +56,12 @@ func AdminGenerateSetupToken(c echo.Context) error {
 
 	// Verify the employee exists
 	var user models.User
-	if err := database.DB.First(&user, employeeID).Error; err != nil {
+	if err := orgDB(c).First(&user, employeeID).Error; err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "employee not found"})
 	}
 
 	// Invalidate any existing unused tokens for this user
-	database.DB.Model(&models.AgentSetupToken{}).
+	orgDB(c).Model(&models.AgentSetupToken{}).
 		Where("user_id = ? AND used = false", user.ID).
 		Update("used", true)
 
@@ -70,7 +70,7 @@ func AdminGenerateSetupToken(c echo.Context) error {
 		Code:      generateCode(),
 		ExpiresAt: time.Now().Add(15 * time.Minute),
 	}
-	database.DB.Create(&token)
+	orgDB(c).Create(&token)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"code":          token.Code,
@@ -81,10 +81,13 @@ func AdminGenerateSetupToken(c echo.Context) error {
 }
 
 // ExchangeSetupCode is a PUBLIC endpoint (no JWT required).
-// The desktop agent sends the 6-char code and receives a JWT in return.
+// The desktop agent sends the 6-char code and is registered as a Device.
+// It receives a short-lived access token plus a refresh secret for /api/agent/token.
 func ExchangeSetupCode(c echo.Context) error {
 	var req struct {
-		Code string `json:"code"`
+		Code       string `json:"code"`
+		DeviceName string `json:"device_name"`
+		Platform   string `json:"platform"`
 	}
 	if err := c.Bind(&req); err != nil || req.Code == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "code is required"})
@@ -102,16 +105,18 @@ func ExchangeSetupCode(c echo.Context) error {
 	// Mark user as agent setup done
 	database.DB.Model(&models.User{}).Where("id = ?", token.UserID).Update("agent_setup_done", true)
 
-	// Get user and generate JWT
 	var user models.User
-	database.DB.First(&user, token.UserID)
-
-	jwt, err := mw.GenerateToken(user)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+	if err := database.DB.Where("id = ? AND is_active = true", token.UserID).First(&user).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired code"})
 	}
 
-	return c.JSON(http.StatusOK, models.LoginResponse{Token: jwt, User: user})
+	device := models.Device{
+		OrgID:    user.OrgID,
+		UserID:   user.ID,
+		Name:     req.DeviceName,
+		Platform: req.Platform,
+	}
+	return issueDeviceCredentials(c, &device, user)
 }
 
 // GetAgentStatus checks if the current user has completed agent setup.
@@ -119,7 +124,7 @@ func GetAgentStatus(c echo.Context) error {
 	userID := mw.GetUserID(c)
 
 	var user models.User
-	database.DB.First(&user, userID)
+	orgDB(c).First(&user, userID)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"agent_setup_done": user.AgentSetupDone,
@@ -129,7 +134,7 @@ func GetAgentStatus(c echo.Context) error {
 // SkipAgentSetup allows an employee to dismiss the onboarding.
 func SkipAgentSetup(c echo.Context) error {
 	userID := mw.GetUserID(c)
-	database.DB.Model(&models.User{}).Where("id = ?", userID).Update("agent_setup_done", true)
+	orgDB(c).Model(&models.User{}).Where("id = ?", userID).Update("agent_setup_done", true)
 	return c.JSON(http.StatusOK, map[string]string{"status": "skipped"})
 }
 
@@ -188,7 +193,7 @@ func RecordAgentHeartbeat(c echo.Context) error {
 	}
 
 	// Ensure agent_setup_done is true on first heartbeat
-	database.DB.Model(&models.User{}).Where("id = ? AND agent_setup_done = false", userID).Update("agent_setup_done", true)
+	orgDB(c).Model(&models.User{}).Where("id = ? AND agent_setup_done = false", userID).Update("agent_setup_done", true)
 
 	hb := models.AgentHeartbeat{
 		UserID:            userID,
@@ -201,12 +206,12 @@ func RecordAgentHeartbeat(c echo.Context) error {
 		ActiveWindowTitle: req.ActiveWindowTitle,
 		IdleSeconds:       req.IdleSeconds,
 	}
-	database.DB.Create(&hb)
+	orgDB(c).Create(&hb)
 
 	// Broadcast to WebSocket clients
 	var user models.User
-	database.DB.First(&user, userID)
-	BroadcastMonitorUpdate("heartbeat", map[string]interface{}{
+	orgDB(c).First(&user, userID)
+	BroadcastMonitorUpdate(mw.GetOrgID(c), "heartbeat", map[string]interface{}{
 		"user_id":   userID,
 		"user_name": user.Name,
 		"heartbeat": hb,
@@ -215,16 +220,14 @@ func RecordAgentHeartbeat(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"status": "recorded"})
 }
 
-// ─── Admin Endpoints ─────────────────────────────────────────
+// ─── Monitoring Endpoints (activity:view) ────────────────────
 
-// GetAgentMonitor returns aggregated agent monitoring data for each employee (today).
+// GetAgentMonitor returns aggregated agent monitoring data for each employee
+// (today, in the employee's time zone).
 func GetAgentMonitor(c echo.Context) error {
-	today := todayStr()
-	startOfDay, _ := time.Parse("2006-01-02", today)
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
 	var employees []models.User
-	database.DB.Where("is_active = true AND role = ?", models.RoleEmployee).Find(&employees)
+	orgDB(c).Where("is_active = true AND role = ?", models.RoleEmployee).
+		Scopes(scopeToVisible(c, "id", false)).Find(&employees)
 
 	var entries []models.AgentMonitorEntry
 
@@ -233,9 +236,11 @@ func GetAgentMonitor(c echo.Context) error {
 			UserID:   emp.ID,
 			UserName: emp.Name,
 		}
+		loc := userLocation(orgDB(c), emp.ID)
+		startOfDay, endOfDay := dayBounds(todayIn(loc), loc)
 
 		var latest models.AgentHeartbeat
-		if err := database.DB.Where("user_id = ? AND timestamp >= ? AND timestamp < ?", emp.ID, startOfDay, endOfDay).
+		if err := orgDB(c).Where("user_id = ? AND timestamp >= ? AND timestamp < ?", emp.ID, startOfDay, endOfDay).
 			Order("timestamp desc").First(&latest).Error; err == nil {
 			entry.ActiveApp = latest.ActiveApp
 			entry.ActiveWindowTitle = latest.ActiveWindowTitle
@@ -252,7 +257,7 @@ func GetAgentMonitor(c echo.Context) error {
 			Count        int
 		}
 		var sums Sums
-		database.DB.Model(&models.AgentHeartbeat{}).
+		orgDB(c).Model(&models.AgentHeartbeat{}).
 			Select("COALESCE(SUM(mouse_moves),0) as mouse_moves, COALESCE(SUM(mouse_clicks),0) as mouse_clicks, COALESCE(SUM(keystrokes),0) as keystrokes, COALESCE(SUM(scroll_events),0) as scroll_events, COUNT(*) as count").
 			Where("user_id = ? AND timestamp >= ? AND timestamp < ?", emp.ID, startOfDay, endOfDay).
 			Scan(&sums)
@@ -271,11 +276,11 @@ func GetAgentMonitor(c echo.Context) error {
 	return c.JSON(http.StatusOK, entries)
 }
 
-// GetAppUsage returns app usage breakdown for today, grouped by user.
+// GetAppUsage returns app usage breakdown for today (in the caller's time
+// zone), grouped by user.
 func GetAppUsage(c echo.Context) error {
-	today := todayStr()
-	startOfDay, _ := time.Parse("2006-01-02", today)
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	loc := callerLocation(c)
+	startOfDay, endOfDay := dayBounds(todayIn(loc), loc)
 
 	type UserAppCount struct {
 		UserID    uint
@@ -283,16 +288,17 @@ func GetAppUsage(c echo.Context) error {
 		Count     int64
 	}
 	var results []UserAppCount
-	database.DB.Model(&models.AgentHeartbeat{}).
+	orgDB(c).Model(&models.AgentHeartbeat{}).
 		Select("user_id, active_app, COUNT(*) as count").
 		Where("timestamp >= ? AND timestamp < ? AND active_app != ''", startOfDay, endOfDay).
+		Scopes(scopeToVisible(c, "user_id", false)).
 		Group("user_id, active_app").
 		Order("user_id, count desc").
 		Find(&results)
 
 	// Get employee names
 	var users []models.User
-	database.DB.Find(&users)
+	orgDB(c).Find(&users)
 	employeeNames := make(map[uint]string)
 	for _, u := range users {
 		employeeNames[u.ID] = u.Name
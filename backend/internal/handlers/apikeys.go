@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+	"teampulse/internal/policy"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ─── Personal API Keys ───────────────────────────────────────
+
+const (
+	apiKeyDefaultDays = 90
+	apiKeyMaxDays     = 365
+)
+
+// ListMyAPIKeys returns the caller's API keys, including revoked and expired
+// ones, and the scopes a new key can be given
+func ListMyAPIKeys(c echo.Context) error {
+	var keys []models.APIKey
+	orgDB(c).Where("user_id = ?", mw.GetUserID(c)).Order("created_at desc").Find(&keys)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"keys":   keys,
+		"scopes": policy.AllScopes,
+	})
+}
+
+// CreateAPIKey issues a key for the caller. The key itself is only returned
+// here; afterwards just its prefix is shown.
+func CreateAPIKey(c echo.Context) error {
+	var req models.CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required (100 characters max)"})
+	}
+	if len(req.Scopes) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "at least one scope is required"})
+	}
+	for _, s := range req.Scopes {
+		if !policy.ValidScope(s) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown scope " + s})
+		}
+	}
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = apiKeyDefaultDays
+	}
+	if req.ExpiresInDays < 1 || req.ExpiresInDays > apiKeyMaxDays {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "expires_in_days must be between 1 and 365"})
+	}
+
+	raw := mw.APIKeyPrefix + newRefreshSecret()
+	expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+	key := models.APIKey{
+		UserID:    mw.GetUserID(c),
+		Name:      req.Name,
+		Prefix:    raw[:len(mw.APIKeyPrefix)+8],
+		KeyHash:   mw.HashAPIKey(raw),
+		Scopes:    req.Scopes,
+		ExpiresAt: &expiresAt,
+	}
+	if err := orgDB(c).Create(&key).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create api key"})
+	}
+
+	logAudit(key.UserID, "created_api_key", key.UserID, key.Prefix+" "+strings.Join(key.Scopes, ","))
+	return c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{Key: raw, APIKey: key})
+}
+
+// RevokeAPIKey revokes one of the caller's keys
+func RevokeAPIKey(c echo.Context) error {
+	return revokeAPIKey(c, mw.GetUserID(c), c.Param("id"))
+}
+
+// ListEmployeeAPIKeys — Admin: an employee's API keys
+func ListEmployeeAPIKeys(c echo.Context) error {
+	var keys []models.APIKey
+	orgDB(c).Where("user_id = ?", c.Param("id")).Order("created_at desc").Find(&keys)
+	return c.JSON(http.StatusOK, keys)
+}
+
+// RevokeEmployeeAPIKey — Admin: revoke an employee's API key
+func RevokeEmployeeAPIKey(c echo.Context) error {
+	var employee models.User
+	if err := orgDB(c).First(&employee, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "employee not found"})
+	}
+	return revokeAPIKey(c, employee.ID, c.Param("keyId"))
+}
+
+func revokeAPIKey(c echo.Context, ownerID uint, keyID string) error {
+	var key models.APIKey
+	if err := orgDB(c).Where("id = ? AND user_id = ?", keyID, ownerID).First(&key).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "api key not found"})
+	}
+
+	if key.RevokedAt == nil {
+		orgDB(c).Model(&key).Update("revoked_at", time.Now())
+		logAudit(mw.GetUserID(c), "revoked_api_key", key.UserID, key.Prefix)
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "revoked"})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ─── Guess Limits ────────────────────────────────────────────
+// Short secrets — MFA codes, kiosk PINs — are guarded by a per-user count of
+// wrong guesses. The count is taken before the secret is checked, in one
+// UPDATE, so guesses sent in parallel can't all slip in under the limit.
+
+// attemptLimit caps wrong guesses at one secret, in two users columns
+type attemptLimit struct {
+	failures    string // count of guesses since the last success or lockout
+	lockedUntil string
+	max         int
+	lockout     time.Duration
+}
+
+// reserve counts a guess by userID and returns its number, or false while
+// the user is locked out or the limit is already used up
+func (l attemptLimit) reserve(db *gorm.DB, userID uint) (int, bool) {
+	var count int
+	res := db.Raw("UPDATE users SET "+l.failures+" = "+l.failures+" + 1"+
+		" WHERE id = ? AND ("+l.lockedUntil+" IS NULL OR "+l.lockedUntil+" <= ?)"+
+		" RETURNING "+l.failures, userID, time.Now()).Scan(&count)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return 0, false
+	}
+	return count, count <= l.max
+}
+
+// settle records how guess number count went: a right one clears the count,
+// and the last wrong one allowed locks the user out
+func (l attemptLimit) settle(db *gorm.DB, userID uint, count int, ok bool) {
+	switch {
+	case ok:
+		db.Exec("UPDATE users SET "+l.failures+" = 0, "+l.lockedUntil+" = NULL WHERE id = ?", userID)
+	case count >= l.max:
+		db.Exec("UPDATE users SET "+l.failures+" = 0, "+l.lockedUntil+" = ? WHERE id = ?",
+			time.Now().Add(l.lockout), userID)
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"teampulse/internal/attendance"
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ─── Attendance ──────────────────────────────────────────────
+// A day's attendance judges each shift starting that day (in the employee's
+// zone) against the sessions overlapping it, and lists sessions filed under
+// that day that fall outside every shift. Nobody is late or a no-show for a
+// shift on one of their holidays, or a day of approved leave, that they
+// didn't work.
+
+// attendanceFor analyses users' attendance on date as of now
+func attendanceFor(db *gorm.DB, orgID uint, users []models.User, date string, now time.Time) []models.AttendanceRecord {
+	records := []models.AttendanceRecord{}
+	if len(users) == 0 {
+		return records
+	}
+	grace := time.Duration(database.GetOrgSettings(orgID).AttendanceGraceMinutes) * time.Minute
+
+	userIDs := make([]uint, len(users))
+	for i, u := range users {
+		userIDs[i] = u.ID
+	}
+	locs := userLocations(db, userIDs)
+	holidays := userHolidays(db, userIDs, date, date)
+	onLeave := leaveByDay(db.Where("user_id IN ?", userIDs), date, date)
+
+	for _, u := range users {
+		dayStart, dayEnd := dayBounds(date, locs[u.ID])
+
+		// Shifts touching the day; an overnight shift from the day before is
+		// only used to place its sessions, not reported
+		var shifts []models.Shift
+		db.Where("user_id = ? AND starts_at < ? AND ends_at > ?", u.ID, dayEnd, dayStart).
+			Order("starts_at asc").Find(&shifts)
+
+		// Sessions that could touch those shifts or are filed under the day
+		windowStart, windowEnd := dayStart, dayEnd
+		for _, s := range shifts {
+			if s.StartsAt.Before(windowStart) {
+				windowStart = s.StartsAt
+			}
+			if s.EndsAt.After(windowEnd) {
+				windowEnd = s.EndsAt
+			}
+		}
+		var entries []models.TimeEntry
+		db.Where("user_id = ? AND (date = ? OR (clock_in < ? AND (clock_out IS NULL OR clock_out > ?)))",
+			u.ID, date, windowEnd, windowStart).
+			Order("clock_in asc").Find(&entries)
+
+		if len(shifts) == 0 && len(entries) == 0 {
+			continue
+		}
+
+		planned := make([]attendance.Shift, len(shifts))
+		for i, s := range shifts {
+			planned[i] = attendance.Shift{ID: s.ID, Start: s.StartsAt, End: s.EndsAt}
+		}
+		sessions := make([]attendance.Session, len(entries))
+		entryDate := map[uint]string{}
+		for i, e := range entries {
+			sessions[i] = attendance.Session{ID: e.ID, In: e.ClockIn, Out: e.ClockOut}
+			entryDate[e.ID] = e.Date
+		}
+
+		results, unscheduled := attendance.Analyze(planned, sessions, grace, now)
+		for i, r := range results {
+			shift := shifts[i]
+			if shift.StartsAt.Before(dayStart) {
+				continue
+			}
+			record := models.AttendanceRecord{
+				UserID:           u.ID,
+				UserName:         u.Name,
+				Date:             date,
+				Shift:            &shift,
+				TimeEntryIDs:     r.SessionIDs,
+				Flags:            []string{},
+				LateMinutes:      int(r.LateBy.Minutes()),
+				LeftEarlyMinutes: int(r.LeftEarlyBy.Minutes()),
+			}
+			record.Holiday = holidays[u.ID][date]
+			_, record.OnLeave = onLeave[u.ID][date]
+			if (record.Holiday == "" && !record.OnLeave) || len(r.SessionIDs) > 0 {
+				for _, f := range r.Flags {
+					record.Flags = append(record.Flags, string(f))
+				}
+			}
+			records = append(records, record)
+		}
+		for _, id := range unscheduled {
+			// Sessions from other days only matter if they met one of today's shifts
+			if entryDate[id] != date {
+				continue
+			}
+			records = append(records, models.AttendanceRecord{
+				UserID:       u.ID,
+				UserName:     u.Name,
+				Date:         date,
+				TimeEntryIDs: []uint{id},
+				Flags:        []string{string(attendance.Unscheduled)},
+			})
+		}
+	}
+	return records
+}
+
+// summarizeAttendance counts shifts and flags across records
+func summarizeAttendance(records []models.AttendanceRecord) models.AttendanceSummary {
+	var sum models.AttendanceSummary
+	for _, r := range records {
+		if r.Shift != nil {
+			sum.Scheduled++
+		}
+		for _, f := range r.Flags {
+			switch attendance.Flag(f) {
+			case attendance.Late:
+				sum.Late++
+			case attendance.EarlyDeparture:
+				sum.EarlyDepartures++
+			case attendance.NoShow:
+				sum.NoShows++
+			case attendance.Unscheduled:
+				sum.Unscheduled++
+			}
+		}
+	}
+	return sum
+}
+
+// GetAttendance — session:view: the team's shifts on ?date= (default today)
+// and how each was worked, plus sessions outside any shift
+func GetAttendance(c echo.Context) error {
+	date := c.QueryParam("date")
+	if date == "" {
+		date = todayIn(callerLocation(c))
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "date must be YYYY-MM-DD"})
+	}
+
+	var users []models.User
+	orgDB(c).Where("is_active = true").Scopes(scopeToVisible(c, "id", false)).Find(&users)
+
+	records := attendanceFor(orgDB(c), mw.GetOrgID(c), users, date, time.Now())
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"date":    date,
+		"records": records,
+		"summary": summarizeAttendance(records),
+	})
+}
@@ -5,12 +5,14 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"teampulse/internal/database"
 	"teampulse/internal/email"
 	mw "teampulse/internal/middleware"
 	"teampulse/internal/models"
+	"teampulse/internal/policy"
 
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
@@ -40,12 +42,25 @@ func Login(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
 	}
 
-	token, err := mw.GenerateToken(user)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+	return completeLogin(c, user)
+}
+
+// completeLogin finishes a successful first-factor login (password or SSO):
+// MFA users get a short-lived challenge instead of a session.
+func completeLogin(c echo.Context, user models.User) error {
+	if user.MFAEnabled {
+		challenge, expiresAt, err := mw.GenerateMFAChallenge(user)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+		}
+		return c.JSON(http.StatusOK, models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    challenge,
+			ExpiresAt:   expiresAt,
+		})
 	}
 
-	return c.JSON(http.StatusOK, models.LoginResponse{Token: token, User: user})
+	return startSession(c, user)
 }
 
 // RegisterEmployee - admin creates employee accounts
@@ -75,6 +90,18 @@ func RegisterEmployee(c echo.Context) error {
 	if req.Role == "" {
 		req.Role = models.RoleEmployee
 	}
+	if !validRole(req.Role) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "role must be admin, manager or employee"})
+	}
+	if req.Role != models.RoleEmployee && !mw.Can(c, policy.RoleManage) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "missing permission role:manage"})
+	}
+	if !validManager(c, 0, req.ManagerID) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid manager_id"})
+	}
+	if !validTimeZone(req.TimeZone) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "time_zone must be an IANA zone such as Europe/London"})
+	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -83,7 +110,7 @@ func RegisterEmployee(c echo.Context) error {
 
 	// Check if a deactivated user with this email already exists
 	var user models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err == nil {
+	if err := orgDB(c).Where("email = ?", req.Email).First(&user).Error; err == nil {
 		if user.IsActive {
 			return c.JSON(http.StatusConflict, map[string]string{"error": "email already exists"})
 		}
@@ -92,28 +119,32 @@ func RegisterEmployee(c echo.Context) error {
 		user.Name = req.Name
 		user.Title = req.Title
 		user.Role = req.Role
+		user.ManagerID = req.ManagerID
+		user.TimeZone = req.TimeZone
 		user.IsActive = true
-		database.DB.Save(&user)
+		user.MustChangePassword = true
+		orgDB(c).Save(&user)
 	} else {
 		user = models.User{
-			Email:    req.Email,
-			Password: string(hash),
-			Name:     req.Name,
-			Title:    req.Title,
-			Role:     req.Role,
-			IsActive: true,
+			Email:     req.Email,
+			Password:  string(hash),
+			Name:      req.Name,
+			Title:     req.Title,
+			Role:      req.Role,
+			ManagerID: req.ManagerID,
+			TimeZone:  req.TimeZone,
+			IsActive:  true,
+			// The emailed password is temporary
+			MustChangePassword: true,
 		}
-		if err := database.DB.Create(&user).Error; err != nil {
+		if err := orgDB(c).Create(&user).Error; err != nil {
 			return c.JSON(http.StatusConflict, map[string]string{"error": "email already exists"})
 		}
 	}
 
 	// Send welcome email in background (don't block the response)
 	plainPassword := req.Password
-	loginURL := os.Getenv("APP_URL")
-	if loginURL == "" {
-		loginURL = "https://teampulse-production-c56d.up.railway.app"
-	}
+	loginURL := appURL()
 	go func() {
 		if err := email.SendWelcomeEmail(req.Name, req.Email, plainPassword, loginURL); err != nil {
 			log.Printf("ERROR: welcome email to %s failed: %v", req.Email, err)
@@ -126,25 +157,57 @@ func RegisterEmployee(c echo.Context) error {
 	return c.JSON(http.StatusCreated, user)
 }
 
+// appURL is the public base URL used in emailed links
+func appURL() string {
+	if url := os.Getenv("APP_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "https://teampulse-production-c56d.up.railway.app"
+}
+
 func GetMe(c echo.Context) error {
 	userID := mw.GetUserID(c)
 	var user models.User
-	if err := database.DB.First(&user, userID).Error; err != nil {
+	if err := orgDB(c).First(&user, userID).Error; err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
 	}
+	user.MFAEnrollmentRequired = mw.MFAEnrollmentRequired(user)
+	user.Permissions = mw.Permissions(user).List()
 	return c.JSON(http.StatusOK, user)
 }
 
+// ListEmployees — Admin/Manager: managers only get their reporting line
 func ListEmployees(c echo.Context) error {
 	var users []models.User
-	database.DB.Where("is_active = true").Order("name asc").Find(&users)
+	orgDB(c).Where("is_active = true").
+		Scopes(scopeToVisible(c, "id", false)).
+		Order("name asc").Find(&users)
 	return c.JSON(http.StatusOK, users)
 }
 
+func validRole(role models.Role) bool {
+	return role == models.RoleAdmin || role == models.RoleManager || role == models.RoleEmployee
+}
+
+// editableEmployeeFields are the user columns UpdateEmployee may change
+var editableEmployeeFields = map[string]bool{
+	"name":             true,
+	"email":            true,
+	"title":            true,
+	"role":             true,
+	"custom_role_id":   true,
+	"manager_id":       true,
+	"time_zone":        true,
+	"location":         true,
+	"pin":              true,
+	"is_active":        true,
+	"agent_setup_done": true,
+}
+
 func UpdateEmployee(c echo.Context) error {
 	id := c.Param("id")
 	var user models.User
-	if err := database.DB.First(&user, id).Error; err != nil {
+	if err := orgDB(c).First(&user, id).Error; err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
 	}
 
@@ -153,12 +216,97 @@ func UpdateEmployee(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
 	}
 
-	// Don't allow password update through this endpoint
-	delete(updates, "password")
-	delete(updates, "id")
+	// Only profile and access fields; credentials, MFA, SSO links and the org
+	// have their own flows
+	for key := range updates {
+		if !editableEmployeeFields[key] {
+			delete(updates, key)
+		}
+	}
+
+	emailChanged := false
+	if raw, ok := updates["email"]; ok {
+		addr, isString := raw.(string)
+		if !isString || !emailRegex.MatchString(addr) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid email format"})
+		}
+		emailChanged = !strings.EqualFold(addr, user.Email)
+	}
+
+	// Email and PIN lead to the account itself (via forgot-password or the
+	// kiosk), so only someone who could already do all the target can may
+	// change them
+	_, pinChange := updates["pin"]
+	if (emailChanged || pinChange) && !mw.Can(c, policy.RoleManage) {
+		mine, _ := c.Get("permissions").(policy.Set)
+		if !mine.Covers(mw.Permissions(user)) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "changing this user's email or pin needs role:manage"})
+		}
+	}
+
+	// pin: a 6-digit kiosk PIN, stored hashed; "" or null clears it
+	if raw, ok := updates["pin"]; ok {
+		pin, _ := raw.(string)
+		hash, msg := hashPIN(pin)
+		if msg != "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+		}
+		updates["pin"] = hash
+		updates["pin_failures"] = 0
+		updates["pin_locked_until"] = nil
+	}
+
+	if raw, ok := updates["time_zone"]; ok {
+		tz, isString := raw.(string)
+		if !isString || !validTimeZone(tz) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "time_zone must be an IANA zone such as Europe/London"})
+		}
+	}
+
+	if raw, ok := updates["location"]; ok {
+		location, isString := raw.(string)
+		if !isString || len(strings.TrimSpace(location)) > 100 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "location must be text, 100 characters max"})
+		}
+		updates["location"] = strings.TrimSpace(location)
+	}
 
-	database.DB.Model(&user).Updates(updates)
-	database.DB.First(&user, id)
+	// Changing what someone may do is reserved for role:manage
+	_, roleChange := updates["role"]
+	_, customRoleChange := updates["custom_role_id"]
+	if (roleChange || customRoleChange) && !mw.Can(c, policy.RoleManage) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "missing permission role:manage"})
+	}
+	if role, ok := updates["role"]; ok {
+		r, _ := role.(string)
+		if !validRole(models.Role(r)) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "role must be admin, manager or employee"})
+		}
+	}
+	if raw, ok := updates["custom_role_id"]; ok && raw != nil {
+		f, isNum := raw.(float64)
+		if !isNum || orgDB(c).First(&models.CustomRole{}, uint(f)).Error != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid custom_role_id"})
+		}
+		updates["custom_role_id"] = uint(f)
+	}
+
+	// manager_id: null clears it; otherwise it must not create a reporting loop
+	if raw, ok := updates["manager_id"]; ok && raw != nil {
+		f, isNum := raw.(float64)
+		managerID := uint(f)
+		if !isNum || !validManager(c, user.ID, &managerID) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid manager_id"})
+		}
+		updates["manager_id"] = managerID
+	}
+
+	orgDB(c).Model(&user).Updates(updates)
+	if emailChanged {
+		// Sessions signed in under the old address shouldn't outlive it
+		revokeUserCredentials(user.ID)
+	}
+	orgDB(c).First(&user, id)
 	return c.JSON(http.StatusOK, user)
 }
 
@@ -168,19 +316,39 @@ func DeactivateEmployee(c echo.Context) error {
 	// Check if hard delete requested
 	if c.QueryParam("hard") == "true" {
 		// Delete all related data first
-		database.DB.Where("user_id = ?", id).Delete(&models.TimeEntry{})
-		database.DB.Where("user_id = ?", id).Delete(&models.ActivityPing{})
-		database.DB.Where("user_id = ?", id).Delete(&models.TaskTime{})
-		database.DB.Where("user_id = ?", id).Delete(&models.KPI{})
-		database.DB.Where("user_id = ?", id).Delete(&models.Standup{})
-		database.DB.Where("user_id = ?", id).Delete(&models.AgentSetupToken{})
-		database.DB.Where("user_id = ?", id).Delete(&models.AgentHeartbeat{})
-		database.DB.Where("user_id = ?", id).Delete(&models.ActivitySegment{})
-		database.DB.Where("user_id = ?", id).Delete(&models.DailyAggregation{})
-		database.DB.Unscoped().Where("id = ?", id).Delete(&models.User{})
+		orgDB(c).Where("time_entry_id IN (?)", orgDB(c).Model(&models.TimeEntry{}).Select("id").Where("user_id = ?", id)).
+			Delete(&models.TimeEntryRevision{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.TimeEntryCorrection{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.Timesheet{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.Shift{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.LeaveRequest{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.LeaveBalance{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.ClockInPolicy{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.TimeEntry{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.Break{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.ActivityPing{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.TaskTime{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.KPI{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.Standup{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.AgentSetupToken{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.Device{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.Session{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.APIKey{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.MFARecoveryCode{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.AgentHeartbeat{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.ActivitySegment{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.SegmentBatch{})
+		orgDB(c).Where("user_id = ?", id).Delete(&models.DailyAggregation{})
+		orgDB(c).Unscoped().Where("id = ?", id).Delete(&models.User{})
 		return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
 	}
 
-	database.DB.Model(&models.User{}).Where("id = ?", id).Update("is_active", false)
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid employee id"})
+	}
+
+	orgDB(c).Model(&models.User{}).Where("id = ?", userID).Update("is_active", false)
+	revokeUserCredentials(uint(userID))
 	return c.JSON(http.StatusOK, map[string]string{"status": "deactivated"})
 }
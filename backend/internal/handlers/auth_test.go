@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"teampulse/internal/models"
+	"teampulse/internal/policy"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUpdateEmployeeCredentialFields(t *testing.T) {
+	manager := models.User{ID: 2, OrgID: 1, Role: models.RoleManager, IsActive: true}
+	admin := models.User{ID: 1, OrgID: 1, Role: models.RoleAdmin, IsActive: true}
+	// A manager given employee:manage across the org, but not role:manage
+	managerPerms := policy.Resolve(models.RoleManager, []string{string(policy.EmployeeManage), string(policy.ScopeAll)})
+
+	tests := []struct {
+		name       string
+		caller     models.User
+		perms      policy.Set
+		targetRole models.Role
+		body       string
+		status     int
+		revoked    bool
+	}{
+		{"manager renames an admin", manager, managerPerms, models.RoleAdmin, `{"name":"Root"}`, http.StatusOK, false},
+		{"manager changes an admin's email", manager, managerPerms, models.RoleAdmin, `{"email":"mine@example.com"}`, http.StatusForbidden, false},
+		{"manager sets an admin's pin", manager, managerPerms, models.RoleAdmin, `{"pin":"123456"}`, http.StatusForbidden, false},
+		{"manager changes a manager's email", manager, managerPerms, models.RoleManager, `{"email":"new@example.com"}`, http.StatusOK, true},
+		{"manager changes an employee's email", manager, managerPerms, models.RoleEmployee, `{"email":"new@example.com"}`, http.StatusOK, true},
+		{"same email in another case", manager, managerPerms, models.RoleAdmin, `{"email":"TARGET@example.com"}`, http.StatusOK, false},
+		{"admin changes an admin's email", admin, nil, models.RoleAdmin, `{"email":"new@example.com"}`, http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDB(t)
+			target := sqlmock.NewRows([]string{"id", "org_id", "email", "role", "is_active"}).
+				AddRow(9, 1, "target@example.com", tt.targetRole, true)
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).WillReturnRows(target)
+			if tt.status == http.StatusOK {
+				mock.ExpectExec(`UPDATE "users" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+				if tt.revoked {
+					for _, table := range []string{"sessions", "devices", "api_keys"} {
+						mock.ExpectExec(`UPDATE "`+table+`" SET "revoked_at"=\$1 WHERE user_id = \$2 AND revoked_at IS NULL`).
+							WithArgs(sqlmock.AnyArg(), 9).WillReturnResult(sqlmock.NewResult(0, 1))
+					}
+				}
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+			}
+
+			c, rec := newContext(http.MethodPut, "/api/employees/9", tt.body, &tt.caller)
+			if tt.perms != nil {
+				c.Set("permissions", tt.perms)
+			}
+			c.SetParamNames("id")
+			c.SetParamValues("9")
+
+			if err := UpdateEmployee(c); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.status {
+				t.Errorf("got %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+		})
+	}
+}
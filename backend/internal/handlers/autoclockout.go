@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"teampulse/internal/database"
+	"teampulse/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ─── Auto Clock-Out ──────────────────────────────────────────
+// Forgotten sessions would otherwise stay open forever and keep adding hours
+// to the dashboard. A background job closes them per each org's settings and
+// flags them so the employee or a manager can correct the times.
+
+const (
+	AutoClockOutInterval = 5 * time.Minute
+
+	autoCloseMaxLength  = "max_length"
+	autoCloseNoActivity = "no_activity"
+)
+
+// RunAutoClockOut closes overdue sessions every interval. It never returns.
+func RunAutoClockOut(interval time.Duration) {
+	for {
+		autoClockOut(time.Now())
+		time.Sleep(interval)
+	}
+}
+
+func autoClockOut(now time.Time) {
+	var orgs []models.Organization
+	database.DB.Where("is_active = true").Find(&orgs)
+
+	for _, org := range orgs {
+		settings := database.GetOrgSettings(org.ID)
+		if settings.MaxSessionHours <= 0 && settings.IdleClockOutMinutes <= 0 {
+			continue
+		}
+
+		db := database.ForOrg(org.ID)
+		var open []models.TimeEntry
+		db.Where("clock_out IS NULL").Find(&open)
+
+		for _, entry := range open {
+			at, reason := autoCloseTime(db, settings, entry, now)
+			if reason == "" {
+				continue
+			}
+			entry.AutoClosed = true
+			entry.AutoCloseReason = reason
+			if !closeTimeEntry(db, &entry, at) {
+				continue // clocked out since it was loaded
+			}
+			log.Printf("auto clock-out: entry %d (user %d) closed at %s (%s)", entry.ID, entry.UserID, at.Format(time.RFC3339), reason)
+		}
+	}
+}
+
+// autoCloseTime decides whether an open entry is overdue and when it should
+// be considered to have ended
+func autoCloseTime(db *gorm.DB, settings models.OrgSettings, entry models.TimeEntry, now time.Time) (time.Time, string) {
+	if settings.MaxSessionHours > 0 {
+		limit := entry.ClockIn.Add(time.Duration(settings.MaxSessionHours) * time.Hour)
+		if now.After(limit) {
+			return limit, autoCloseMaxLength
+		}
+	}
+
+	if settings.IdleClockOutMinutes > 0 {
+		// Only agent users report continuously; browser-only sessions are
+		// left to the length limit
+		var devices int64
+		db.Model(&models.Device{}).Where("user_id = ? AND revoked_at IS NULL", entry.UserID).Count(&devices)
+		if devices == 0 {
+			return time.Time{}, ""
+		}
+
+		last := lastAgentReport(db, entry)
+		if now.Sub(last) > time.Duration(settings.IdleClockOutMinutes)*time.Minute {
+			return last, autoCloseNoActivity
+		}
+	}
+	return time.Time{}, ""
+}
+
+// lastAgentReport is the latest heartbeat or segment end since clock-in, or
+// the clock-in itself if the agent never reported
+func lastAgentReport(db *gorm.DB, entry models.TimeEntry) time.Time {
+	last := entry.ClockIn
+
+	var heartbeat, segment struct{ At *time.Time }
+	db.Model(&models.AgentHeartbeat{}).Select("MAX(timestamp) AS at").
+		Where("user_id = ? AND timestamp >= ?", entry.UserID, entry.ClockIn).Scan(&heartbeat)
+	db.Model(&models.ActivitySegment{}).Select("MAX(end_time) AS at").
+		Where("user_id = ? AND end_time >= ?", entry.UserID, entry.ClockIn).Scan(&segment)
+
+	for _, t := range []*time.Time{heartbeat.At, segment.At} {
+		if t != nil && t.After(last) {
+			last = *t
+		}
+	}
+	return last
+}
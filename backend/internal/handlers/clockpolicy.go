@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+	"teampulse/internal/netpolicy"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ─── Clock-In Networks ───────────────────────────────────────
+// Policies restrict clock-ins to allowed IP ranges for a user or role. A
+// reject-mode policy refuses a clock-in from anywhere else; a flag-mode one
+// lets it through marked off_network for an approver to review. The source
+// address of every clock-in is kept on the time entry.
+
+var errOffNetwork = errors.New("clock-in is not allowed from this network")
+
+// clockInPolicies are the policies governing userID: those naming the user,
+// or failing that those for their role or custom role
+func clockInPolicies(db *gorm.DB, userID uint) []models.ClockInPolicy {
+	var own []models.ClockInPolicy
+	db.Where("user_id = ?", userID).Find(&own)
+	if len(own) > 0 {
+		return own
+	}
+
+	var user models.User
+	if db.Select("id", "org_id", "role", "custom_role_id").First(&user, userID).Error != nil {
+		return nil
+	}
+	var byRole []models.ClockInPolicy
+	q := db.Where("user_id IS NULL")
+	if user.CustomRoleID != nil {
+		q = q.Where("role = ? OR custom_role_id = ?", user.Role, *user.CustomRoleID)
+	} else {
+		q = q.Where("role = ?", user.Role)
+	}
+	q.Find(&byRole)
+	return byRole
+}
+
+// checkNetwork decides a clock-in from ip: allowed, allowed but off-network,
+// or refused with errOffNetwork. Users under no policy may clock in anywhere.
+func checkNetwork(db *gorm.DB, userID uint, ip string) (bool, error) {
+	policies := clockInPolicies(db, userID)
+	if len(policies) == 0 {
+		return false, nil
+	}
+	reject := false
+	for _, p := range policies {
+		if netpolicy.Allowed(p.CIDRs, ip) {
+			return false, nil
+		}
+		reject = reject || p.Mode != models.ClockInFlag
+	}
+	if reject {
+		return false, errOffNetwork
+	}
+	return true, nil
+}
+
+// clockInStatus is the HTTP status for a clockIn error
+func clockInStatus(err error) int {
+	if errors.Is(err, errOffNetwork) {
+		return http.StatusForbidden
+	}
+	return http.StatusConflict
+}
+
+type clockInPolicyRequest struct {
+	Name         string             `json:"name"`
+	UserID       *uint              `json:"user_id"`
+	Role         models.Role        `json:"role"`
+	CustomRoleID *uint              `json:"custom_role_id"`
+	CIDRs        []string           `json:"cidrs"`
+	Mode         models.ClockInMode `json:"mode"`
+}
+
+// validateClockInPolicy normalises a policy request and checks its target
+// and ranges
+func validateClockInPolicy(c echo.Context, req *clockInPolicyRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required (100 characters max)"
+	}
+
+	targets := 0
+	if req.UserID != nil {
+		targets++
+		if orgDB(c).First(&models.User{}, *req.UserID).Error != nil {
+			return "invalid user_id"
+		}
+	}
+	if req.Role != "" {
+		targets++
+		if !validRole(req.Role) {
+			return "role must be admin, manager or employee"
+		}
+	}
+	if req.CustomRoleID != nil {
+		targets++
+		if orgDB(c).First(&models.CustomRole{}, *req.CustomRoleID).Error != nil {
+			return "invalid custom_role_id"
+		}
+	}
+	if targets != 1 {
+		return "set exactly one of user_id, role or custom_role_id"
+	}
+
+	if req.Mode == "" {
+		req.Mode = models.ClockInReject
+	}
+	if req.Mode != models.ClockInReject && req.Mode != models.ClockInFlag {
+		return "mode must be reject or flag"
+	}
+
+	if len(req.CIDRs) == 0 {
+		return "at least one CIDR range is required"
+	}
+	for i, r := range req.CIDRs {
+		normalized, err := netpolicy.Normalize(r)
+		if err != nil {
+			return r + ": " + err.Error()
+		}
+		req.CIDRs[i] = normalized
+	}
+	return ""
+}
+
+// ListClockInPolicies — settings:manage
+func ListClockInPolicies(c echo.Context) error {
+	var policies []models.ClockInPolicy
+	orgDB(c).Order("name asc").Find(&policies)
+	return c.JSON(http.StatusOK, policies)
+}
+
+// CreateClockInPolicy — settings:manage: `{name, user_id | role | custom_role_id, cidrs, mode}`
+func CreateClockInPolicy(c echo.Context) error {
+	var req clockInPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateClockInPolicy(c, &req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	p := models.ClockInPolicy{
+		Name:         req.Name,
+		UserID:       req.UserID,
+		Role:         req.Role,
+		CustomRoleID: req.CustomRoleID,
+		CIDRs:        req.CIDRs,
+		Mode:         req.Mode,
+	}
+	if err := orgDB(c).Create(&p).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "policy name already exists"})
+	}
+	logAudit(mw.GetUserID(c), "created_clock_in_policy", p.ID, p.Name+" "+strings.Join(p.CIDRs, ","))
+	return c.JSON(http.StatusCreated, p)
+}
+
+// UpdateClockInPolicy — settings:manage: same body as create
+func UpdateClockInPolicy(c echo.Context) error {
+	var p models.ClockInPolicy
+	if err := orgDB(c).First(&p, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "policy not found"})
+	}
+
+	var req clockInPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateClockInPolicy(c, &req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	p.Name = req.Name
+	p.UserID = req.UserID
+	p.Role = req.Role
+	p.CustomRoleID = req.CustomRoleID
+	p.CIDRs = req.CIDRs
+	p.Mode = req.Mode
+	if err := orgDB(c).Save(&p).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "policy name already exists"})
+	}
+	logAudit(mw.GetUserID(c), "updated_clock_in_policy", p.ID, p.Name+" "+strings.Join(p.CIDRs, ","))
+	return c.JSON(http.StatusOK, p)
+}
+
+// DeleteClockInPolicy — settings:manage
+func DeleteClockInPolicy(c echo.Context) error {
+	var p models.ClockInPolicy
+	if err := orgDB(c).First(&p, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "policy not found"})
+	}
+	orgDB(c).Delete(&p)
+	logAudit(mw.GetUserID(c), "deleted_clock_in_policy", p.ID, p.Name)
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ReviewOffNetworkEntry — time:approve: clear the off-network flag on a team
+// member's time entry
+func ReviewOffNetworkEntry(c echo.Context) error {
+	var entry models.TimeEntry
+	if err := orgDB(c).Where("id = ? AND off_network = true", c.Param("id")).First(&entry).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "flagged entry not found"})
+	}
+	reviewerID := mw.GetUserID(c)
+	if entry.UserID == reviewerID || !canViewUser(c, entry.UserID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "you can't review this entry"})
+	}
+
+	orgDB(c).Model(&entry).Update("network_reviewer_id", reviewerID)
+	logAudit(reviewerID, "reviewed_off_network_clock_in", entry.UserID, entry.Date+" from "+entry.SourceIP)
+	return c.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+	"teampulse/internal/policy"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ─── Time Entry Corrections ──────────────────────────────────
+// Employees can't edit their time entries. They file a correction, which
+// someone with time:approve over them reviews; approving it rewrites the
+// entry and keeps the old values as a revision.
+
+// maxSessionLength bounds proposed corrections
+const maxSessionLength = 24 * time.Hour
+
+var errCorrectionChanged = errors.New("correction has already been reviewed")
+
+// markReviewed moves a correction out of pending. It fails with
+// errCorrectionChanged if another reviewer got there first.
+func markReviewed(db *gorm.DB, correctionID uint, status models.CorrectionStatus, reviewerID uint, note string) error {
+	res := db.Model(&models.TimeEntryCorrection{}).
+		Where("id = ? AND status = ?", correctionID, models.CorrectionPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewer_id": reviewerID,
+			"review_note": note,
+			"reviewed_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errCorrectionChanged
+	}
+	return nil
+}
+
+// reviseTimeEntry sets an entry's times, recording its previous values.
+// Entries in an approved timesheet can't be moved, nor moved into one.
+func reviseTimeEntry(db *gorm.DB, entry *models.TimeEntry, clockIn, clockOut time.Time, changedBy uint, correctionID *uint, reason string) error {
+	revised := *entry
+	revised.ClockIn = clockIn
+	revised.ClockOut = &clockOut
+	roundPunches(db, &revised)
+	date := entryDate(db, revised)
+	if periodLocked(db, entry.UserID, entry.Date) || periodLocked(db, entry.UserID, date) {
+		return errPeriodLocked
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		revision := models.TimeEntryRevision{
+			TimeEntryID:  entry.ID,
+			ClockIn:      entry.ClockIn,
+			ClockOut:     entry.ClockOut,
+			Duration:     entry.Duration,
+			Date:         entry.Date,
+			ChangedBy:    changedBy,
+			CorrectionID: correctionID,
+			Reason:       reason,
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		// Only break time inside the corrected session is deducted
+		wasOpen := entry.ClockOut == nil
+		endBreak(tx, entry.ID, clockOut)
+		var breaks []models.Break
+		tx.Where("time_entry_id = ?", entry.ID).Find(&breaks)
+		_, unpaid := breakSecondsWithin(breaks, clockIn, clockOut)
+		if wasOpen {
+			stopTaskTimers(tx, entry.UserID, clockOut)
+		}
+
+		entry.ClockIn = clockIn
+		entry.ClockOut = &clockOut
+		entry.RoundedClockIn = revised.RoundedClockIn
+		entry.RoundedClockOut = revised.RoundedClockOut
+		entry.Date = date
+		start, end := paidSpan(*entry)
+		entry.Duration = spanSeconds(start, end, unpaid)
+		return tx.Omit("Breaks", "User").Save(entry).Error
+	})
+}
+
+// RequestCorrection — Employee: propose new times for one of your entries
+// `{clock_in, clock_out, reason}`
+func RequestCorrection(c echo.Context) error {
+	userID := mw.GetUserID(c)
+
+	var entry models.TimeEntry
+	if err := orgDB(c).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&entry).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "time entry not found"})
+	}
+
+	var req models.CorrectionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "reason is required"})
+	}
+	if req.ClockIn.IsZero() || req.ClockOut.IsZero() || !req.ClockOut.After(req.ClockIn) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "clock_out must be after clock_in"})
+	}
+	if req.ClockOut.After(time.Now()) || req.ClockOut.Sub(req.ClockIn) > maxSessionLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "corrected session must be in the past and at most 24 hours long"})
+	}
+
+	if periodLocked(orgDB(c), userID, entry.Date) || periodLocked(orgDB(c), userID, dateIn(req.ClockIn, callerLocation(c))) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": errPeriodLocked.Error()})
+	}
+
+	var pending int64
+	orgDB(c).Model(&models.TimeEntryCorrection{}).
+		Where("time_entry_id = ? AND status = ?", entry.ID, models.CorrectionPending).Count(&pending)
+	if pending > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "a correction for this entry is already pending"})
+	}
+
+	correction := models.TimeEntryCorrection{
+		TimeEntryID:      entry.ID,
+		UserID:           userID,
+		ProposedClockIn:  req.ClockIn,
+		ProposedClockOut: req.ClockOut,
+		Reason:           req.Reason,
+		Status:           models.CorrectionPending,
+	}
+	orgDB(c).Create(&correction)
+
+	return c.JSON(http.StatusCreated, correction)
+}
+
+// ListMyCorrections — Employee: your correction requests, newest first
+func ListMyCorrections(c echo.Context) error {
+	var corrections []models.TimeEntryCorrection
+	orgDB(c).Preload("TimeEntry").Where("user_id = ?", mw.GetUserID(c)).
+		Order("created_at desc").Limit(100).Find(&corrections)
+	return c.JSON(http.StatusOK, corrections)
+}
+
+// ListCorrections — time:approve: the approval queue for the caller's team.
+// ?status= filters (default pending).
+func ListCorrections(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status == "" {
+		status = string(models.CorrectionPending)
+	}
+
+	var corrections []models.TimeEntryCorrection
+	orgDB(c).Preload("User").Preload("TimeEntry").
+		Where("status = ?", status).
+		Scopes(scopeToVisible(c, "user_id", false)).
+		Order("created_at asc").Limit(200).Find(&corrections)
+	return c.JSON(http.StatusOK, corrections)
+}
+
+// loadCorrectionForReview finds a pending correction the caller may decide on
+func loadCorrectionForReview(c echo.Context) (models.TimeEntryCorrection, int, string) {
+	var correction models.TimeEntryCorrection
+	if err := orgDB(c).First(&correction, c.Param("id")).Error; err != nil {
+		return correction, http.StatusNotFound, "correction not found"
+	}
+	if correction.UserID == mw.GetUserID(c) || !canViewUser(c, correction.UserID) {
+		return correction, http.StatusForbidden, "you can't review this correction"
+	}
+	if correction.Status != models.CorrectionPending {
+		return correction, http.StatusConflict, "correction was already " + string(correction.Status)
+	}
+	return correction, 0, ""
+}
+
+// ApproveCorrection — time:approve: apply the proposed times to the entry `{note}`
+func ApproveCorrection(c echo.Context) error {
+	correction, status, msg := loadCorrectionForReview(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	var req models.ReviewRequest
+	c.Bind(&req)
+
+	reviewerID := mw.GetUserID(c)
+	var entry models.TimeEntry
+	var before string
+	err := orgDB(c).Transaction(func(tx *gorm.DB) error {
+		// Claiming the correction first serialises concurrent reviews of it
+		if err := markReviewed(tx, correction.ID, models.CorrectionApproved, reviewerID, req.Note); err != nil {
+			return err
+		}
+		if err := tx.First(&entry, correction.TimeEntryID).Error; err != nil {
+			return err
+		}
+		before = fmt.Sprintf("%s–%s", entry.ClockIn.Format(time.RFC3339), formatClockOut(entry.ClockOut))
+		return reviseTimeEntry(tx, &entry, correction.ProposedClockIn, correction.ProposedClockOut,
+			reviewerID, &correction.ID, correction.Reason)
+	})
+	if errors.Is(err, errCorrectionChanged) || errors.Is(err, errPeriodLocked) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "time entry not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update time entry"})
+	}
+
+	logAudit(reviewerID, "approved_time_correction", correction.UserID,
+		fmt.Sprintf("entry=%d %s -> %s–%s", entry.ID, before, entry.ClockIn.Format(time.RFC3339), formatClockOut(entry.ClockOut)))
+
+	return c.JSON(http.StatusOK, entry)
+}
+
+// RejectCorrection — time:approve: decline a correction `{note}`
+func RejectCorrection(c echo.Context) error {
+	correction, status, msg := loadCorrectionForReview(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	var req models.ReviewRequest
+	c.Bind(&req)
+
+	reviewerID := mw.GetUserID(c)
+	err := markReviewed(orgDB(c), correction.ID, models.CorrectionRejected, reviewerID, req.Note)
+	if errors.Is(err, errCorrectionChanged) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to reject correction"})
+	}
+	logAudit(reviewerID, "rejected_time_correction", correction.UserID, fmt.Sprintf("entry=%d", correction.TimeEntryID))
+
+	return c.JSON(http.StatusOK, map[string]string{"status": string(models.CorrectionRejected)})
+}
+
+// GetEntryRevisions returns an entry's edit history. Visible to its owner and
+// to holders of session:view over them.
+func GetEntryRevisions(c echo.Context) error {
+	var entry models.TimeEntry
+	if err := orgDB(c).First(&entry, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "time entry not found"})
+	}
+	if !canActOn(c, policy.SessionView, entry.UserID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not in your team"})
+	}
+
+	var revisions []models.TimeEntryRevision
+	orgDB(c).Where("time_entry_id = ?", entry.ID).Order("created_at desc").Find(&revisions)
+	return c.JSON(http.StatusOK, revisions)
+}
+
+func formatClockOut(t *time.Time) string {
+	if t == nil {
+		return "open"
+	}
+	return t.Format(time.RFC3339)
+}
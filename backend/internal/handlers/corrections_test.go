@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"teampulse/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectPendingCorrection expects loadCorrectionForReview to find correction
+// 4, filed by user 9, pending
+func expectPendingCorrection(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT \* FROM "time_entry_corrections" WHERE "time_entry_corrections"."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "time_entry_id", "user_id", "status"}).
+			AddRow(4, 11, 9, models.CorrectionPending))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE id = \$1`).
+		WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+}
+
+const markReviewedSQL = `UPDATE "time_entry_corrections" SET .* WHERE id = \$\d+ AND status = \$\d+`
+
+func TestRejectCorrection(t *testing.T) {
+	admin := models.User{ID: 1, OrgID: 1, Role: models.RoleAdmin, IsActive: true}
+
+	tests := []struct {
+		name     string
+		affected int64
+		status   int
+	}{
+		{"pending", 1, http.StatusOK},
+		{"reviewed meanwhile", 0, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDB(t)
+			expectPendingCorrection(mock)
+			mock.ExpectExec(markReviewedSQL).
+				WithArgs("", sqlmock.AnyArg(), 1, models.CorrectionRejected, 4, models.CorrectionPending).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			c, rec := newContext(http.MethodPost, "/api/corrections/4/reject", `{}`, &admin)
+			c.SetParamNames("id")
+			c.SetParamValues("4")
+			if err := RejectCorrection(c); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.status {
+				t.Errorf("got %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+		})
+	}
+}
+
+func TestApproveCorrectionReviewedMeanwhile(t *testing.T) {
+	admin := models.User{ID: 1, OrgID: 1, Role: models.RoleAdmin, IsActive: true}
+
+	mock := mockDB(t)
+	expectPendingCorrection(mock)
+	// Losing the race rolls back before the entry is read or revised
+	mock.ExpectBegin()
+	mock.ExpectExec(markReviewedSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	c, rec := newContext(http.MethodPost, "/api/corrections/4/approve", `{}`, &admin)
+	c.SetParamNames("id")
+	c.SetParamValues("4")
+	if err := ApproveCorrection(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("got %d %s, want %d", rec.Code, rec.Body, http.StatusConflict)
+	}
+}
+
+func TestApproveCorrectionPeriodLocked(t *testing.T) {
+	admin := models.User{ID: 1, OrgID: 1, Role: models.RoleAdmin, IsActive: true}
+
+	mock := mockDB(t)
+	expectPendingCorrection(mock)
+	// The status change shares the revision's transaction, so a locked
+	// period leaves the correction pending. Rounding lookups in between
+	// aren't expected and fall back to defaults.
+	mock.ExpectBegin()
+	mock.ExpectExec(markReviewedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "time_entries" WHERE "time_entries"."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "date"}).AddRow(11, 9, "2026-03-02"))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "timesheets"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	c, rec := newContext(http.MethodPost, "/api/corrections/4/approve", `{}`, &admin)
+	c.SetParamNames("id")
+	c.SetParamValues("4")
+	if err := ApproveCorrection(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("got %d %s, want %d", rec.Code, rec.Body, http.StatusConflict)
+	}
+}
@@ -2,87 +2,119 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
-	"teampulse/internal/database"
 	mw "teampulse/internal/middleware"
 	"teampulse/internal/models"
+	"teampulse/internal/policy"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
+// GetDashboard — Admin/Manager: admins see every employee, managers their reporting line
 func GetDashboard(c echo.Context) error {
-	today := todayStr()
+	loc := callerLocation(c)
+	today := todayIn(loc)
+	dayStart, dayEnd := dayBounds(today, loc)
 
 	var stats models.DashboardStats
 
+	// Admins track employees; a manager's team is everyone reporting to them
+	team := func(db *gorm.DB) *gorm.DB {
+		if mw.Can(c, policy.ScopeAll) {
+			return db.Where("role = ?", models.RoleEmployee)
+		}
+		return db.Scopes(scopeToVisible(c, "id", false))
+	}
+
 	// Total active employees
-	database.DB.Model(&models.User{}).Where("is_active = true AND role = ?", models.RoleEmployee).Count(&stats.TotalEmployees)
+	orgDB(c).Model(&models.User{}).Where("is_active = true").Scopes(team).Count(&stats.TotalEmployees)
 
 	// Currently clocked in
-	database.DB.Model(&models.TimeEntry{}).
+	orgDB(c).Model(&models.TimeEntry{}).
 		Joins("JOIN users ON users.id = time_entries.user_id").
 		Where("time_entries.clock_out IS NULL AND users.is_active = true").
+		Scopes(scopeToVisible(c, "time_entries.user_id", false)).
 		Count(&stats.ClockedIn)
 
-	// Total hours today
+	// Hours worked today per person, counting only the part of overnight
+	// sessions after midnight; ongoing sessions count up to now
 	var entries []models.TimeEntry
-	database.DB.Where("date = ? AND clock_out IS NOT NULL", today).Find(&entries)
+	orgDB(c).Where("date BETWEEN ? AND ?", dayBefore(today), today).
+		Scopes(scopeToVisible(c, "user_id", false)).Find(&entries)
+	locs := userLocations(orgDB(c), entryUserIDs(entries))
+	secondsToday := map[uint]int64{}
 	for _, e := range entries {
-		stats.TotalHoursToday += float64(e.Duration) / 3600.0
+		for _, share := range splitByDay(orgDB(c), e, locs[e.UserID]) {
+			if share.Date == today {
+				secondsToday[e.UserID] += share.Seconds
+			}
+		}
 	}
-	// Add ongoing sessions
-	var activeEntries []models.TimeEntry
-	database.DB.Where("date = ? AND clock_out IS NULL", today).Find(&activeEntries)
-	for _, e := range activeEntries {
-		stats.TotalHoursToday += time.Since(e.ClockIn).Hours()
+	for _, secs := range secondsToday {
+		stats.TotalHoursToday += float64(secs) / 3600.0
 	}
 
 	// Tasks completed today
-	database.DB.Model(&models.Task{}).
-		Where("status = ? AND DATE(completed_at) = ?", models.TaskComplete, today).
+	orgDB(c).Model(&models.Task{}).
+		Where("status = ? AND completed_at >= ? AND completed_at < ?", models.TaskComplete, dayStart, dayEnd).
+		Scopes(scopeToVisible(c, "assignee_id", false)).
 		Count(&stats.TasksDoneToday)
 
 	// Pending tasks
-	database.DB.Model(&models.Task{}).
+	orgDB(c).Model(&models.Task{}).
 		Where("status != ?", models.TaskComplete).
+		Scopes(scopeToVisible(c, "assignee_id", false)).
 		Count(&stats.PendingTasks)
 
 	// Team status
 	var employees []models.User
-	database.DB.Where("is_active = true AND role = ?", models.RoleEmployee).Find(&employees)
+	orgDB(c).Where("is_active = true").Scopes(team).Find(&employees)
+
+	// Attendance against today's schedule
+	records := attendanceFor(orgDB(c), mw.GetOrgID(c), employees, today, time.Now())
+	stats.Attendance = summarizeAttendance(records)
+	flags := map[uint][]string{}
+	for _, r := range records {
+		flags[r.UserID] = append(flags[r.UserID], r.Flags...)
+	}
+	employeeIDs := make([]uint, len(employees))
+	for i, emp := range employees {
+		employeeIDs[i] = emp.ID
+	}
+	holidays := userHolidays(orgDB(c), employeeIDs, today, today)
 
 	for _, emp := range employees {
 		member := models.TeamMember{
-			ID:    emp.ID,
-			Name:  emp.Name,
-			Title: emp.Title,
+			ID:              emp.ID,
+			Name:            emp.Name,
+			Title:           emp.Title,
+			AttendanceFlags: []string{},
+			Holiday:         holidays[emp.ID][today],
+		}
+		if f := flags[emp.ID]; f != nil {
+			member.AttendanceFlags = f
 		}
 
 		// Check clock status
 		var clockEntry models.TimeEntry
-		if err := database.DB.Where("user_id = ? AND clock_out IS NULL", emp.ID).First(&clockEntry).Error; err == nil {
+		if err := orgDB(c).Where("user_id = ? AND clock_out IS NULL", emp.ID).First(&clockEntry).Error; err == nil {
 			member.IsClockedIn = true
 		}
 
 		// Hours today
-		var dayEntries []models.TimeEntry
-		database.DB.Where("user_id = ? AND date = ? AND clock_out IS NOT NULL", emp.ID, today).Find(&dayEntries)
-		for _, e := range dayEntries {
-			member.HoursToday += float64(e.Duration) / 3600.0
-		}
-		if member.IsClockedIn {
-			member.HoursToday += time.Since(clockEntry.ClockIn).Hours()
-		}
+		member.HoursToday = float64(secondsToday[emp.ID]) / 3600.0
 
 		// Activity today
 		var activePings, totalPings int64
-		database.DB.Model(&models.ActivityPing{}).
+		orgDB(c).Model(&models.ActivityPing{}).
 			Joins("JOIN time_entries ON time_entries.id = activity_pings.time_entry_id").
 			Where("time_entries.user_id = ? AND time_entries.date = ?", emp.ID, today).
 			Count(&totalPings)
-		database.DB.Model(&models.ActivityPing{}).
+		orgDB(c).Model(&models.ActivityPing{}).
 			Joins("JOIN time_entries ON time_entries.id = activity_pings.time_entry_id").
 			Where("time_entries.user_id = ? AND time_entries.date = ? AND activity_pings.is_active = true", emp.ID, today).
 			Count(&activePings)
@@ -92,9 +124,9 @@ func GetDashboard(c echo.Context) error {
 
 		// Active task
 		var activeTimer models.TaskTime
-		if err := database.DB.Where("user_id = ? AND stopped_at IS NULL", emp.ID).First(&activeTimer).Error; err == nil {
+		if err := orgDB(c).Where("user_id = ? AND stopped_at IS NULL", emp.ID).First(&activeTimer).Error; err == nil {
 			var task models.Task
-			if err := database.DB.First(&task, activeTimer.TaskID).Error; err == nil {
+			if err := orgDB(c).First(&task, activeTimer.TaskID).Error; err == nil {
 				member.ActiveTask = &task.Title
 			}
 		}
@@ -108,9 +140,14 @@ func GetDashboard(c echo.Context) error {
 // ─── Daily Hours Chart ────────────────────────────────────────
 
 type DailyHoursEntry struct {
-	Date    string  `json:"date"`
-	Hours   float64 `json:"hours"`
-	Entries int     `json:"entries"`
+	Date            string   `json:"date"`
+	Hours           float64  `json:"hours"`
+	RegularHours    float64  `json:"regular_hours"`
+	OvertimeHours   float64  `json:"overtime_hours"`
+	DoubleTimeHours float64  `json:"double_time_hours"`
+	LeaveHours      float64  `json:"leave_hours"` // approved leave, not counted in Hours
+	Entries         int      `json:"entries"`
+	Holidays        []string `json:"holidays,omitempty"` // holiday names for anyone in view
 }
 
 func GetDailyHours(c echo.Context) error {
@@ -121,40 +158,75 @@ func GetDailyHours(c echo.Context) error {
 		}
 	}
 
-	userID := mw.GetUserID(c)
-	role := mw.GetUserRole(c)
+	now := time.Now().In(callerLocation(c))
+	from := now.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+	to := now.Format("2006-01-02")
 
-	result := make([]DailyHoursEntry, days)
-	now := time.Now()
-
-	for i := 0; i < days; i++ {
-		day := now.AddDate(0, 0, -(days-1-i))
-		dateStr := day.Format("2006-01-02")
+	// Sessions from the day before may run past midnight into the range
+	var entries []models.TimeEntry
+	orgDB(c).Where("date BETWEEN ? AND ?", dayBefore(from), to).
+		Scopes(scopeToVisible(c, "user_id", true)).
+		Find(&entries)
+	userIDs := entryUserIDs(entries)
+	buckets := entryBuckets(orgDB(c), overtimeRules(mw.GetOrgID(c)), userIDs, from, to)
+	locs := userLocations(orgDB(c), userIDs)
+
+	type dayTotals struct {
+		seconds int64
+		count   int
+		models.HourBuckets
+	}
+	byDate := map[string]*dayTotals{}
+	day := func(date string) *dayTotals {
+		if byDate[date] == nil {
+			byDate[date] = &dayTotals{}
+		}
+		return byDate[date]
+	}
+	for _, e := range entries {
+		// Active sessions count up to now, less unpaid breaks so far
+		for _, share := range splitByDay(orgDB(c), e, locs[e.UserID]) {
+			t := day(share.Date)
+			t.seconds += share.Seconds
+			addBuckets(&t.HourBuckets, buckets[e.ID][share.Date])
+		}
+		day(e.Date).count++
+	}
 
-		var entries []models.TimeEntry
-		query := database.DB.Where("date = ?", dateStr)
-		if role != models.RoleAdmin {
-			query = query.Where("user_id = ?", userID)
+	leaveSecs := map[string]int64{}
+	for _, byDay := range leaveByDay(orgDB(c).Scopes(scopeToVisible(c, "user_id", true)), from, to) {
+		for date, secs := range byDay {
+			leaveSecs[date] += secs
 		}
-		query.Find(&entries)
-
-		var totalSeconds float64
-		entryCount := 0
-		for _, e := range entries {
-			if e.ClockOut != nil {
-				totalSeconds += float64(e.Duration)
-				entryCount++
-			} else {
-				// Active session: compute elapsed
-				totalSeconds += time.Since(e.ClockIn).Seconds()
-				entryCount++
+	}
+
+	// Holidays of everyone whose hours are in view
+	var viewIDs []uint
+	orgDB(c).Model(&models.User{}).Where("is_active = true").
+		Scopes(scopeToVisible(c, "id", true)).Pluck("id", &viewIDs)
+	holidayNames := map[string][]string{}
+	for _, byDate := range userHolidays(orgDB(c), viewIDs, from, to) {
+		for date, name := range byDate {
+			if !slices.Contains(holidayNames[date], name) {
+				holidayNames[date] = append(holidayNames[date], name)
 			}
 		}
+	}
 
-		result[i] = DailyHoursEntry{
-			Date:    dateStr,
-			Hours:   totalSeconds / 3600.0,
-			Entries: entryCount,
+	result := make([]DailyHoursEntry, days)
+	for i := 0; i < days; i++ {
+		dateStr := now.AddDate(0, 0, -(days - 1 - i)).Format("2006-01-02")
+		result[i] = DailyHoursEntry{Date: dateStr, LeaveHours: float64(leaveSecs[dateStr]) / 3600.0}
+		if names := holidayNames[dateStr]; names != nil {
+			slices.Sort(names)
+			result[i].Holidays = names
+		}
+		if t := byDate[dateStr]; t != nil {
+			result[i].Hours = float64(t.seconds) / 3600.0
+			result[i].RegularHours = float64(t.RegularSeconds) / 3600.0
+			result[i].OvertimeHours = float64(t.OvertimeSeconds) / 3600.0
+			result[i].DoubleTimeHours = float64(t.DoubleTimeSeconds) / 3600.0
+			result[i].Entries = t.count
 		}
 	}
 
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"time"
+
+	"teampulse/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ─── Sessions Across Midnight ────────────────────────────────
+// A session is filed under the day it started, but a night shift's hours
+// belong partly to the next day. Daily figures split each session at the
+// owner's local midnight; listings still show it as one shift.
+
+// dayShare is the part of a session worked on one local day
+type dayShare struct {
+	Date    string
+	Seconds int64
+}
+
+// splitByDay spreads an entry's worked seconds over the local days its
+// rounded span touches, in order. Unpaid breaks come off the day they fell
+// on; the first day absorbs any remainder so the shares add up to
+// workedSeconds.
+func splitByDay(db *gorm.DB, entry models.TimeEntry, loc *time.Location) []dayShare {
+	start, end := paidSpan(entry)
+	if dateIn(end, loc) == dateIn(start, loc) {
+		return []dayShare{{Date: dateIn(start, loc), Seconds: workedSeconds(db, entry)}}
+	}
+
+	var breaks []models.Break
+	db.Where("time_entry_id = ? AND type = ?", entry.ID, models.BreakUnpaid).Find(&breaks)
+
+	var shares []dayShare
+	for from := start; from.Before(end); {
+		date := dateIn(from, loc)
+		_, next := dayBounds(date, loc)
+		to := end
+		if next.Before(end) {
+			to = next
+		}
+
+		secs := to.Sub(from)
+		for _, b := range breaks {
+			breakEnd := end
+			if b.EndedAt != nil {
+				breakEnd = *b.EndedAt
+			}
+			secs -= overlap(b.StartedAt, breakEnd, from, to)
+		}
+		shares = append(shares, dayShare{Date: date, Seconds: int64(secs.Seconds())})
+		from = to
+	}
+
+	rest := workedSeconds(db, entry)
+	for _, s := range shares[1:] {
+		rest -= s.Seconds
+	}
+	shares[0].Seconds = max(rest, 0)
+	return shares
+}
+
+// overlap is how much of [aStart, aEnd) falls within [bStart, bEnd)
+func overlap(aStart, aEnd, bStart, bEnd time.Time) time.Duration {
+	start, end := aStart, aEnd
+	if bStart.After(start) {
+		start = bStart
+	}
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// userLocations resolves the time zones of userIDs
+func userLocations(db *gorm.DB, userIDs []uint) map[uint]*time.Location {
+	locs := map[uint]*time.Location{}
+	if len(userIDs) == 0 {
+		return locs
+	}
+
+	var users []models.User
+	db.Select("id", "org_id", "time_zone").Where("id IN ?", userIDs).Find(&users)
+	orgs := map[uint]*time.Location{}
+	for _, u := range users {
+		if loc := loadZone(u.TimeZone); loc != nil {
+			locs[u.ID] = loc
+			continue
+		}
+		if orgs[u.OrgID] == nil {
+			orgs[u.OrgID] = orgLocation(u.OrgID)
+		}
+		locs[u.ID] = orgs[u.OrgID]
+	}
+	for _, id := range userIDs {
+		if locs[id] == nil {
+			locs[id] = time.UTC
+		}
+	}
+	return locs
+}
+
+// dayBefore is the calendar day before date (YYYY-MM-DD). Sessions filed
+// under it may run past midnight into date.
+func dayBefore(date string) string {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return d.AddDate(0, 0, -1).Format("2006-01-02")
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ─── Device Credentials ──────────────────────────────────────
+
+// newRefreshSecret returns a random 256-bit secret, hex encoded
+func newRefreshSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// hashSecret is used for all stored refresh secrets so a database leak
+// doesn't hand out working credentials
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueDeviceCredentials registers a new device, or rotates an existing
+// one's refresh secret, and returns a fresh access token alongside it.
+func issueDeviceCredentials(c echo.Context, device *models.Device, user models.User) error {
+	secret := newRefreshSecret()
+	now := time.Now()
+	oldHash := device.RefreshHash
+	device.RefreshHash = hashSecret(secret)
+	device.LastSeenAt = &now
+	device.LastIP = c.RealIP()
+
+	if device.ID == 0 {
+		if err := database.DB.Create(device).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to register device"})
+		}
+	} else {
+		// Conditional on the old hash so two concurrent refreshes can't both win
+		result := database.DB.Model(&models.Device{}).
+			Where("id = ? AND refresh_hash = ? AND revoked_at IS NULL", device.ID, oldHash).
+			Updates(map[string]interface{}{
+				"refresh_hash": device.RefreshHash,
+				"last_seen_at": now,
+				"last_ip":      device.LastIP,
+			})
+		if result.RowsAffected == 0 {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or revoked device credentials"})
+		}
+	}
+
+	token, expiresAt, err := mw.GenerateDeviceToken(user, device.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+	}
+
+	return c.JSON(http.StatusOK, models.DeviceAuthResponse{
+		Token:        token,
+		ExpiresAt:    expiresAt,
+		RefreshToken: secret,
+		DeviceID:     device.ID,
+		User:         user,
+	})
+}
+
+// RefreshDeviceToken is a PUBLIC endpoint (no JWT required).
+// The desktop agent trades its refresh secret for a new access token; the
+// secret is rotated on every use so a copied secret only works once.
+func RefreshDeviceToken(c echo.Context) error {
+	var req models.DeviceTokenRequest
+	if err := c.Bind(&req); err != nil || req.DeviceID == 0 || req.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "device_id and refresh_token are required"})
+	}
+
+	var device models.Device
+	err := database.DB.Where("id = ? AND refresh_hash = ? AND revoked_at IS NULL", req.DeviceID, hashSecret(req.RefreshToken)).
+		First(&device).Error
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or revoked device credentials"})
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ? AND is_active = true", device.UserID).First(&user).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user is inactive"})
+	}
+
+	return issueDeviceCredentials(c, &device, user)
+}
+
+// ListEmployeeDevices — Admin: agent installs registered to an employee
+func ListEmployeeDevices(c echo.Context) error {
+	employeeID := c.Param("id")
+
+	var devices []models.Device
+	orgDB(c).Where("user_id = ?", employeeID).Order("created_at desc").Find(&devices)
+
+	return c.JSON(http.StatusOK, devices)
+}
+
+// RevokeDevice — Admin: revoke an agent install. Its refresh secret stops
+// working immediately and outstanding access tokens are rejected by JWTMiddleware.
+func RevokeDevice(c echo.Context) error {
+	employeeID := c.Param("id")
+	deviceID := c.Param("deviceId")
+
+	var device models.Device
+	if err := orgDB(c).Where("id = ? AND user_id = ?", deviceID, employeeID).First(&device).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "device not found"})
+	}
+
+	if device.RevokedAt == nil {
+		now := time.Now()
+		orgDB(c).Model(&device).Update("revoked_at", now)
+		logAudit(mw.GetUserID(c), "revoked_device", device.UserID, "device_id="+deviceID)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"status": "revoked"})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// mockDB points database.DB at a sqlmock connection for one test. Queries
+// are matched as regular expressions, in order, and every expectation must
+// be met by the end of the test.
+func mockDB(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = prev
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	return mock
+}
+
+// newContext builds a request context; a non-nil user is signed in
+func newContext(method, target, body string, user *models.User) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	if user != nil {
+		mw.SetUser(c, *user)
+	}
+	return c, rec
+}
+
+// sqlLog records every statement gorm runs, matched by an expectation or not
+type sqlLog struct {
+	logger.Interface
+	statements []string
+}
+
+func (l *sqlLog) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	l.statements = append(l.statements, sql)
+}
+
+func (l *sqlLog) ran(prefix string) bool {
+	for _, s := range l.statements {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
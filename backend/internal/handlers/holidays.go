@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"teampulse/internal/ical"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ─── Holiday Calendars ───────────────────────────────────────
+// Holders of settings:manage keep holiday calendars, typed in or imported
+// from iCalendar files. A calendar without a location covers everyone in the
+// org; one with a location covers the users whose location matches. Holidays
+// are shown on timelines, hours and timesheets, skipped by leave requests and
+// never count as no-shows.
+
+const maxCalendarUpload = 1 << 20
+
+// userHolidays maps each of userIDs to its holidays from from to to
+// (inclusive), date → name
+func userHolidays(db *gorm.DB, userIDs []uint, from, to string) map[uint]map[string]string {
+	result := map[uint]map[string]string{}
+	if len(userIDs) == 0 {
+		return result
+	}
+
+	var calendars []models.HolidayCalendar
+	db.Order("id asc").Find(&calendars)
+	if len(calendars) == 0 {
+		return result
+	}
+	calendarIDs := make([]uint, len(calendars))
+	for i, cal := range calendars {
+		calendarIDs[i] = cal.ID
+	}
+	var holidays []models.Holiday
+	db.Where("calendar_id IN ? AND date BETWEEN ? AND ?", calendarIDs, from, to).
+		Order("date asc, calendar_id asc").Find(&holidays)
+	if len(holidays) == 0 {
+		return result
+	}
+
+	var users []models.User
+	db.Select("id", "org_id", "location").Where("id IN ?", userIDs).Find(&users)
+	for _, u := range users {
+		covers := map[uint]bool{}
+		for _, cal := range calendars {
+			covers[cal.ID] = cal.Location == "" || strings.EqualFold(cal.Location, u.Location)
+		}
+		for _, h := range holidays {
+			if !covers[h.CalendarID] {
+				continue
+			}
+			if result[u.ID] == nil {
+				result[u.ID] = map[string]string{}
+			}
+			if _, taken := result[u.ID][h.Date]; !taken {
+				result[u.ID][h.Date] = h.Name
+			}
+		}
+	}
+	return result
+}
+
+// holidaySet is the dates of userID's holidays from from to to
+func holidaySet(db *gorm.DB, userID uint, from, to string) map[string]bool {
+	set := map[string]bool{}
+	for date := range userHolidays(db, []uint{userID}, from, to)[userID] {
+		set[date] = true
+	}
+	return set
+}
+
+// ListMyHolidays — Employee: your holidays from ?from= to ?to= (default this year)
+func ListMyHolidays(c echo.Context) error {
+	year := time.Now().In(callerLocation(c)).Year()
+	from := c.QueryParam("from")
+	if from == "" {
+		from = fmt.Sprintf("%d-01-01", year)
+	}
+	to := c.QueryParam("to")
+	if to == "" {
+		to = fmt.Sprintf("%d-12-31", year)
+	}
+
+	if _, err := time.Parse("2006-01-02", from); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "from and to must be YYYY-MM-DD"})
+	}
+	if _, err := time.Parse("2006-01-02", to); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "from and to must be YYYY-MM-DD"})
+	}
+
+	userID := mw.GetUserID(c)
+	byDate := userHolidays(orgDB(c), []uint{userID}, from, to)[userID]
+	holidays := make([]map[string]string, 0, len(byDate))
+	for date, name := range byDate {
+		holidays = append(holidays, map[string]string{"date": date, "name": name})
+	}
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i]["date"] < holidays[j]["date"] })
+	return c.JSON(http.StatusOK, holidays)
+}
+
+type holidayCalendarRequest struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+}
+
+func validateHolidayCalendar(req *holidayCalendarRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Location = strings.TrimSpace(req.Location)
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required (100 characters max)"
+	}
+	if len(req.Location) > 100 {
+		return "location is 100 characters max"
+	}
+	return ""
+}
+
+// ListHolidayCalendars — settings:manage
+func ListHolidayCalendars(c echo.Context) error {
+	var calendars []models.HolidayCalendar
+	orgDB(c).Order("name asc").Find(&calendars)
+	return c.JSON(http.StatusOK, calendars)
+}
+
+// CreateHolidayCalendar — settings:manage: `{name, location}`; no location = whole org
+func CreateHolidayCalendar(c echo.Context) error {
+	var req holidayCalendarRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateHolidayCalendar(&req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	cal := models.HolidayCalendar{Name: req.Name, Location: req.Location}
+	if err := orgDB(c).Create(&cal).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "calendar name already exists"})
+	}
+	logAudit(mw.GetUserID(c), "created_holiday_calendar", cal.ID, cal.Name)
+	return c.JSON(http.StatusCreated, cal)
+}
+
+// loadHolidayCalendar finds the calendar named by :id
+func loadHolidayCalendar(c echo.Context) (models.HolidayCalendar, bool) {
+	var cal models.HolidayCalendar
+	err := orgDB(c).First(&cal, c.Param("id")).Error
+	return cal, err == nil
+}
+
+// UpdateHolidayCalendar — settings:manage: same body as create
+func UpdateHolidayCalendar(c echo.Context) error {
+	cal, ok := loadHolidayCalendar(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "calendar not found"})
+	}
+
+	var req holidayCalendarRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateHolidayCalendar(&req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	cal.Name = req.Name
+	cal.Location = req.Location
+	if err := orgDB(c).Save(&cal).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "calendar name already exists"})
+	}
+	logAudit(mw.GetUserID(c), "updated_holiday_calendar", cal.ID, cal.Name)
+	return c.JSON(http.StatusOK, cal)
+}
+
+// DeleteHolidayCalendar — settings:manage: removes the calendar and its holidays
+func DeleteHolidayCalendar(c echo.Context) error {
+	cal, ok := loadHolidayCalendar(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "calendar not found"})
+	}
+	orgDB(c).Where("calendar_id = ?", cal.ID).Delete(&models.Holiday{})
+	orgDB(c).Delete(&cal)
+	logAudit(mw.GetUserID(c), "deleted_holiday_calendar", cal.ID, cal.Name)
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ListHolidays — settings:manage: a calendar's holidays, optionally for one ?year=
+func ListHolidays(c echo.Context) error {
+	cal, ok := loadHolidayCalendar(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "calendar not found"})
+	}
+
+	q := orgDB(c).Where("calendar_id = ?", cal.ID)
+	if year := c.QueryParam("year"); year != "" {
+		q = q.Where("date LIKE ?", year+"-%")
+	}
+	var holidays []models.Holiday
+	q.Order("date asc").Find(&holidays)
+	return c.JSON(http.StatusOK, holidays)
+}
+
+// AddHoliday — settings:manage: `{date, name}`
+func AddHoliday(c echo.Context) error {
+	cal, ok := loadHolidayCalendar(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "calendar not found"})
+	}
+
+	var req struct {
+		Date string `json:"date"`
+		Name string `json:"name"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "date must be YYYY-MM-DD"})
+	}
+	if req.Name == "" || len(req.Name) > 200 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required (200 characters max)"})
+	}
+
+	holiday := models.Holiday{CalendarID: cal.ID, Date: req.Date, Name: req.Name}
+	if err := orgDB(c).Create(&holiday).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "this calendar already has a holiday on that date"})
+	}
+	return c.JSON(http.StatusCreated, holiday)
+}
+
+// DeleteHoliday — settings:manage
+func DeleteHoliday(c echo.Context) error {
+	cal, ok := loadHolidayCalendar(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "calendar not found"})
+	}
+	var holiday models.Holiday
+	if err := orgDB(c).Where("id = ? AND calendar_id = ?", c.Param("holiday_id"), cal.ID).First(&holiday).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "holiday not found"})
+	}
+	orgDB(c).Delete(&holiday)
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ImportHolidays — settings:manage: add the events of an iCalendar file,
+// sent as the multipart field "file" or as the raw body. Each day of an
+// event becomes a holiday; a day already in the calendar is renamed.
+func ImportHolidays(c echo.Context) error {
+	cal, ok := loadHolidayCalendar(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "calendar not found"})
+	}
+
+	var body io.Reader = c.Request().Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "could not read file"})
+		}
+		defer f.Close()
+		body = f
+	}
+	events, err := ical.Parse(io.LimitReader(body, maxCalendarUpload))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "could not read calendar: " + err.Error()})
+	}
+
+	db := orgDB(c)
+	created, updated := 0, 0
+	for _, ev := range events {
+		name := ev.Summary
+		if name == "" {
+			name = "Holiday"
+		}
+		if len(name) > 200 {
+			name = name[:200]
+		}
+		for _, date := range ev.Days() {
+			var existing models.Holiday
+			if err := db.Where("calendar_id = ? AND date = ?", cal.ID, date).First(&existing).Error; err == nil {
+				db.Model(&existing).Updates(map[string]interface{}{"name": name, "uid": ev.UID})
+				updated++
+				continue
+			}
+			db.Create(&models.Holiday{CalendarID: cal.ID, Date: date, Name: name, UID: ev.UID})
+			created++
+		}
+	}
+
+	logAudit(mw.GetUserID(c), "imported_holidays", cal.ID, fmt.Sprintf("%s: %d new, %d updated", cal.Name, created, updated))
+	return c.JSON(http.StatusOK, map[string]int{
+		"events":  len(events),
+		"created": created,
+		"updated": updated,
+	})
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ─── Kiosk Mode ──────────────────────────────────────────────
+// A kiosk is a shared tablet that clocks employees in and out by PIN. It
+// authenticates with its own secret and never holds a user token.
+
+// pinAttempts allows five wrong PINs before a 15-minute kiosk lockout
+var pinAttempts = attemptLimit{
+	failures:    "pin_failures",
+	lockedUntil: "pin_locked_until",
+	max:         5,
+	lockout:     15 * time.Minute,
+}
+
+var pinRegex = regexp.MustCompile(`^[0-9]{6}$`)
+
+// hashPIN validates and hashes a kiosk PIN; "" clears it
+func hashPIN(pin string) (string, string) {
+	if pin == "" {
+		return "", ""
+	}
+	if !pinRegex.MatchString(pin) {
+		return "", "pin must be exactly 6 digits"
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "failed to hash pin"
+	}
+	return string(hash), ""
+}
+
+// SetMyPIN sets or clears the caller's kiosk PIN `{pin}`
+func SetMyPIN(c echo.Context) error {
+	var req struct {
+		PIN string `json:"pin"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	hash, msg := hashPIN(req.PIN)
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	orgDB(c).Model(&models.User{}).Where("id = ?", mw.GetUserID(c)).Updates(map[string]interface{}{
+		"pin":              hash,
+		"pin_failures":     0,
+		"pin_locked_until": nil,
+	})
+	return c.JSON(http.StatusOK, map[string]bool{"pin_set": hash != ""})
+}
+
+// KioskAuthMiddleware authenticates a kiosk by its bearer secret and confines
+// the request to the kiosk's organization
+func KioskAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		auth := c.Request().Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth || token == "" {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid kiosk credentials"})
+		}
+
+		var kiosk models.Kiosk
+		if err := database.DB.Where("token_hash = ? AND revoked_at IS NULL", hashSecret(token)).First(&kiosk).Error; err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid kiosk credentials"})
+		}
+		if err := database.DB.Where("id = ? AND is_active = true", kiosk.OrgID).First(&models.Organization{}).Error; err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "organization is suspended"})
+		}
+
+		now := time.Now()
+		database.DB.Model(&kiosk).Updates(map[string]interface{}{"last_seen_at": now, "last_ip": c.RealIP()})
+
+		c.Set("org_id", kiosk.OrgID)
+		c.Set("kiosk_id", kiosk.ID)
+		return next(c)
+	}
+}
+
+// KioskEmployees — Kiosk: employees who have a PIN, with their clock status
+func KioskEmployees(c echo.Context) error {
+	var users []models.User
+	orgDB(c).Where("is_active = true AND pin <> ''").Order("name asc").Find(&users)
+
+	var clockedIn []uint
+	orgDB(c).Model(&models.TimeEntry{}).Where("clock_out IS NULL").Pluck("user_id", &clockedIn)
+	in := map[uint]bool{}
+	for _, id := range clockedIn {
+		in[id] = true
+	}
+
+	result := make([]map[string]interface{}, 0, len(users))
+	for _, u := range users {
+		result = append(result, map[string]interface{}{
+			"id":         u.ID,
+			"name":       u.Name,
+			"title":      u.Title,
+			"clocked_in": in[u.ID],
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// verifyKioskPIN checks a user's PIN, counting failures and locking the user
+// out of kiosks for a while after too many
+func verifyKioskPIN(c echo.Context, req models.KioskPINRequest) (models.User, int, string) {
+	var user models.User
+	if err := orgDB(c).Where("is_active = true AND pin <> ''").First(&user, req.UserID).Error; err != nil {
+		return user, http.StatusUnauthorized, "wrong pin"
+	}
+
+	count, allowed := pinAttempts.reserve(orgDB(c), user.ID)
+	if !allowed {
+		return user, http.StatusTooManyRequests, "too many wrong pins, try again later"
+	}
+	valid := bcrypt.CompareHashAndPassword([]byte(user.PIN), []byte(req.PIN)) == nil
+	pinAttempts.settle(orgDB(c), user.ID, count, valid)
+	if !valid {
+		return user, http.StatusUnauthorized, "wrong pin"
+	}
+	return user, 0, ""
+}
+
+// KioskClockIn — Kiosk: clock a user in `{user_id, pin}`
+func KioskClockIn(c echo.Context) error {
+	return kioskClock(c, true)
+}
+
+// KioskClockOut — Kiosk: clock a user out `{user_id, pin}`
+func KioskClockOut(c echo.Context) error {
+	return kioskClock(c, false)
+}
+
+func kioskClock(c echo.Context, in bool) error {
+	var req models.KioskPINRequest
+	if err := c.Bind(&req); err != nil || req.UserID == 0 || req.PIN == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "user_id and pin are required"})
+	}
+
+	user, status, msg := verifyKioskPIN(c, req)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	var entry models.TimeEntry
+	var err error
+	if in {
+		kioskID, _ := c.Get("kiosk_id").(uint)
+		entry, err = clockIn(orgDB(c), user.ID, &kioskID, c.RealIP())
+	} else {
+		entry, err = clockOut(orgDB(c), user.ID)
+	}
+	if err != nil {
+		return c.JSON(clockInStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"name":  user.Name,
+		"entry": entry,
+	})
+}
+
+// ─── Kiosk Registration ──────────────────────────────────────
+
+// ListKiosks — settings:manage: registered kiosks
+func ListKiosks(c echo.Context) error {
+	var kiosks []models.Kiosk
+	orgDB(c).Order("created_at desc").Find(&kiosks)
+	return c.JSON(http.StatusOK, kiosks)
+}
+
+// CreateKiosk — settings:manage: register a kiosk `{name}`. Its secret is only
+// shown in this response; enter it on the tablet.
+func CreateKiosk(c echo.Context) error {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.Bind(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+
+	token := "kiosk_" + newRefreshSecret()
+	kiosk := models.Kiosk{Name: strings.TrimSpace(req.Name), TokenHash: hashSecret(token)}
+	if err := orgDB(c).Create(&kiosk).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to register kiosk"})
+	}
+
+	logAudit(mw.GetUserID(c), "registered_kiosk", 0, kiosk.Name)
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"kiosk": kiosk,
+		"token": token,
+	})
+}
+
+// RevokeKiosk — settings:manage: the kiosk's secret stops working immediately
+func RevokeKiosk(c echo.Context) error {
+	var kiosk models.Kiosk
+	if err := orgDB(c).First(&kiosk, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "kiosk not found"})
+	}
+
+	if kiosk.RevokedAt == nil {
+		orgDB(c).Model(&kiosk).Update("revoked_at", time.Now())
+		logAudit(mw.GetUserID(c), "revoked_kiosk", 0, kiosk.Name)
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "revoked"})
+}
@@ -0,0 +1,512 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"teampulse/internal/leave"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ─── Leave ───────────────────────────────────────────────────
+// Paid leave types accrue hours each completed pay period into a per-user
+// balance. Employees request whole workdays off; an approver with
+// time:approve over them takes the hours from the balance. Approved leave
+// shows up in timesheets, daily hours and payroll.
+
+const (
+	defaultLeaveHoursPerDay = 8
+	maxLeaveSpanDays        = 366
+)
+
+var (
+	errLeaveChanged   = errors.New("leave request has already been reviewed or withdrawn")
+	errLeaveShortfall = errors.New("not enough leave balance")
+)
+
+func leavePolicy(t models.LeaveType) leave.Policy {
+	return leave.Policy{
+		AccrualHours:      t.AccrualHours,
+		MaxBalanceHours:   t.MaxBalanceHours,
+		MaxCarryoverHours: t.MaxCarryoverHours,
+	}
+}
+
+// syncBalance loads or opens userID's balance in a paid leave type and
+// credits any pay periods completed since it was last brought up to date. A
+// new balance accrues from when both the user and the type existed.
+func syncBalance(db *gorm.DB, orgID, userID uint, t models.LeaveType) models.LeaveBalance {
+	schedule := paySchedule(orgID)
+	loc := userLocation(db, userID)
+	today := time.Now().In(loc)
+
+	var bal models.LeaveBalance
+	if err := db.Where("user_id = ? AND leave_type_id = ?", userID, t.ID).First(&bal).Error; err != nil {
+		var user models.User
+		db.Select("id", "created_at").First(&user, userID)
+		since := t.CreatedAt
+		if user.CreatedAt.After(since) {
+			since = user.CreatedAt
+		}
+		start := leave.Start(schedule, since.In(loc))
+		bal = models.LeaveBalance{UserID: userID, LeaveTypeID: t.ID, LastPeriod: start.LastPeriod, Year: start.Year}
+		// Another request may open it at the same moment; keep whichever won
+		db.Clauses(clause.OnConflict{DoNothing: true}).Create(&bal)
+		db.Where("user_id = ? AND leave_type_id = ?", userID, t.ID).First(&bal)
+	}
+
+	// Credit the accrual as a change to hours, and only from the state it was
+	// worked out from, so neither a concurrent sync nor a concurrent deduction
+	// is lost
+	next := leave.Accrue(leavePolicy(t), schedule, leave.Balance{Hours: bal.Hours, LastPeriod: bal.LastPeriod, Year: bal.Year}, today)
+	if next.Hours != bal.Hours || !next.LastPeriod.Equal(bal.LastPeriod) || next.Year != bal.Year {
+		db.Model(&models.LeaveBalance{}).
+			Where("id = ? AND last_period = ? AND year = ?", bal.ID, bal.LastPeriod, bal.Year).
+			Updates(map[string]interface{}{
+				"hours":       gorm.Expr("hours + ?", next.Hours-bal.Hours),
+				"last_period": next.LastPeriod,
+				"year":        next.Year,
+			})
+		db.First(&bal, bal.ID)
+	}
+	bal.LeaveType = t
+	return bal
+}
+
+// leaveBalances brings userID's balances in every active paid type up to date
+func leaveBalances(db *gorm.DB, orgID, userID uint) []models.LeaveBalance {
+	var types []models.LeaveType
+	db.Where("paid = ? AND is_active = ?", true, true).Order("name asc").Find(&types)
+
+	balances := make([]models.LeaveBalance, 0, len(types))
+	for _, t := range types {
+		balances = append(balances, syncBalance(db, orgID, userID, t))
+	}
+	return balances
+}
+
+// leaveByDay is approved leave in seconds per user per day from from to to
+// (inclusive), for the requests q selects
+func leaveByDay(q *gorm.DB, from, to string) map[uint]map[string]int64 {
+	var requests []models.LeaveRequest
+	q.Where("status = ? AND start_date <= ? AND end_date >= ?", models.LeaveApproved, to, from).Find(&requests)
+
+	byUser := map[uint]map[string]int64{}
+	for _, r := range requests {
+		for _, day := range r.Days {
+			if day < from || day > to {
+				continue
+			}
+			if byUser[r.UserID] == nil {
+				byUser[r.UserID] = map[string]int64{}
+			}
+			byUser[r.UserID][day] += int64(r.HoursPerDay * 3600)
+		}
+	}
+	return byUser
+}
+
+// ─── Leave Types ─────────────────────────────────────────────
+
+type leaveTypeRequest struct {
+	Name              string   `json:"name"`
+	Paid              *bool    `json:"paid"`
+	AccrualHours      float64  `json:"accrual_hours"`
+	MaxBalanceHours   float64  `json:"max_balance_hours"`
+	MaxCarryoverHours *float64 `json:"max_carryover_hours"`
+}
+
+// validateLeaveType normalises a leave type request
+func validateLeaveType(req *leaveTypeRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required (100 characters max)"
+	}
+	if req.AccrualHours < 0 || req.AccrualHours > 100 {
+		return "accrual_hours must be between 0 and 100"
+	}
+	if req.MaxBalanceHours < 0 {
+		return "max_balance_hours can't be negative"
+	}
+	if req.MaxCarryoverHours != nil && *req.MaxCarryoverHours < 0 {
+		return "max_carryover_hours can't be negative"
+	}
+	return ""
+}
+
+// ListLeaveTypes — Authenticated: active leave types; ?all=true includes
+// deactivated ones
+func ListLeaveTypes(c echo.Context) error {
+	q := orgDB(c).Order("name asc")
+	if c.QueryParam("all") != "true" {
+		q = q.Where("is_active = ?", true)
+	}
+	var types []models.LeaveType
+	q.Find(&types)
+	return c.JSON(http.StatusOK, types)
+}
+
+// CreateLeaveType — settings:manage: `{name, paid, accrual_hours, max_balance_hours, max_carryover_hours}`
+func CreateLeaveType(c echo.Context) error {
+	var req leaveTypeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateLeaveType(&req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	t := models.LeaveType{
+		Name:              req.Name,
+		Paid:              req.Paid == nil || *req.Paid,
+		AccrualHours:      req.AccrualHours,
+		MaxBalanceHours:   req.MaxBalanceHours,
+		MaxCarryoverHours: req.MaxCarryoverHours,
+		IsActive:          true,
+	}
+	if err := orgDB(c).Create(&t).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "leave type name already exists"})
+	}
+	logAudit(mw.GetUserID(c), "created_leave_type", t.ID, t.Name)
+	return c.JSON(http.StatusCreated, t)
+}
+
+// UpdateLeaveType — settings:manage: same body as create. Changes to accrual
+// apply from the next pay period credited.
+func UpdateLeaveType(c echo.Context) error {
+	var t models.LeaveType
+	if err := orgDB(c).First(&t, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "leave type not found"})
+	}
+
+	var req leaveTypeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateLeaveType(&req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	t.Name = req.Name
+	if req.Paid != nil {
+		t.Paid = *req.Paid
+	}
+	t.AccrualHours = req.AccrualHours
+	t.MaxBalanceHours = req.MaxBalanceHours
+	t.MaxCarryoverHours = req.MaxCarryoverHours
+	if err := orgDB(c).Save(&t).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "leave type name already exists"})
+	}
+	logAudit(mw.GetUserID(c), "updated_leave_type", t.ID, t.Name)
+	return c.JSON(http.StatusOK, t)
+}
+
+// DeactivateLeaveType — settings:manage: stop new requests of this type.
+// Balances and past requests are kept.
+func DeactivateLeaveType(c echo.Context) error {
+	var t models.LeaveType
+	if err := orgDB(c).First(&t, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "leave type not found"})
+	}
+	orgDB(c).Model(&t).Update("is_active", false)
+	logAudit(mw.GetUserID(c), "deactivated_leave_type", t.ID, t.Name)
+	return c.JSON(http.StatusOK, map[string]string{"status": "deactivated"})
+}
+
+// ─── Leave Requests ──────────────────────────────────────────
+
+// GetMyLeaveBalances — Employee: your balance in each paid leave type
+func GetMyLeaveBalances(c echo.Context) error {
+	return c.JSON(http.StatusOK, leaveBalances(orgDB(c), mw.GetOrgID(c), mw.GetUserID(c)))
+}
+
+// RequestLeave — Employee: `{leave_type_id, start_date, end_date, hours_per_day, reason}`.
+// Covers the workdays in the range other than holidays; the balance is checked
+// now and again on approval.
+func RequestLeave(c echo.Context) error {
+	var req models.LeaveRequestBody
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	db := orgDB(c)
+	userID := mw.GetUserID(c)
+
+	var t models.LeaveType
+	if err := db.Where("id = ? AND is_active = ?", req.LeaveTypeID, true).First(&t).Error; err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid leave_type_id"})
+	}
+	start, errStart := time.Parse("2006-01-02", req.StartDate)
+	end, errEnd := time.Parse("2006-01-02", req.EndDate)
+	if errStart != nil || errEnd != nil || end.Before(start) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "start_date and end_date must be YYYY-MM-DD, start first"})
+	}
+	if end.Sub(start) > maxLeaveSpanDays*24*time.Hour {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "leave can span at most a year"})
+	}
+	if req.HoursPerDay == 0 {
+		req.HoursPerDay = defaultLeaveHoursPerDay
+	}
+	if req.HoursPerDay < 0 || req.HoursPerDay > 24 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "hours_per_day must be between 0 and 24"})
+	}
+
+	days := leave.Workdays(req.StartDate, req.EndDate, holidaySet(db, userID, req.StartDate, req.EndDate))
+	if len(days) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "no workdays outside holidays in that range"})
+	}
+
+	var clashes int64
+	db.Model(&models.LeaveRequest{}).
+		Where("user_id = ? AND start_date <= ? AND end_date >= ? AND status IN ?",
+			userID, req.EndDate, req.StartDate, []models.LeaveStatus{models.LeavePending, models.LeaveApproved}).
+		Count(&clashes)
+	if clashes > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "overlaps another leave request"})
+	}
+
+	total := req.HoursPerDay * float64(len(days))
+	if t.Paid {
+		if bal := syncBalance(db, mw.GetOrgID(c), userID, t); bal.Hours < total {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "not enough leave balance"})
+		}
+	}
+
+	r := models.LeaveRequest{
+		UserID:      userID,
+		LeaveTypeID: t.ID,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		HoursPerDay: req.HoursPerDay,
+		Days:        days,
+		TotalHours:  total,
+		Reason:      strings.TrimSpace(req.Reason),
+		Status:      models.LeavePending,
+	}
+	db.Create(&r)
+	r.LeaveType = t
+	return c.JSON(http.StatusCreated, r)
+}
+
+// ListMyLeaveRequests — Employee: your leave requests, newest first
+func ListMyLeaveRequests(c echo.Context) error {
+	var requests []models.LeaveRequest
+	orgDB(c).Preload("LeaveType").Where("user_id = ?", mw.GetUserID(c)).
+		Order("start_date desc").Limit(200).Find(&requests)
+	return c.JSON(http.StatusOK, requests)
+}
+
+// CancelLeaveRequest — Employee: withdraw your pending or approved request.
+// Approved hours go back to the balance unless a covered timesheet is approved.
+func CancelLeaveRequest(c echo.Context) error {
+	db := orgDB(c)
+	var r models.LeaveRequest
+	if err := db.Preload("LeaveType").Where("id = ? AND user_id = ?", c.Param("id"), mw.GetUserID(c)).First(&r).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "leave request not found"})
+	}
+	if r.Status != models.LeavePending && r.Status != models.LeaveApproved {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "leave request is " + string(r.Status)})
+	}
+
+	if r.Status == models.LeaveApproved {
+		for _, day := range r.Days {
+			if periodLocked(db, r.UserID, day) {
+				return c.JSON(http.StatusConflict, map[string]string{"error": errPeriodLocked.Error()})
+			}
+		}
+	}
+
+	// Only the request that moves the status refunds the hours
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.LeaveRequest{}).Where("id = ? AND status = ?", r.ID, r.Status).
+			Update("status", models.LeaveCancelled)
+		if res.RowsAffected == 0 {
+			return errLeaveChanged
+		}
+		if r.Status == models.LeaveApproved && r.LeaveType.Paid {
+			bal := syncBalance(tx, mw.GetOrgID(c), r.UserID, r.LeaveType)
+			return tx.Model(&models.LeaveBalance{}).Where("id = ?", bal.ID).
+				Update("hours", gorm.Expr("hours + ?", r.TotalHours)).Error
+		}
+		return nil
+	})
+	if errors.Is(err, errLeaveChanged) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to cancel leave request"})
+	}
+	db.Preload("LeaveType").First(&r, r.ID)
+	return c.JSON(http.StatusOK, r)
+}
+
+// ListLeaveRequests — time:approve: the team's leave requests by ?status=
+// (default pending), optionally for one ?user_id=
+func ListLeaveRequests(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status == "" {
+		status = string(models.LeavePending)
+	}
+
+	q := orgDB(c).Preload("User").Preload("LeaveType").Where("status = ?", status).
+		Scopes(scopeToVisible(c, "user_id", false))
+	if id := c.QueryParam("user_id"); id != "" {
+		q = q.Where("user_id = ?", id)
+	}
+
+	var requests []models.LeaveRequest
+	q.Order("start_date asc, user_id asc").Limit(500).Find(&requests)
+	return c.JSON(http.StatusOK, requests)
+}
+
+// reviewLeaveRequest loads a pending request the caller may review
+func reviewLeaveRequest(c echo.Context) (models.LeaveRequest, int, string) {
+	var r models.LeaveRequest
+	if err := orgDB(c).Preload("LeaveType").First(&r, c.Param("id")).Error; err != nil {
+		return r, http.StatusNotFound, "leave request not found"
+	}
+	if r.UserID == mw.GetUserID(c) || !canViewUser(c, r.UserID) {
+		return r, http.StatusForbidden, "you can't review this leave request"
+	}
+	if r.Status != models.LeavePending {
+		return r, http.StatusConflict, "leave request is " + string(r.Status)
+	}
+	return r, 0, ""
+}
+
+// ApproveLeaveRequest — time:approve: approve a pending request `{note}`,
+// taking its hours from the balance of a paid type
+func ApproveLeaveRequest(c echo.Context) error {
+	r, status, msg := reviewLeaveRequest(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	db := orgDB(c)
+	for _, day := range r.Days {
+		if periodLocked(db, r.UserID, day) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": errPeriodLocked.Error()})
+		}
+	}
+
+	var req models.ReviewRequest
+	c.Bind(&req)
+
+	// The status moves from pending and the hours come off in one step, so a
+	// repeated approval can't deduct twice
+	reviewerID := mw.GetUserID(c)
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.LeaveRequest{}).Where("id = ? AND status = ?", r.ID, models.LeavePending).
+			Updates(map[string]interface{}{
+				"status":      models.LeaveApproved,
+				"reviewer_id": reviewerID,
+				"reviewed_at": now,
+				"review_note": req.Note,
+			})
+		if res.RowsAffected == 0 {
+			return errLeaveChanged
+		}
+		if !r.LeaveType.Paid {
+			return nil
+		}
+		bal := syncBalance(tx, mw.GetOrgID(c), r.UserID, r.LeaveType)
+		res = tx.Model(&models.LeaveBalance{}).Where("id = ? AND hours >= ?", bal.ID, r.TotalHours).
+			Update("hours", gorm.Expr("hours - ?", r.TotalHours))
+		if res.RowsAffected == 0 {
+			return errLeaveShortfall
+		}
+		return nil
+	})
+	if errors.Is(err, errLeaveChanged) || errors.Is(err, errLeaveShortfall) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to approve leave request"})
+	}
+	db.Preload("LeaveType").First(&r, r.ID)
+
+	logAudit(reviewerID, "approved_leave", r.UserID, r.StartDate+" to "+r.EndDate)
+	return c.JSON(http.StatusOK, r)
+}
+
+// RejectLeaveRequest — time:approve: turn down a pending request `{note}`
+func RejectLeaveRequest(c echo.Context) error {
+	r, status, msg := reviewLeaveRequest(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	var req models.ReviewRequest
+	c.Bind(&req)
+
+	reviewerID := mw.GetUserID(c)
+	now := time.Now()
+	res := orgDB(c).Model(&models.LeaveRequest{}).Where("id = ? AND status = ?", r.ID, models.LeavePending).
+		Updates(map[string]interface{}{
+			"status":      models.LeaveRejected,
+			"reviewer_id": reviewerID,
+			"reviewed_at": now,
+			"review_note": req.Note,
+		})
+	if res.RowsAffected == 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": errLeaveChanged.Error()})
+	}
+	orgDB(c).Preload("LeaveType").First(&r, r.ID)
+
+	logAudit(reviewerID, "rejected_leave", r.UserID, r.StartDate+" to "+r.EndDate)
+	return c.JSON(http.StatusOK, r)
+}
+
+// ─── Leave Balances ──────────────────────────────────────────
+
+// GetLeaveBalances — time:approve: a team member's balances, by ?user_id=
+func GetLeaveBalances(c echo.Context) error {
+	var user models.User
+	if err := orgDB(c).First(&user, c.QueryParam("user_id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+	if !canViewUser(c, user.ID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not in your team"})
+	}
+	return c.JSON(http.StatusOK, leaveBalances(orgDB(c), mw.GetOrgID(c), user.ID))
+}
+
+// AdjustLeaveBalance — settings:manage: `{user_id, leave_type_id, hours, note}`
+// adds hours to a balance (negative to deduct), e.g. for an opening balance
+func AdjustLeaveBalance(c echo.Context) error {
+	var req models.LeaveAdjustRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if req.Hours == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "hours is required"})
+	}
+	db := orgDB(c)
+
+	var user models.User
+	if err := db.First(&user, req.UserID).Error; err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user_id"})
+	}
+	var t models.LeaveType
+	if err := db.Where("id = ? AND paid = ?", req.LeaveTypeID, true).First(&t).Error; err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid leave_type_id"})
+	}
+
+	bal := syncBalance(db, mw.GetOrgID(c), user.ID, t)
+	db.Model(&bal).Update("hours", gorm.Expr("hours + ?", req.Hours))
+	db.First(&bal, bal.ID)
+	bal.LeaveType = t
+
+	details := t.Name + " " + formatHours(int64(req.Hours*3600)) + "h"
+	if req.Note != "" {
+		details += ": " + req.Note
+	}
+	logAudit(mw.GetUserID(c), "adjusted_leave_balance", user.ID, details)
+	return c.JSON(http.StatusOK, bal)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"teampulse/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+)
+
+// expectPendingLeave expects reviewLeaveRequest to find request 4, 16 hours
+// of a paid type filed by user 9, pending
+func expectPendingLeave(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT \* FROM "leave_requests" WHERE "leave_requests"."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "leave_type_id", "total_hours", "status"}).
+			AddRow(4, 9, 2, 16.0, models.LeavePending))
+	mock.ExpectQuery(`SELECT \* FROM "leave_types" WHERE "leave_types"."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "paid"}).AddRow(2, true))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE id = \$1`).
+		WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+}
+
+const reviewLeaveSQL = `UPDATE "leave_requests" SET .* WHERE id = \$\d+ AND status = \$\d+`
+
+func leaveReviewContext(action string) (echo.Context, *httptest.ResponseRecorder) {
+	admin := models.User{ID: 1, OrgID: 1, Role: models.RoleAdmin, IsActive: true}
+	c, rec := newContext(http.MethodPost, "/api/leave/requests/4/"+action, `{}`, &admin)
+	c.SetParamNames("id")
+	c.SetParamValues("4")
+	return c, rec
+}
+
+func TestApproveLeaveRequestReviewedMeanwhile(t *testing.T) {
+	mock := mockDB(t)
+	expectPendingLeave(mock)
+	// Losing the race rolls back before the balance is touched
+	mock.ExpectBegin()
+	mock.ExpectExec(reviewLeaveSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	c, rec := leaveReviewContext("approve")
+	if err := ApproveLeaveRequest(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("got %d %s, want %d", rec.Code, rec.Body, http.StatusConflict)
+	}
+}
+
+func TestApproveLeaveRequestShortfall(t *testing.T) {
+	mock := mockDB(t)
+	expectPendingLeave(mock)
+	// The deduction finds too few hours, so the approval is rolled back with
+	// it. Accrual lookups in between aren't expected and fall back to defaults.
+	mock.ExpectBegin()
+	mock.ExpectExec(reviewLeaveSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "leave_balances" WHERE user_id = \$1 AND leave_type_id = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "leave_type_id", "hours"}).AddRow(6, 9, 2, 8.0))
+	mock.ExpectExec(`UPDATE "leave_balances" SET "hours"=hours - \$1,"updated_at"=\$2 WHERE id = \$3 AND hours >= \$4`).
+		WithArgs(16.0, sqlmock.AnyArg(), 6, 16.0).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	c, rec := leaveReviewContext("approve")
+	if err := ApproveLeaveRequest(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("got %d %s, want %d", rec.Code, rec.Body, http.StatusConflict)
+	}
+}
+
+func TestRejectLeaveRequestReviewedMeanwhile(t *testing.T) {
+	mock := mockDB(t)
+	expectPendingLeave(mock)
+	mock.ExpectExec(reviewLeaveSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	c, rec := leaveReviewContext("reject")
+	if err := RejectLeaveRequest(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("got %d %s, want %d", rec.Code, rec.Body, http.StatusConflict)
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+	"teampulse/internal/totp"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ─── Two-Factor Authentication (TOTP) ────────────────────────
+
+const recoveryCodeCount = 10
+
+// mfaAttempts allows five wrong codes at login before a 15-minute lockout
+var mfaAttempts = attemptLimit{
+	failures:    "mfa_failures",
+	lockedUntil: "mfa_locked_until",
+	max:         5,
+	lockout:     15 * time.Minute,
+}
+
+// VerifyMFA is a PUBLIC endpoint (no JWT required).
+// It completes a two-step login: the challenge token from Login plus a TOTP
+// or recovery code is exchanged for a real session.
+func VerifyMFA(c echo.Context) error {
+	var req models.MFAVerifyRequest
+	if err := c.Bind(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "mfa_token and code are required"})
+	}
+
+	claims, err := mw.ParseMFAChallenge(req.MFAToken)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ? AND is_active = true AND mfa_enabled = true", claims.UserID).First(&user).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired mfa challenge"})
+	}
+
+	count, allowed := mfaAttempts.reserve(database.DB, user.ID)
+	if !allowed {
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "too many wrong codes, try again later"})
+	}
+	valid := checkMFACode(&user, req.Code)
+	mfaAttempts.settle(database.DB, user.ID, count, valid)
+	if !valid {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid code"})
+	}
+
+	return startSession(c, user)
+}
+
+// EnrollMFA starts enrollment by generating a new secret for the caller.
+// MFA is not enforced until ActivateMFA confirms a code from the app.
+func EnrollMFA(c echo.Context) error {
+	userID := mw.GetUserID(c)
+
+	var user models.User
+	if err := orgDB(c).First(&user, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+	if user.MFAEnabled {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "mfa already enabled"})
+	}
+
+	secret := totp.GenerateSecret()
+	orgDB(c).Model(&user).Update("totp_secret", secret)
+
+	return c.JSON(http.StatusOK, models.MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURL: totp.URL("TeamPulse", user.Email, secret),
+	})
+}
+
+// ActivateMFA confirms enrollment with a code from the authenticator app and
+// returns one-time recovery codes. They are only ever shown here.
+func ActivateMFA(c echo.Context) error {
+	userID := mw.GetUserID(c)
+
+	var req models.MFACodeRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "code is required"})
+	}
+
+	var user models.User
+	if err := orgDB(c).First(&user, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+	if user.MFAEnabled {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "mfa already enabled"})
+	}
+	if user.TOTPSecret == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "start enrollment first"})
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid code"})
+	}
+
+	orgDB(c).Model(&user).Updates(map[string]interface{}{
+		"mfa_enabled":    true,
+		"totp_last_step": step,
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":         "enabled",
+		"recovery_codes": replaceRecoveryCodes(user.ID),
+	})
+}
+
+// RegenerateRecoveryCodes invalidates the caller's recovery codes and issues new ones
+func RegenerateRecoveryCodes(c echo.Context) error {
+	userID := mw.GetUserID(c)
+
+	var req models.MFACodeRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "code is required"})
+	}
+
+	var user models.User
+	if err := orgDB(c).Where("id = ? AND mfa_enabled = true", userID).First(&user).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "mfa is not enabled"})
+	}
+	if !checkMFACode(&user, req.Code) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid code"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"recovery_codes": replaceRecoveryCodes(user.ID),
+	})
+}
+
+// DisableMFA turns off MFA for the caller, unless org policy requires it
+func DisableMFA(c echo.Context) error {
+	userID := mw.GetUserID(c)
+
+	var req models.MFACodeRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "code is required"})
+	}
+
+	var user models.User
+	if err := orgDB(c).Where("id = ? AND mfa_enabled = true", userID).First(&user).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "mfa is not enabled"})
+	}
+	if user.Role == models.RoleAdmin && database.GetOrgSettings(user.OrgID).RequireAdminMFA {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "mfa is required for admins"})
+	}
+	if !checkMFACode(&user, req.Code) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid code"})
+	}
+
+	clearMFA(user.ID)
+	return c.JSON(http.StatusOK, map[string]string{"status": "disabled"})
+}
+
+// AdminResetMFA — Admin: clear an employee's MFA after a lost device.
+// They are logged out everywhere and must enrol again if policy requires it.
+func AdminResetMFA(c echo.Context) error {
+	employeeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid employee id"})
+	}
+
+	clearMFA(uint(employeeID))
+	revokeUserCredentials(uint(employeeID))
+	logAudit(mw.GetUserID(c), "reset_mfa", uint(employeeID), "")
+
+	return c.JSON(http.StatusOK, map[string]string{"status": "reset"})
+}
+
+// checkMFACode accepts a current TOTP code (each step once) or an unused recovery code
+func checkMFACode(user *models.User, code string) bool {
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now()); ok {
+		// Conditional update so a code can't be replayed, even concurrently
+		result := database.DB.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		return result.RowsAffected == 1
+	}
+
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	result := database.DB.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashSecret(normalized)).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and returns a fresh set
+func replaceRecoveryCodes(userID uint) []string {
+	database.DB.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{})
+
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i] = generateCode() + "-" + generateCode()
+		database.DB.Create(&models.MFARecoveryCode{
+			UserID:   userID,
+			CodeHash: hashSecret(codes[i]),
+		})
+	}
+	return codes
+}
+
+func clearMFA(userID uint) {
+	database.DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"mfa_enabled":    false,
+		"totp_secret":    "",
+		"totp_last_step": 0,
+	})
+	database.DB.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{})
+}
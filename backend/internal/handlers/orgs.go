@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"teampulse/internal/database"
+	"teampulse/internal/email"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ─── Organizations (tenants) ─────────────────────────────────
+
+// orgDB is the database handle for the caller's organization. Every query
+// through it is filtered to that org; see database.ForOrg.
+func orgDB(c echo.Context) *gorm.DB {
+	return database.ForOrg(mw.GetOrgID(c))
+}
+
+var slugRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+type orgSummary struct {
+	models.Organization
+	UserCount int64 `json:"user_count"`
+}
+
+// ListOrganizations — Platform admin: every tenant with its active user count
+func ListOrganizations(c echo.Context) error {
+	var orgs []models.Organization
+	database.DB.Order("name asc").Find(&orgs)
+
+	result := make([]orgSummary, 0, len(orgs))
+	for _, org := range orgs {
+		summary := orgSummary{Organization: org}
+		database.ForOrg(org.ID).Model(&models.User{}).Where("is_active = true").Count(&summary.UserCount)
+		result = append(result, summary)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// CreateOrganization — Platform admin: create a tenant and its first admin,
+// who gets the usual welcome email and must change the password on login
+func CreateOrganization(c echo.Context) error {
+	var req models.CreateOrganizationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	if strings.TrimSpace(req.Name) == "" || !slugRegex.MatchString(req.Slug) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name and a lowercase slug (letters, digits, dashes) are required"})
+	}
+	if req.AdminName == "" || !emailRegex.MatchString(req.AdminEmail) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "admin_name and a valid admin_email are required"})
+	}
+	if msg := validatePassword(req.AdminPassword); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.AdminPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to hash password"})
+	}
+
+	org := models.Organization{Name: strings.TrimSpace(req.Name), Slug: req.Slug, IsActive: true}
+	admin := models.User{
+		Email:              req.AdminEmail,
+		Password:           string(hash),
+		Name:               req.AdminName,
+		Title:              "Administrator",
+		Role:               models.RoleAdmin,
+		IsActive:           true,
+		MustChangePassword: true,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		admin.OrgID = org.ID
+		return tx.Create(&admin).Error
+	})
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "slug or admin email already exists"})
+	}
+	database.GetOrgSettings(org.ID)
+
+	go func() {
+		if err := email.SendWelcomeEmail(req.AdminName, req.AdminEmail, req.AdminPassword, appURL()); err != nil {
+			log.Printf("ERROR: welcome email to %s failed: %v", req.AdminEmail, err)
+		}
+	}()
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"organization": org,
+		"admin":        admin,
+	})
+}
+
+// UpdateOrganization — Platform admin: rename or suspend a tenant. Users of a
+// suspended org are refused on their next request.
+func UpdateOrganization(c echo.Context) error {
+	var org models.Organization
+	if err := database.DB.First(&org, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "organization not found"})
+	}
+
+	var req struct {
+		Name     *string `json:"name"`
+		IsActive *bool   `json:"is_active"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+		org.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.IsActive != nil {
+		if !*req.IsActive && org.ID == mw.GetOrgID(c) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "cannot suspend your own organization"})
+		}
+		org.IsActive = *req.IsActive
+	}
+
+	database.DB.Save(&org)
+	logAudit(mw.GetUserID(c), "updated_organization", org.ID, org.Name)
+	return c.JSON(http.StatusOK, org)
+}
+
+// GetMyOrganization returns the caller's organization
+func GetMyOrganization(c echo.Context) error {
+	var org models.Organization
+	if err := database.DB.First(&org, mw.GetOrgID(c)).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "organization not found"})
+	}
+	return c.JSON(http.StatusOK, org)
+}
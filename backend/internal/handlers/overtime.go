@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"teampulse/internal/database"
+	"teampulse/internal/models"
+	"teampulse/internal/overtime"
+
+	"gorm.io/gorm"
+)
+
+// ─── Overtime ────────────────────────────────────────────────
+
+// overtimeRules reads the org's thresholds
+func overtimeRules(orgID uint) overtime.Rules {
+	settings := database.GetOrgSettings(orgID)
+	return overtime.Rules{
+		DailyOvertime:   int64(settings.DailyOvertimeHours * 3600),
+		DailyDoubleTime: int64(settings.DailyDoubleTimeHours * 3600),
+		WeeklyOvertime:  int64(settings.WeeklyOvertimeHours * 3600),
+	}
+}
+
+// entryDayBuckets holds classified time by entry ID, then by the local day
+// it was worked on
+type entryDayBuckets map[uint]map[string]models.HourBuckets
+
+// total is an entry's classified time over all its days
+func (b entryDayBuckets) total(entryID uint) models.HourBuckets {
+	var sum models.HourBuckets
+	for _, day := range b[entryID] {
+		addBuckets(&sum, day)
+	}
+	return sum
+}
+
+// entryBuckets classifies the worked time of userIDs' entries dated from..to,
+// split at each owner's local midnight. Earlier days of from's workweek are
+// read too so the weekly threshold sees the whole week.
+func entryBuckets(db *gorm.DB, rules overtime.Rules, userIDs []uint, from, to string) entryDayBuckets {
+	result := entryDayBuckets{}
+	if len(userIDs) == 0 {
+		return result
+	}
+
+	var entries []models.TimeEntry
+	db.Where("user_id IN ? AND date BETWEEN ? AND ?", userIDs, dayBefore(overtime.WeekStart(from)), to).
+		Order("user_id asc, clock_in asc").Find(&entries)
+
+	byUser := map[uint][]models.TimeEntry{}
+	for _, e := range entries {
+		byUser[e.UserID] = append(byUser[e.UserID], e)
+	}
+
+	locs := userLocations(db, userIDs)
+	for userID, userEntries := range byUser {
+		var work []overtime.Work
+		var owners []uint
+		for _, e := range userEntries {
+			for _, share := range splitByDay(db, e, locs[userID]) {
+				work = append(work, overtime.Work{Date: share.Date, Seconds: share.Seconds})
+				owners = append(owners, e.ID)
+			}
+		}
+		for i, b := range overtime.Classify(rules, work) {
+			if result[owners[i]] == nil {
+				result[owners[i]] = map[string]models.HourBuckets{}
+			}
+			day := result[owners[i]][work[i].Date]
+			addBuckets(&day, models.HourBuckets{
+				RegularSeconds:    b.Regular,
+				OvertimeSeconds:   b.Overtime,
+				DoubleTimeSeconds: b.DoubleTime,
+			})
+			result[owners[i]][work[i].Date] = day
+		}
+	}
+	return result
+}
+
+// addBuckets accumulates b into total
+func addBuckets(total *models.HourBuckets, b models.HourBuckets) {
+	total.RegularSeconds += b.RegularSeconds
+	total.OvertimeSeconds += b.OvertimeSeconds
+	total.DoubleTimeSeconds += b.DoubleTimeSeconds
+}
+
+// entryUserIDs lists the distinct users of entries
+func entryUserIDs(entries []models.TimeEntry) []uint {
+	seen := map[uint]bool{}
+	var ids []uint
+	for _, e := range entries {
+		if !seen[e.UserID] {
+			seen[e.UserID] = true
+			ids = append(ids, e.UserID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"teampulse/internal/database"
+	"teampulse/internal/email"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordResetTTL is how long an emailed reset link stays valid
+const passwordResetTTL = time.Hour
+
+func validatePassword(password string) string {
+	if len(password) < 8 {
+		return "password must be at least 8 characters"
+	}
+	if len(password) > 72 {
+		return "password must be 72 characters or fewer" // bcrypt limit
+	}
+	return ""
+}
+
+// ChangePassword lets a signed-in user replace their password.
+// Other sessions, agent devices and API keys are revoked; the current session
+// stays.
+func ChangePassword(c echo.Context) error {
+	userID := mw.GetUserID(c)
+
+	var req models.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	var user models.User
+	if err := orgDB(c).First(&user, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "current password is incorrect"})
+	}
+	if msg := validatePassword(req.NewPassword); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "new password must be different"})
+	}
+
+	if err := setPassword(&user, req.NewPassword); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to hash password"})
+	}
+
+	now := time.Now()
+	orgDB(c).Model(&models.Session{}).
+		Where("user_id = ? AND id != ? AND revoked_at IS NULL", userID, mw.GetSessionID(c)).
+		Update("revoked_at", now)
+	orgDB(c).Model(&models.Device{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now)
+	orgDB(c).Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now)
+
+	user.MFAEnrollmentRequired = mw.MFAEnrollmentRequired(user)
+	return c.JSON(http.StatusOK, user)
+}
+
+// ForgotPassword is a PUBLIC endpoint (no JWT required).
+// It emails a single-use reset link. The response is the same whether or not
+// the email is registered so the endpoint can't be used to probe accounts.
+func ForgotPassword(c echo.Context) error {
+	var req models.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil || !emailRegex.MatchString(req.Email) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid email format"})
+	}
+
+	normalizedEmail := strings.ToLower(strings.TrimSpace(req.Email))
+	resp := map[string]string{"status": "if that account exists, a reset link has been sent"}
+
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = ? AND is_active = true", normalizedEmail).First(&user).Error; err != nil {
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	// Only the newest link works
+	now := time.Now()
+	database.DB.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", user.ID).
+		Update("used_at", now)
+
+	secret := newRefreshSecret()
+	token := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashSecret(secret),
+		ExpiresAt: now.Add(passwordResetTTL),
+	}
+	if err := database.DB.Create(&token).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create reset token"})
+	}
+
+	resetURL := appURL() + "/?reset_token=" + secret
+	go func() {
+		if err := email.SendPasswordResetEmail(user.Name, user.Email, resetURL, passwordResetTTL); err != nil {
+			log.Printf("ERROR: password reset email to %s failed: %v", user.Email, err)
+		}
+	}()
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ResetPassword is a PUBLIC endpoint (no JWT required).
+// It consumes a reset token and logs the user out everywhere.
+func ResetPassword(c echo.Context) error {
+	var req models.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil || req.Token == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "token is required"})
+	}
+	if msg := validatePassword(req.NewPassword); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	now := time.Now()
+	var token models.PasswordResetToken
+	err := database.DB.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashSecret(req.Token), now).
+		First(&token).Error
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired reset link"})
+	}
+
+	// Claim the token before changing anything so it can't be replayed concurrently
+	result := database.DB.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired reset link"})
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ? AND is_active = true", token.UserID).First(&user).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired reset link"})
+	}
+
+	if err := setPassword(&user, req.NewPassword); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to hash password"})
+	}
+	revokeUserCredentials(user.ID)
+
+	return c.JSON(http.StatusOK, map[string]string{"status": "password reset"})
+}
+
+// setPassword stores a new bcrypt hash and clears the forced-change flag
+func setPassword(user *models.User, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hash)
+	user.MustChangePassword = false
+	return database.DB.Model(user).Updates(map[string]interface{}{
+		"password":             user.Password,
+		"must_change_password": false,
+	}).Error
+}
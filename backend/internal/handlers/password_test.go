@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"teampulse/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestChangePasswordRevokesOtherCredentials(t *testing.T) {
+	user := models.User{ID: 9, OrgID: 1, Role: models.RoleEmployee, IsActive: true}
+	hash, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := mockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "role", "password"}).AddRow(9, 1, models.RoleEmployee, string(hash)))
+	mock.ExpectExec(`UPDATE "users" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+	// The current session survives; everything else goes
+	mock.ExpectExec(`UPDATE "sessions" SET "revoked_at"=\$1 WHERE user_id = \$2 AND id != \$3 AND revoked_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 9, 4).WillReturnResult(sqlmock.NewResult(0, 2))
+	for _, table := range []string{"devices", "api_keys"} {
+		mock.ExpectExec(`UPDATE "`+table+`" SET "revoked_at"=\$1 WHERE user_id = \$2 AND revoked_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), 9).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	c, rec := newContext(http.MethodPost, "/api/auth/password",
+		`{"current_password":"old-password","new_password":"new-password"}`, &user)
+	c.Set("session_id", uint(4))
+	if err := ChangePassword(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("got %d %s, want %d", rec.Code, rec.Body, http.StatusOK)
+	}
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ─── Payroll Export ──────────────────────────────────────────
+// Payroll gets one CSV row per employee for a pay period. Which columns appear,
+// in what order and under what headers comes from a saved template.
+
+const taskHoursField = "task_hours"
+
+// payrollFields are the columns a template can use, with their default headers
+var payrollFields = map[string]string{
+	"employee_id":       "Employee ID",
+	"name":              "Name",
+	"email":             "Email",
+	"title":             "Title",
+	"period_start":      "Period Start",
+	"period_end":        "Period End",
+	"total_hours":       "Total Hours",
+	"regular_hours":     "Regular Hours",
+	"overtime_hours":    "Overtime Hours",
+	"double_time_hours": "Double Time Hours",
+	"sessions":          "Sessions",
+	"leave_hours":       "Leave Hours",
+	taskHoursField:      "", // one column per task, headed by header + task title
+}
+
+// defaultPayrollColumns is the layout used without a template
+var defaultPayrollColumns = []models.PayrollColumn{
+	{Field: "employee_id"}, {Field: "name"}, {Field: "email"},
+	{Field: "period_start"}, {Field: "period_end"},
+	{Field: "total_hours"}, {Field: "regular_hours"}, {Field: "overtime_hours"}, {Field: "double_time_hours"},
+	{Field: "sessions"}, {Field: "leave_hours"}, {Field: taskHoursField},
+}
+
+type payrollTemplateRequest struct {
+	Name    string                 `json:"name"`
+	Columns []models.PayrollColumn `json:"columns"`
+}
+
+// validatePayrollTemplate normalises a template request and checks its fields
+func validatePayrollTemplate(req *payrollTemplateRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required (100 characters max)"
+	}
+	if len(req.Columns) == 0 {
+		return "at least one column is required"
+	}
+	for _, col := range req.Columns {
+		if _, ok := payrollFields[col.Field]; !ok {
+			return "unknown field " + col.Field
+		}
+	}
+	return ""
+}
+
+// ListPayrollTemplates — time:approve: saved layouts, the default layout and every field
+func ListPayrollTemplates(c echo.Context) error {
+	var templates []models.PayrollTemplate
+	orgDB(c).Order("name asc").Find(&templates)
+
+	fields := make([]string, 0, len(payrollFields))
+	for field := range payrollFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"templates": templates,
+		"default":   defaultPayrollColumns,
+		"fields":    fields,
+	})
+}
+
+// CreatePayrollTemplate — settings:manage: save a column layout `{name, columns: [{field, header}]}`
+func CreatePayrollTemplate(c echo.Context) error {
+	var req payrollTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validatePayrollTemplate(&req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	tmpl := models.PayrollTemplate{Name: req.Name, Columns: req.Columns}
+	if err := orgDB(c).Create(&tmpl).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "template name already exists"})
+	}
+
+	logAudit(mw.GetUserID(c), "created_payroll_template", tmpl.ID, tmpl.Name)
+	return c.JSON(http.StatusCreated, tmpl)
+}
+
+// UpdatePayrollTemplate — settings:manage: rename or re-lay-out a template
+func UpdatePayrollTemplate(c echo.Context) error {
+	var tmpl models.PayrollTemplate
+	if err := orgDB(c).First(&tmpl, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+
+	var req payrollTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validatePayrollTemplate(&req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	tmpl.Name = req.Name
+	tmpl.Columns = req.Columns
+	if err := orgDB(c).Save(&tmpl).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "template name already exists"})
+	}
+
+	logAudit(mw.GetUserID(c), "updated_payroll_template", tmpl.ID, tmpl.Name)
+	return c.JSON(http.StatusOK, tmpl)
+}
+
+// DeletePayrollTemplate — settings:manage
+func DeletePayrollTemplate(c echo.Context) error {
+	var tmpl models.PayrollTemplate
+	if err := orgDB(c).First(&tmpl, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	orgDB(c).Delete(&tmpl)
+
+	logAudit(mw.GetUserID(c), "deleted_payroll_template", tmpl.ID, tmpl.Name)
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// payrollRow is one employee's totals for the period
+type payrollRow struct {
+	user     models.User
+	seconds  int64
+	leave    int64
+	sessions int
+	buckets  models.HourBuckets
+	tasks    map[uint]int64
+}
+
+// ExportPayroll — time:approve: CSV of hours per employee for the pay period
+// containing ?date= (default today), laid out by ?template_id= (default layout
+// without one). Covers active employees and anyone with time in the period.
+func ExportPayroll(c echo.Context) error {
+	date := time.Now().In(callerLocation(c))
+	if d := c.QueryParam("date"); d != "" {
+		parsed, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "date must be YYYY-MM-DD"})
+		}
+		date = parsed
+	}
+
+	columns := defaultPayrollColumns
+	if id := c.QueryParam("template_id"); id != "" {
+		var tmpl models.PayrollTemplate
+		if err := orgDB(c).First(&tmpl, id).Error; err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+		}
+		columns = tmpl.Columns
+	}
+
+	db := orgDB(c)
+	start, end := payPeriod(mw.GetOrgID(c), date)
+
+	// Sessions from the day before may run past midnight into the period
+	var entries []models.TimeEntry
+	db.Where("date BETWEEN ? AND ?", dayBefore(start), end).
+		Scopes(scopeToVisible(c, "user_id", true)).
+		Find(&entries)
+	worked := entryUserIDs(entries)
+	buckets := entryBuckets(db, overtimeRules(mw.GetOrgID(c)), worked, start, end)
+	locs := userLocations(db, worked)
+
+	var users []models.User
+	q := db.Scopes(scopeToVisible(c, "id", true))
+	if len(worked) > 0 {
+		q = q.Where("is_active = ? OR id IN ?", true, worked)
+	} else {
+		q = q.Where("is_active = ?", true)
+	}
+	q.Order("name asc").Find(&users)
+
+	rows := make([]*payrollRow, len(users))
+	byUser := map[uint]*payrollRow{}
+	for i, u := range users {
+		rows[i] = &payrollRow{user: u, tasks: map[uint]int64{}}
+		byUser[u.ID] = rows[i]
+	}
+	for _, e := range entries {
+		row := byUser[e.UserID]
+		if row == nil {
+			continue
+		}
+		for _, share := range splitByDay(db, e, locs[e.UserID]) {
+			if share.Date >= start && share.Date <= end {
+				row.seconds += share.Seconds
+				addBuckets(&row.buckets, buckets[e.ID][share.Date])
+			}
+		}
+		if e.Date >= start {
+			row.sessions++
+		}
+	}
+
+	for userID, byDay := range leaveByDay(db.Scopes(scopeToVisible(c, "user_id", true)), start, end) {
+		if row := byUser[userID]; row != nil {
+			for _, secs := range byDay {
+				row.leave += secs
+			}
+		}
+	}
+
+	// Task time by when the timer ran, in the org's zone; running timers
+	// count up to now
+	loc := orgLocation(mw.GetOrgID(c))
+	periodStart, _ := time.ParseInLocation("2006-01-02", start, loc)
+	periodEnd, _ := time.ParseInLocation("2006-01-02", end, loc)
+	var taskTimes []struct {
+		UserID  uint
+		TaskID  uint
+		Title   string
+		Seconds int64
+	}
+	db.Model(&models.TaskTime{}).
+		Select(`task_times.user_id, task_times.task_id, tasks.title,
+		        CAST(SUM(EXTRACT(EPOCH FROM (COALESCE(task_times.stopped_at, NOW()) - task_times.started_at))) AS BIGINT) AS seconds`).
+		Joins("JOIN tasks ON tasks.id = task_times.task_id").
+		Where("task_times.started_at >= ? AND task_times.started_at < ?", periodStart, periodEnd.AddDate(0, 0, 1)).
+		Scopes(scopeToVisible(c, "task_times.user_id", true)).
+		Group("task_times.user_id, task_times.task_id, tasks.title").
+		Order("tasks.title asc, task_times.task_id asc").
+		Scan(&taskTimes)
+
+	var tasks []models.TimesheetTask
+	seenTask := map[uint]bool{}
+	for _, t := range taskTimes {
+		if row := byUser[t.UserID]; row != nil {
+			row.tasks[t.TaskID] += t.Seconds
+		}
+		if !seenTask[t.TaskID] {
+			seenTask[t.TaskID] = true
+			tasks = append(tasks, models.TimesheetTask{TaskID: t.TaskID, Title: t.Title})
+		}
+	}
+
+	var header []string
+	for _, col := range columns {
+		if col.Field == taskHoursField {
+			for _, t := range tasks {
+				header = append(header, col.Header+t.Title)
+			}
+			continue
+		}
+		name := col.Header
+		if name == "" {
+			name = payrollFields[col.Field]
+		}
+		header = append(header, name)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="payroll-%s-%s.csv"`, start, end))
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+	w.Write(csvSafe(header))
+	for _, row := range rows {
+		var record []string
+		for _, col := range columns {
+			if col.Field == taskHoursField {
+				for _, t := range tasks {
+					record = append(record, formatHours(row.tasks[t.TaskID]))
+				}
+				continue
+			}
+			record = append(record, payrollValue(col.Field, row, start, end))
+		}
+		w.Write(csvSafe(record))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// payrollValue renders one non-task field of a row
+func payrollValue(field string, row *payrollRow, start, end string) string {
+	switch field {
+	case "employee_id":
+		return strconv.FormatUint(uint64(row.user.ID), 10)
+	case "name":
+		return row.user.Name
+	case "email":
+		return row.user.Email
+	case "title":
+		return row.user.Title
+	case "period_start":
+		return start
+	case "period_end":
+		return end
+	case "total_hours":
+		return formatHours(row.seconds)
+	case "regular_hours":
+		return formatHours(row.buckets.RegularSeconds)
+	case "overtime_hours":
+		return formatHours(row.buckets.OvertimeSeconds)
+	case "double_time_hours":
+		return formatHours(row.buckets.DoubleTimeSeconds)
+	case "sessions":
+		return strconv.Itoa(row.sessions)
+	case "leave_hours":
+		return formatHours(row.leave)
+	}
+	return ""
+}
+
+// csvSafe quotes cells a spreadsheet would otherwise run as a formula. Names,
+// emails and task titles come from users, so an export must not carry
+// "=HYPERLINK(...)" and the like into payroll's spreadsheet (CSV injection).
+func csvSafe(cells []string) []string {
+	for i, v := range cells {
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			cells[i] = "'" + v
+		}
+	}
+	return cells
+}
+
+func formatHours(seconds int64) string {
+	return strconv.FormatFloat(float64(seconds)/3600, 'f', 2, 64)
+}
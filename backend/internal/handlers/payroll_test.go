@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCSVSafe(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Ana Lima", "Ana Lima"},
+		{"", ""},
+		{"8.50", "8.50"},
+		{"ana@example.com", "ana@example.com"},
+		{"=HYPERLINK(\"http://evil\",\"x\")", "'=HYPERLINK(\"http://evil\",\"x\")"},
+		{"+1 555 0100", "'+1 555 0100"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A9)", "'@SUM(A1:A9)"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		{"Fix = sign", "Fix = sign"},
+	}
+	for _, tt := range tests {
+		if got := csvSafe([]string{tt.in}); !reflect.DeepEqual(got, []string{tt.want}) {
+			t.Errorf("csvSafe(%q) = %q, want %q", tt.in, got[0], tt.want)
+		}
+	}
+}
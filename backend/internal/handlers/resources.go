@@ -4,9 +4,9 @@ import (
 	"net/http"
 	"time"
 
-	"teampulse/internal/database"
 	mw "teampulse/internal/middleware"
 	"teampulse/internal/models"
+	"teampulse/internal/policy"
 
 	"github.com/labstack/echo/v4"
 )
@@ -20,6 +20,9 @@ func CreateTask(c echo.Context) error {
 	}
 
 	task.CreatedByID = mw.GetUserID(c)
+	if task.AssigneeID != nil && !canActOn(c, policy.TaskWrite, *task.AssigneeID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "cannot assign tasks to this user"})
+	}
 	if task.Status == "" {
 		task.Status = models.TaskPending
 	}
@@ -27,22 +30,19 @@ func CreateTask(c echo.Context) error {
 		task.Priority = models.PriorityMedium
 	}
 
-	database.DB.Create(&task)
-	database.DB.Preload("Assignee").First(&task, task.ID)
+	orgDB(c).Create(&task)
+	orgDB(c).Preload("Assignee").First(&task, task.ID)
 	return c.JSON(http.StatusCreated, task)
 }
 
 func ListTasks(c echo.Context) error {
-	userID := mw.GetUserID(c)
-	role := mw.GetUserRole(c)
 	status := c.QueryParam("status")
 
 	var tasks []models.Task
-	q := database.DB.Preload("Assignee").Preload("TaskTimes").Order("created_at desc")
+	q := orgDB(c).Preload("Assignee").Preload("TaskTimes").Order("created_at desc")
 
-	if role != models.RoleAdmin {
-		q = q.Where("assignee_id = ?", userID)
-	}
+	// Employees see their own tasks, managers their team's
+	q = q.Scopes(scopeToVisible(c, "assignee_id", true))
 	if status != "" {
 		q = q.Where("status = ?", status)
 	}
@@ -54,15 +54,26 @@ func ListTasks(c echo.Context) error {
 func UpdateTask(c echo.Context) error {
 	id := c.Param("id")
 	var task models.Task
-	if err := database.DB.First(&task, id).Error; err != nil {
+	if err := orgDB(c).First(&task, id).Error; err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "task not found"})
 	}
+	if !canEditTask(c, policy.TaskWrite, task) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not your task"})
+	}
 
 	var updates map[string]interface{}
 	if err := c.Bind(&updates); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
 	}
 
+	// Reassigning needs task:write over the new assignee
+	if raw, ok := updates["assignee_id"]; ok && raw != nil {
+		f, isNum := raw.(float64)
+		if !isNum || !canActOn(c, policy.TaskWrite, uint(f)) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "cannot assign tasks to this user"})
+		}
+	}
+
 	// Handle status transitions
 	if newStatus, ok := updates["status"]; ok {
 		if newStatus == "complete" {
@@ -74,17 +85,44 @@ func UpdateTask(c echo.Context) error {
 	}
 
 	delete(updates, "id")
-	database.DB.Model(&task).Updates(updates)
-	database.DB.Preload("Assignee").First(&task, id)
+	delete(updates, "created_by_id")
+	orgDB(c).Model(&task).Updates(updates)
+	orgDB(c).Preload("Assignee").First(&task, id)
 	return c.JSON(http.StatusOK, task)
 }
 
 func DeleteTask(c echo.Context) error {
 	id := c.Param("id")
-	database.DB.Delete(&models.Task{}, id)
+	var task models.Task
+	if err := orgDB(c).First(&task, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "task not found"})
+	}
+
+	// Assignees can't delete work handed to them; creators can
+	if task.CreatedByID != mw.GetUserID(c) && !(mw.Can(c, policy.TaskDelete) && canViewUser(c, taskOwner(task))) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "missing permission task:delete"})
+	}
+
+	orgDB(c).Delete(&task)
 	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
 }
 
+// taskOwner is whose task it is for scoping: the assignee, else the creator
+func taskOwner(task models.Task) uint {
+	if task.AssigneeID != nil {
+		return *task.AssigneeID
+	}
+	return task.CreatedByID
+}
+
+// canEditTask allows the creator and assignee, and anyone holding perm over the owner
+func canEditTask(c echo.Context, perm policy.Permission, task models.Task) bool {
+	if task.CreatedByID == mw.GetUserID(c) {
+		return true
+	}
+	return canActOn(c, perm, taskOwner(task))
+}
+
 // ─── KPIs ─────────────────────────────────────────────────────
 
 func CreateKPI(c echo.Context) error {
@@ -92,21 +130,21 @@ func CreateKPI(c echo.Context) error {
 	if err := c.Bind(&kpi); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
 	}
-	database.DB.Create(&kpi)
-	database.DB.Preload("User").First(&kpi, kpi.ID)
+
+	// KPIs are targets set for someone, so even your own need kpi:write
+	if !mw.Can(c, policy.KPIWrite) || !canViewUser(c, kpi.UserID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "missing permission kpi:write"})
+	}
+
+	orgDB(c).Create(&kpi)
+	orgDB(c).Preload("User").First(&kpi, kpi.ID)
 	return c.JSON(http.StatusCreated, kpi)
 }
 
 func ListKPIs(c echo.Context) error {
-	userID := mw.GetUserID(c)
-	role := mw.GetUserRole(c)
-
 	var kpis []models.KPI
-	q := database.DB.Preload("User").Order("created_at desc")
-
-	if role != models.RoleAdmin {
-		q = q.Where("user_id = ?", userID)
-	}
+	q := orgDB(c).Preload("User").Order("created_at desc").
+		Scopes(scopeToVisible(c, "user_id", true))
 
 	q.Find(&kpis)
 	return c.JSON(http.StatusOK, kpis)
@@ -115,23 +153,40 @@ func ListKPIs(c echo.Context) error {
 func UpdateKPI(c echo.Context) error {
 	id := c.Param("id")
 	var kpi models.KPI
-	if err := database.DB.First(&kpi, id).Error; err != nil {
+	if err := orgDB(c).First(&kpi, id).Error; err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "KPI not found"})
 	}
+	if !mw.Can(c, policy.KPIWrite) || !canViewUser(c, kpi.UserID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "missing permission kpi:write"})
+	}
 
 	var updates map[string]interface{}
 	if err := c.Bind(&updates); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
 	}
+	if raw, ok := updates["user_id"]; ok {
+		f, isNum := raw.(float64)
+		if !isNum || !canViewUser(c, uint(f)) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "cannot move KPI to this user"})
+		}
+	}
 	delete(updates, "id")
-	database.DB.Model(&kpi).Updates(updates)
-	database.DB.Preload("User").First(&kpi, id)
+	orgDB(c).Model(&kpi).Updates(updates)
+	orgDB(c).Preload("User").First(&kpi, id)
 	return c.JSON(http.StatusOK, kpi)
 }
 
 func DeleteKPI(c echo.Context) error {
 	id := c.Param("id")
-	database.DB.Delete(&models.KPI{}, id)
+	var kpi models.KPI
+	if err := orgDB(c).First(&kpi, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "KPI not found"})
+	}
+	if !mw.Can(c, policy.KPIDelete) || !canViewUser(c, kpi.UserID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "missing permission kpi:delete"})
+	}
+
+	orgDB(c).Delete(&kpi)
 	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
 }
 
@@ -145,25 +200,20 @@ func CreateStandup(c echo.Context) error {
 
 	standup.UserID = mw.GetUserID(c)
 	if standup.Date == "" {
-		standup.Date = todayStr()
+		standup.Date = todayIn(callerLocation(c))
 	}
 
-	database.DB.Create(&standup)
-	database.DB.Preload("User").First(&standup, standup.ID)
+	orgDB(c).Create(&standup)
+	orgDB(c).Preload("User").First(&standup, standup.ID)
 	return c.JSON(http.StatusCreated, standup)
 }
 
 func ListStandups(c echo.Context) error {
-	userID := mw.GetUserID(c)
-	role := mw.GetUserRole(c)
 	date := c.QueryParam("date")
 
 	var standups []models.Standup
-	q := database.DB.Preload("User").Order("created_at desc")
-
-	if role != models.RoleAdmin {
-		q = q.Where("user_id = ?", userID)
-	}
+	q := orgDB(c).Preload("User").Order("created_at desc").
+		Scopes(scopeToVisible(c, "user_id", true))
 	if date != "" {
 		q = q.Where("date = ?", date)
 	} else {
@@ -176,6 +226,14 @@ func ListStandups(c echo.Context) error {
 
 func DeleteStandup(c echo.Context) error {
 	id := c.Param("id")
-	database.DB.Delete(&models.Standup{}, id)
+	var standup models.Standup
+	if err := orgDB(c).First(&standup, id).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "standup not found"})
+	}
+	if !canActOn(c, policy.StandupDelete, standup.UserID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "missing permission standup:delete"})
+	}
+
+	orgDB(c).Delete(&standup)
 	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
 }
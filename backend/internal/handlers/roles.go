@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+	"teampulse/internal/policy"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ─── Roles & Permissions ─────────────────────────────────────
+
+type roleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// ListRoles — role:manage: built-in role grants, custom roles and every known permission
+func ListRoles(c echo.Context) error {
+	builtin := map[models.Role][]policy.Permission{}
+	for role, perms := range policy.Builtin {
+		builtin[role] = perms
+	}
+
+	var custom []models.CustomRole
+	orgDB(c).Order("name asc").Find(&custom)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"permissions": policy.All,
+		"builtin":     builtin,
+		"custom":      custom,
+	})
+}
+
+// validateRole normalises a role request and checks its permission names
+func validateRole(req *roleRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required (100 characters max)"
+	}
+	for _, p := range req.Permissions {
+		if !policy.Valid(p) {
+			return "unknown permission " + p
+		}
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+	return ""
+}
+
+func CreateRole(c echo.Context) error {
+	var req roleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateRole(&req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	role := models.CustomRole{Name: req.Name, Description: req.Description, Permissions: req.Permissions}
+	if err := orgDB(c).Create(&role).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "role name already exists"})
+	}
+
+	logAudit(mw.GetUserID(c), "created_role", role.ID, strings.Join(role.Permissions, ","))
+	return c.JSON(http.StatusCreated, role)
+}
+
+func UpdateRole(c echo.Context) error {
+	var role models.CustomRole
+	if err := orgDB(c).First(&role, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "role not found"})
+	}
+
+	var req roleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateRole(&req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	role.Name = req.Name
+	role.Description = req.Description
+	role.Permissions = req.Permissions
+	if err := orgDB(c).Save(&role).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "role name already exists"})
+	}
+
+	logAudit(mw.GetUserID(c), "updated_role", role.ID, strings.Join(role.Permissions, ","))
+	return c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole — role:manage: remove a custom role; its holders fall back to their built-in role
+func DeleteRole(c echo.Context) error {
+	var role models.CustomRole
+	if err := orgDB(c).First(&role, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "role not found"})
+	}
+
+	orgDB(c).Model(&models.User{}).Where("custom_role_id = ?", role.ID).Update("custom_role_id", nil)
+	orgDB(c).Where("custom_role_id = ?", role.ID).Delete(&models.ClockInPolicy{})
+	orgDB(c).Delete(&role)
+
+	logAudit(mw.GetUserID(c), "deleted_role", role.ID, role.Name)
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// TargetInView guards /employees/:id routes so holders of employee:manage
+// without scope:all can only act on their own reporting line
+func TargetInView(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid employee id"})
+		}
+		if !canViewUser(c, uint(id)) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "not in your team"})
+		}
+		return next(c)
+	}
+}
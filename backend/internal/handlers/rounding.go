@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"time"
+
+	"teampulse/internal/database"
+	"teampulse/internal/models"
+	"teampulse/internal/rounding"
+
+	"gorm.io/gorm"
+)
+
+// ─── Punch Rounding ──────────────────────────────────────────
+// Time entries keep the raw punches in clock_in and clock_out for audit, and
+// the org's rounding of them in rounded_clock_in and rounded_clock_out.
+// Worked time — durations, daily hours, timesheets, payroll — is counted
+// between the rounded times. Entries from before rounding was set up have no
+// rounded times and count their raw ones.
+
+// roundingRules reads the org's rounding settings for clock-ins and clock-outs
+func roundingRules(orgID uint) (rounding.Rule, rounding.Rule) {
+	settings := database.GetOrgSettings(orgID)
+	interval := time.Duration(settings.RoundingIntervalMinutes) * time.Minute
+	grace := time.Duration(settings.RoundingGraceMinutes) * time.Minute
+	in := rounding.Rule{Interval: interval, Mode: rounding.Mode(settings.RoundClockIn), Grace: grace}
+	out := rounding.Rule{Interval: interval, Mode: rounding.Mode(settings.RoundClockOut), Grace: grace}
+	return in, out
+}
+
+// roundPunches sets entry's rounded times from its raw punches, in the
+// owner's zone. A rounded clock-out never falls before the rounded clock-in.
+func roundPunches(db *gorm.DB, entry *models.TimeEntry) {
+	var user models.User
+	db.Select("id", "org_id").First(&user, entry.UserID)
+	in, out := roundingRules(user.OrgID)
+	loc := userLocation(db, entry.UserID)
+
+	roundedIn := in.Apply(entry.ClockIn, loc)
+	entry.RoundedClockIn = &roundedIn
+	entry.RoundedClockOut = nil
+	if entry.ClockOut != nil {
+		roundedOut := out.Apply(*entry.ClockOut, loc)
+		if roundedOut.Before(roundedIn) {
+			roundedOut = roundedIn
+		}
+		entry.RoundedClockOut = &roundedOut
+	}
+}
+
+// entryDate is the day an entry is filed under: the owner's local day of its
+// rounded clock-in, where its worked time starts. Rounding can carry a punch
+// just before midnight into the next day.
+func entryDate(db *gorm.DB, entry models.TimeEntry) string {
+	start, _ := paidSpan(entry)
+	return dateIn(start, userLocation(db, entry.UserID))
+}
+
+// paidSpan is the stretch of an entry that counts as worked: its rounded
+// times, else its raw ones. An entry still open runs to now.
+func paidSpan(entry models.TimeEntry) (time.Time, time.Time) {
+	start := entry.ClockIn
+	if entry.RoundedClockIn != nil {
+		start = *entry.RoundedClockIn
+	}
+	end := time.Now()
+	if entry.ClockOut != nil {
+		end = *entry.ClockOut
+		if entry.RoundedClockOut != nil {
+			end = *entry.RoundedClockOut
+		}
+	}
+	if end.Before(start) {
+		end = start
+	}
+	return start, end
+}
+
+// spanSeconds is the worked length of [start, end) less unpaid break seconds
+func spanSeconds(start, end time.Time, unpaid int64) int64 {
+	return max(int64(end.Sub(start).Seconds())-unpaid, 0)
+}
@@ -0,0 +1,734 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ─── SCIM 2.0 Provisioning (RFC 7643 / 7644) ─────────────────
+//
+// The directory authenticates with a bearer provisioning token generated at
+// POST /api/settings/scim-token. Users map onto User (active → IsActive,
+// title → Title, roles → Role); DELETE deactivates rather than erasing time
+// data. Members of the group named by SCIM_ADMIN_GROUP are made admins.
+
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimPatchSchema = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+)
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimMultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type scimUserResource struct {
+	Schemas     []string          `json:"schemas"`
+	ID          string            `json:"id,omitempty"`
+	ExternalID  string            `json:"externalId,omitempty"`
+	UserName    string            `json:"userName"`
+	Name        scimName          `json:"name"`
+	DisplayName string            `json:"displayName,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Active      *bool             `json:"active,omitempty"`
+	Emails      []scimMultiValue  `json:"emails,omitempty"`
+	Roles       []scimMultiValue  `json:"roles,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty"`
+}
+
+type scimGroupResource struct {
+	Schemas     []string          `json:"schemas"`
+	ID          string            `json:"id,omitempty"`
+	ExternalID  string            `json:"externalId,omitempty"`
+	DisplayName string            `json:"displayName"`
+	Members     []scimMultiValue  `json:"members"`
+	Meta        map[string]string `json:"meta,omitempty"`
+}
+
+type scimPatchRequest struct {
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+// ─── Auth & Responses ────────────────────────────────────────
+
+// SCIMAuthMiddleware checks the directory's bearer provisioning token. The
+// token identifies the organization being provisioned.
+func SCIMAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		auth := c.Request().Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth || token == "" {
+			return scimError(c, http.StatusUnauthorized, "invalid provisioning token")
+		}
+
+		var settings models.OrgSettings
+		if err := database.DB.Where("scim_token_hash = ?", hashSecret(token)).First(&settings).Error; err != nil {
+			return scimError(c, http.StatusUnauthorized, "invalid provisioning token")
+		}
+
+		c.Set("org_id", settings.OrgID)
+		return next(c)
+	}
+}
+
+// GenerateSCIMToken — Admin: issue (or rotate) the directory's provisioning token.
+// The token is only shown in this response.
+func GenerateSCIMToken(c echo.Context) error {
+	settings := database.GetOrgSettings(mw.GetOrgID(c))
+	token := "scim_" + newRefreshSecret()
+	now := time.Now()
+
+	orgDB(c).Model(&settings).Updates(map[string]interface{}{
+		"scim_token_hash":   hashSecret(token),
+		"scim_token_set_at": now,
+	})
+	logAudit(mw.GetUserID(c), "rotated_scim_token", 0, "")
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"token":    token,
+		"base_url": appURL() + "/scim/v2",
+	})
+}
+
+// RevokeSCIMToken — Admin: disable SCIM provisioning
+func RevokeSCIMToken(c echo.Context) error {
+	settings := database.GetOrgSettings(mw.GetOrgID(c))
+	orgDB(c).Model(&settings).Updates(map[string]interface{}{
+		"scim_token_hash":   "",
+		"scim_token_set_at": nil,
+	})
+	logAudit(mw.GetUserID(c), "revoked_scim_token", 0, "")
+	return c.JSON(http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+func scimJSON(c echo.Context, status int, v interface{}) error {
+	c.Response().Header().Set(echo.HeaderContentType, "application/scim+json")
+	c.Response().WriteHeader(status)
+	return json.NewEncoder(c.Response()).Encode(v)
+}
+
+func scimError(c echo.Context, status int, detail string) error {
+	return scimJSON(c, status, map[string]interface{}{
+		"schemas": []string{scimErrorSchema},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	})
+}
+
+func scimList(c echo.Context, total int64, startIndex int, resources interface{}, count int) error {
+	return scimJSON(c, http.StatusOK, map[string]interface{}{
+		"schemas":      []string{scimListSchema},
+		"totalResults": total,
+		"startIndex":   startIndex,
+		"itemsPerPage": count,
+		"Resources":    resources,
+	})
+}
+
+// scimPaging reads the 1-based startIndex and count query params
+func scimPaging(c echo.Context) (startIndex, count int) {
+	startIndex, count = 1, 100
+	if v, err := strconv.Atoi(c.QueryParam("startIndex")); err == nil && v > 0 {
+		startIndex = v
+	}
+	if v, err := strconv.Atoi(c.QueryParam("count")); err == nil && v >= 0 && v <= 500 {
+		count = v
+	}
+	return startIndex, count
+}
+
+var scimFilterRegex = regexp.MustCompile(`^\s*([\w.]+)\s+eq\s+"([^"]*)"\s*$`)
+
+// parseSCIMFilter supports the single `attr eq "value"` form directories use
+// to look up existing resources
+func parseSCIMFilter(filter string) (attr, value string, ok bool) {
+	m := scimFilterRegex.FindStringSubmatch(filter)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// ─── Discovery ───────────────────────────────────────────────
+
+func SCIMServiceProviderConfig(c echo.Context) error {
+	return scimJSON(c, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": 500},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]string{{
+			"type": "oauthbearertoken", "name": "Bearer Token", "description": "Provisioning token from TeamPulse settings",
+		}},
+	})
+}
+
+func SCIMResourceTypes(c echo.Context) error {
+	types := []map[string]interface{}{
+		{"schemas": []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"}, "id": "User", "name": "User", "endpoint": "/Users", "schema": scimUserSchema},
+		{"schemas": []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"}, "id": "Group", "name": "Group", "endpoint": "/Groups", "schema": scimGroupSchema},
+	}
+	return scimList(c, int64(len(types)), 1, types, len(types))
+}
+
+// ─── Users ───────────────────────────────────────────────────
+
+func toSCIMUser(u models.User) scimUserResource {
+	active := u.IsActive
+	given, family := splitName(u.Name)
+	return scimUserResource{
+		Schemas:     []string{scimUserSchema},
+		ID:          strconv.Itoa(int(u.ID)),
+		ExternalID:  u.SCIMExternalID,
+		UserName:    u.Email,
+		Name:        scimName{Formatted: u.Name, GivenName: given, FamilyName: family},
+		DisplayName: u.Name,
+		Title:       u.Title,
+		Active:      &active,
+		Emails:      []scimMultiValue{{Value: u.Email, Type: "work", Primary: true}},
+		Roles:       []scimMultiValue{{Value: string(u.Role), Primary: true}},
+		Meta: map[string]string{
+			"resourceType": "User",
+			"created":      u.CreatedAt.UTC().Format(time.RFC3339),
+			"lastModified": u.UpdatedAt.UTC().Format(time.RFC3339),
+			"location":     appURL() + "/scim/v2/Users/" + strconv.Itoa(int(u.ID)),
+		},
+	}
+}
+
+func splitName(name string) (given, family string) {
+	parts := strings.SplitN(strings.TrimSpace(name), " ", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
+
+// applySCIMUser copies a full SCIM user resource (POST/PUT) onto user
+func applySCIMUser(user *models.User, res scimUserResource) error {
+	email := res.UserName
+	for _, e := range res.Emails {
+		if e.Primary || !emailRegex.MatchString(email) {
+			email = e.Value
+		}
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !emailRegex.MatchString(email) {
+		return fmt.Errorf("userName or a primary email must be an email address")
+	}
+	user.Email = email
+
+	name := res.Name.Formatted
+	if name == "" {
+		name = strings.TrimSpace(res.Name.GivenName + " " + res.Name.FamilyName)
+	}
+	if name == "" {
+		name = res.DisplayName
+	}
+	if name == "" {
+		name = email
+	}
+	user.Name = name
+	user.Title = res.Title
+	user.SCIMExternalID = res.ExternalID
+	if res.Active != nil {
+		user.IsActive = *res.Active
+	}
+	for _, r := range res.Roles {
+		if err := applySCIMRole(user, r.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applySCIMRole(user *models.User, value string) error {
+	role := models.Role(strings.ToLower(value))
+	if !validRole(role) {
+		return fmt.Errorf("unknown role %q", value)
+	}
+	user.Role = role
+	return nil
+}
+
+// saveSCIMUser persists user and revokes credentials if it was just deactivated
+func saveSCIMUser(c echo.Context, user *models.User, wasActive bool, status int) error {
+	if err := orgDB(c).Save(user).Error; err != nil {
+		return scimJSON(c, http.StatusConflict, map[string]interface{}{
+			"schemas": []string{scimErrorSchema}, "status": "409", "scimType": "uniqueness", "detail": "userName already exists",
+		})
+	}
+	if wasActive && !user.IsActive {
+		revokeUserCredentials(user.ID)
+	}
+	return scimJSON(c, status, toSCIMUser(*user))
+}
+
+func SCIMListUsers(c echo.Context) error {
+	startIndex, count := scimPaging(c)
+	q := orgDB(c).Model(&models.User{})
+
+	if filter := c.QueryParam("filter"); filter != "" {
+		attr, value, ok := parseSCIMFilter(filter)
+		if !ok {
+			return scimError(c, http.StatusBadRequest, "unsupported filter")
+		}
+		switch attr {
+		case "userName", "emails.value", "emails":
+			q = q.Where("LOWER(email) = ?", strings.ToLower(value))
+		case "externalId":
+			q = q.Where("scim_external_id = ?", value)
+		case "id":
+			q = q.Where("id = ?", value)
+		default:
+			return scimError(c, http.StatusBadRequest, "unsupported filter attribute "+attr)
+		}
+	}
+
+	var total int64
+	q.Count(&total)
+
+	var users []models.User
+	q.Order("id asc").Offset(startIndex - 1).Limit(count).Find(&users)
+
+	resources := make([]scimUserResource, 0, len(users))
+	for _, u := range users {
+		resources = append(resources, toSCIMUser(u))
+	}
+	return scimList(c, total, startIndex, resources, len(resources))
+}
+
+func SCIMGetUser(c echo.Context) error {
+	var user models.User
+	if err := orgDB(c).First(&user, c.Param("id")).Error; err != nil {
+		return scimError(c, http.StatusNotFound, "user not found")
+	}
+	return scimJSON(c, http.StatusOK, toSCIMUser(user))
+}
+
+func SCIMCreateUser(c echo.Context) error {
+	var res scimUserResource
+	if err := json.NewDecoder(c.Request().Body).Decode(&res); err != nil {
+		return scimError(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	user := models.User{Role: models.RoleEmployee, IsActive: true}
+	if err := applySCIMUser(&user, res); err != nil {
+		return scimError(c, http.StatusBadRequest, err.Error())
+	}
+
+	// Directory users sign in via SSO or forgot-password; nobody knows this one
+	hash, _ := bcrypt.GenerateFromPassword([]byte(newRefreshSecret()), bcrypt.DefaultCost)
+	user.Password = string(hash)
+
+	return saveSCIMUser(c, &user, true, http.StatusCreated)
+}
+
+func SCIMReplaceUser(c echo.Context) error {
+	var user models.User
+	if err := orgDB(c).First(&user, c.Param("id")).Error; err != nil {
+		return scimError(c, http.StatusNotFound, "user not found")
+	}
+
+	var res scimUserResource
+	if err := json.NewDecoder(c.Request().Body).Decode(&res); err != nil {
+		return scimError(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	wasActive := user.IsActive
+	if err := applySCIMUser(&user, res); err != nil {
+		return scimError(c, http.StatusBadRequest, err.Error())
+	}
+	return saveSCIMUser(c, &user, wasActive, http.StatusOK)
+}
+
+func SCIMPatchUser(c echo.Context) error {
+	var user models.User
+	if err := orgDB(c).First(&user, c.Param("id")).Error; err != nil {
+		return scimError(c, http.StatusNotFound, "user not found")
+	}
+
+	var req scimPatchRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return scimError(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	wasActive := user.IsActive
+	for _, op := range req.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		default:
+			return scimError(c, http.StatusBadRequest, "unsupported op "+op.Op)
+		}
+
+		// Without a path the value is an object of attribute → value
+		attrs := map[string]json.RawMessage{}
+		if op.Path != "" {
+			attrs[op.Path] = op.Value
+		} else if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return scimError(c, http.StatusBadRequest, "invalid patch value")
+		}
+
+		for path, value := range attrs {
+			if err := patchSCIMUserAttr(&user, path, value); err != nil {
+				return scimError(c, http.StatusBadRequest, err.Error())
+			}
+		}
+	}
+
+	return saveSCIMUser(c, &user, wasActive, http.StatusOK)
+}
+
+// patchSCIMUserAttr applies one PATCH attribute. Unknown attributes are
+// ignored, as directories send many TeamPulse doesn't store.
+func patchSCIMUserAttr(user *models.User, path string, raw json.RawMessage) error {
+	var str string
+	json.Unmarshal(raw, &str)
+
+	switch {
+	case path == "active":
+		// Some directories send "True"/"False" strings
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			b = strings.EqualFold(str, "true")
+		}
+		user.IsActive = b
+	case path == "title":
+		user.Title = str
+	case path == "externalId":
+		user.SCIMExternalID = str
+	case path == "displayName", path == "name.formatted":
+		if str != "" {
+			user.Name = str
+		}
+	case path == "name.givenName":
+		_, family := splitName(user.Name)
+		user.Name = strings.TrimSpace(str + " " + family)
+	case path == "name.familyName":
+		given, _ := splitName(user.Name)
+		user.Name = strings.TrimSpace(given + " " + str)
+	case path == "name":
+		var n scimName
+		json.Unmarshal(raw, &n)
+		if n.Formatted != "" {
+			user.Name = n.Formatted
+		} else if full := strings.TrimSpace(n.GivenName + " " + n.FamilyName); full != "" {
+			user.Name = full
+		}
+	case path == "userName", strings.HasPrefix(path, "emails"):
+		if str == "" {
+			var emails []scimMultiValue
+			json.Unmarshal(raw, &emails)
+			for _, e := range emails {
+				if e.Primary || str == "" {
+					str = e.Value
+				}
+			}
+		}
+		if !emailRegex.MatchString(str) {
+			return fmt.Errorf("%s must be an email address", path)
+		}
+		user.Email = strings.ToLower(str)
+	case path == "roles":
+		var roles []scimMultiValue
+		if err := json.Unmarshal(raw, &roles); err != nil {
+			return fmt.Errorf("invalid roles")
+		}
+		for _, r := range roles {
+			if err := applySCIMRole(user, r.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SCIMDeleteUser deactivates the user; time records are kept for payroll
+func SCIMDeleteUser(c echo.Context) error {
+	var user models.User
+	if err := orgDB(c).First(&user, c.Param("id")).Error; err != nil {
+		return scimError(c, http.StatusNotFound, "user not found")
+	}
+	orgDB(c).Model(&user).Update("is_active", false)
+	revokeUserCredentials(user.ID)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ─── Groups ──────────────────────────────────────────────────
+
+func toSCIMGroup(g models.Group) scimGroupResource {
+	members := make([]scimMultiValue, 0, len(g.Members))
+	for _, m := range g.Members {
+		members = append(members, scimMultiValue{Value: strconv.Itoa(int(m.ID)), Display: m.Name})
+	}
+	return scimGroupResource{
+		Schemas:     []string{scimGroupSchema},
+		ID:          strconv.Itoa(int(g.ID)),
+		ExternalID:  g.ExternalID,
+		DisplayName: g.DisplayName,
+		Members:     members,
+		Meta: map[string]string{
+			"resourceType": "Group",
+			"created":      g.CreatedAt.UTC().Format(time.RFC3339),
+			"lastModified": g.UpdatedAt.UTC().Format(time.RFC3339),
+			"location":     appURL() + "/scim/v2/Groups/" + strconv.Itoa(int(g.ID)),
+		},
+	}
+}
+
+// scimMemberIDs resolves member references to existing user IDs
+func scimMemberIDs(members []scimMultiValue) []uint {
+	var ids []uint
+	for _, m := range members {
+		if id, err := strconv.Atoi(m.Value); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// setGroupMembers adds and removes members and applies SCIM_ADMIN_GROUP role mapping
+func setGroupMembers(c echo.Context, group *models.Group, add, remove []uint) {
+	if len(add) > 0 {
+		var users []models.User
+		orgDB(c).Where("id IN ?", add).Find(&users)
+		orgDB(c).Model(group).Association("Members").Append(&users)
+	}
+	if len(remove) > 0 {
+		var users []models.User
+		orgDB(c).Where("id IN ?", remove).Find(&users)
+		orgDB(c).Model(group).Association("Members").Delete(&users)
+	}
+
+	adminGroup := os.Getenv("SCIM_ADMIN_GROUP")
+	if adminGroup == "" || !strings.EqualFold(group.DisplayName, adminGroup) {
+		return
+	}
+	if len(add) > 0 {
+		orgDB(c).Model(&models.User{}).Where("id IN ?", add).Update("role", models.RoleAdmin)
+	}
+	if len(remove) > 0 {
+		orgDB(c).Model(&models.User{}).Where("id IN ? AND role = ?", remove, models.RoleAdmin).Update("role", models.RoleEmployee)
+	}
+}
+
+func loadGroup(c echo.Context, id string) (models.Group, error) {
+	var group models.Group
+	err := orgDB(c).Preload("Members").First(&group, id).Error
+	return group, err
+}
+
+func SCIMListGroups(c echo.Context) error {
+	startIndex, count := scimPaging(c)
+	q := orgDB(c).Model(&models.Group{})
+
+	if filter := c.QueryParam("filter"); filter != "" {
+		attr, value, ok := parseSCIMFilter(filter)
+		if !ok {
+			return scimError(c, http.StatusBadRequest, "unsupported filter")
+		}
+		switch attr {
+		case "displayName":
+			q = q.Where("display_name = ?", value)
+		case "externalId":
+			q = q.Where("external_id = ?", value)
+		case "id":
+			q = q.Where("id = ?", value)
+		default:
+			return scimError(c, http.StatusBadRequest, "unsupported filter attribute "+attr)
+		}
+	}
+
+	var total int64
+	q.Count(&total)
+
+	var groups []models.Group
+	q.Preload("Members").Order("id asc").Offset(startIndex - 1).Limit(count).Find(&groups)
+
+	resources := make([]scimGroupResource, 0, len(groups))
+	for _, g := range groups {
+		resources = append(resources, toSCIMGroup(g))
+	}
+	return scimList(c, total, startIndex, resources, len(resources))
+}
+
+func SCIMGetGroup(c echo.Context) error {
+	group, err := loadGroup(c, c.Param("id"))
+	if err != nil {
+		return scimError(c, http.StatusNotFound, "group not found")
+	}
+	return scimJSON(c, http.StatusOK, toSCIMGroup(group))
+}
+
+func SCIMCreateGroup(c echo.Context) error {
+	var res scimGroupResource
+	if err := json.NewDecoder(c.Request().Body).Decode(&res); err != nil || res.DisplayName == "" {
+		return scimError(c, http.StatusBadRequest, "displayName is required")
+	}
+
+	group := models.Group{DisplayName: res.DisplayName, ExternalID: res.ExternalID}
+	if err := orgDB(c).Create(&group).Error; err != nil {
+		return scimJSON(c, http.StatusConflict, map[string]interface{}{
+			"schemas": []string{scimErrorSchema}, "status": "409", "scimType": "uniqueness", "detail": "displayName already exists",
+		})
+	}
+	setGroupMembers(c, &group, scimMemberIDs(res.Members), nil)
+
+	group, _ = loadGroup(c, strconv.Itoa(int(group.ID)))
+	return scimJSON(c, http.StatusCreated, toSCIMGroup(group))
+}
+
+func SCIMReplaceGroup(c echo.Context) error {
+	group, err := loadGroup(c, c.Param("id"))
+	if err != nil {
+		return scimError(c, http.StatusNotFound, "group not found")
+	}
+
+	var res scimGroupResource
+	if err := json.NewDecoder(c.Request().Body).Decode(&res); err != nil || res.DisplayName == "" {
+		return scimError(c, http.StatusBadRequest, "displayName is required")
+	}
+
+	orgDB(c).Model(&group).Updates(map[string]interface{}{
+		"display_name": res.DisplayName,
+		"external_id":  res.ExternalID,
+	})
+
+	// Diff membership so role mapping only touches users who actually changed
+	wanted := map[uint]bool{}
+	for _, id := range scimMemberIDs(res.Members) {
+		wanted[id] = true
+	}
+	var add, remove []uint
+	for _, m := range group.Members {
+		if !wanted[m.ID] {
+			remove = append(remove, m.ID)
+		}
+		delete(wanted, m.ID)
+	}
+	for id := range wanted {
+		add = append(add, id)
+	}
+	setGroupMembers(c, &group, add, remove)
+
+	group, _ = loadGroup(c, c.Param("id"))
+	return scimJSON(c, http.StatusOK, toSCIMGroup(group))
+}
+
+var scimMemberPathRegex = regexp.MustCompile(`^members\[value eq "([^"]+)"\]$`)
+
+func SCIMPatchGroup(c echo.Context) error {
+	group, err := loadGroup(c, c.Param("id"))
+	if err != nil {
+		return scimError(c, http.StatusNotFound, "group not found")
+	}
+
+	var req scimPatchRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return scimError(c, http.StatusBadRequest, "invalid request body")
+	}
+
+	for _, op := range req.Operations {
+		var members []scimMultiValue
+		if op.Path == "members" || op.Path == "" {
+			json.Unmarshal(op.Value, &members)
+		}
+
+		switch strings.ToLower(op.Op) {
+		case "add":
+			setGroupMembers(c, &group, scimMemberIDs(members), nil)
+		case "remove":
+			if m := scimMemberPathRegex.FindStringSubmatch(op.Path); m != nil {
+				members = []scimMultiValue{{Value: m[1]}}
+			} else if op.Path == "members" && len(members) == 0 {
+				// Remove all members
+				for _, u := range group.Members {
+					members = append(members, scimMultiValue{Value: strconv.Itoa(int(u.ID))})
+				}
+			}
+			setGroupMembers(c, &group, nil, scimMemberIDs(members))
+		case "replace":
+			switch op.Path {
+			case "displayName":
+				var name string
+				json.Unmarshal(op.Value, &name)
+				orgDB(c).Model(&group).Update("display_name", name)
+			case "externalId":
+				var ext string
+				json.Unmarshal(op.Value, &ext)
+				orgDB(c).Model(&group).Update("external_id", ext)
+			case "members":
+				var current []uint
+				for _, u := range group.Members {
+					current = append(current, u.ID)
+				}
+				setGroupMembers(c, &group, nil, current)
+				setGroupMembers(c, &group, scimMemberIDs(members), nil)
+			case "":
+				var attrs struct {
+					DisplayName string `json:"displayName"`
+					ExternalID  string `json:"externalId"`
+				}
+				json.Unmarshal(op.Value, &attrs)
+				if attrs.DisplayName != "" {
+					orgDB(c).Model(&group).Update("display_name", attrs.DisplayName)
+				}
+				if attrs.ExternalID != "" {
+					orgDB(c).Model(&group).Update("external_id", attrs.ExternalID)
+				}
+			}
+		default:
+			return scimError(c, http.StatusBadRequest, "unsupported op "+op.Op)
+		}
+
+		group, _ = loadGroup(c, c.Param("id"))
+	}
+
+	return scimJSON(c, http.StatusOK, toSCIMGroup(group))
+}
+
+func SCIMDeleteGroup(c echo.Context) error {
+	group, err := loadGroup(c, c.Param("id"))
+	if err != nil {
+		return scimError(c, http.StatusNotFound, "group not found")
+	}
+
+	var members []uint
+	for _, u := range group.Members {
+		members = append(members, u.ID)
+	}
+	setGroupMembers(c, &group, nil, members)
+	orgDB(c).Delete(&group)
+	return c.NoContent(http.StatusNoContent)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -13,6 +14,7 @@ import (
 	"teampulse/internal/models"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 // Sensitive keywords to strip from window titles
@@ -29,87 +31,215 @@ func filterWindowTitle(title string) string {
 }
 
 // ─── POST /api/agent/segments — Receive batch of segments from desktop agent ───
+// The agent retries a batch until it gets an answer, so ingestion has to be
+// safe to repeat. A segment carrying a client_id is stored once per user and
+// a resend updates it in place; one without is matched on its user, times,
+// type and app. A batch sent with an Idempotency-Key header is answered from
+// the first response when the same key comes again.
 
-func ReceiveSegments(c echo.Context) error {
-	userID := mw.GetUserID(c)
+const segmentBatchRetention = 7 * 24 * time.Hour
 
-	var segments []models.SegmentRequest
-	if err := c.Bind(&segments); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
-	}
+var validSegmentTypes = map[string]bool{"active": true, "idle": true, "app_usage": true}
 
-	if len(segments) == 0 {
-		return c.JSON(http.StatusOK, map[string]interface{}{"status": "ok", "received": 0})
+// parseSegment checks one segment of a batch and builds its record
+func parseSegment(seg models.SegmentRequest, userID uint, loc *time.Location) (models.ActivitySegment, string) {
+	if len(seg.ClientID) > 64 {
+		return models.ActivitySegment{}, "client_id is too long (64 characters max)"
+	}
+	startTime, err := time.Parse(time.RFC3339, seg.StartTime)
+	if err != nil {
+		return models.ActivitySegment{}, "invalid start_time"
+	}
+	endTime, err := time.Parse(time.RFC3339, seg.EndTime)
+	if err != nil {
+		return models.ActivitySegment{}, "invalid end_time"
+	}
+	if !endTime.After(startTime) {
+		return models.ActivitySegment{}, "end_time must be after start_time"
+	}
+	if !validSegmentTypes[seg.SegmentType] {
+		return models.ActivitySegment{}, "segment_type must be active, idle or app_usage"
 	}
 
-	// Ensure agent_setup_done is true
-	database.DB.Model(&models.User{}).Where("id = ? AND agent_setup_done = false", userID).Update("agent_setup_done", true)
+	return models.ActivitySegment{
+		UserID:      userID,
+		ClientID:    seg.ClientID,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		Duration:    int(endTime.Sub(startTime).Seconds()),
+		SegmentType: seg.SegmentType,
+		AppName:     seg.AppName,
+		// Privacy: filter sensitive window titles
+		WindowTitle:  filterWindowTitle(seg.WindowTitle),
+		MouseMoves:   seg.MouseMoves,
+		MouseClicks:  seg.MouseClicks,
+		Keystrokes:   seg.Keystrokes,
+		ScrollEvents: seg.ScrollEvents,
+		// Segments are filed under the user's local day
+		Date: dateIn(startTime, loc),
+	}, ""
+}
 
-	affectedDates := map[string]bool{}
-	saved := 0
+// isUniqueViolation reports whether err is Postgres refusing a row that
+// duplicates another under the named unique index: idx_segment_user_client
+// (same user and client_id) or idx_segment_natural_key (same user, times,
+// type and app, for segments without a client_id)
+func isUniqueViolation(err error, index string) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505" &&
+		strings.Contains(err.Error(), index)
+}
 
-	for _, seg := range segments {
-		startTime, err := time.Parse(time.RFC3339, seg.StartTime)
-		if err != nil {
-			continue
+// storeSegment saves record unless it is already stored, returning the stored
+// ID, whether it was new, and every date whose totals it touched. An error
+// means the segment may not be stored and the batch should be retried.
+func storeSegment(db *gorm.DB, record models.ActivitySegment) (uint, bool, []string, error) {
+	var existing models.ActivitySegment
+	if record.ClientID != "" {
+		err := db.Where("user_id = ? AND client_id = ?", record.UserID, record.ClientID).First(&existing).Error
+		if err == nil {
+			oldDate := existing.Date
+			record.ID = existing.ID
+			record.CreatedAt = existing.CreatedAt
+			if err := db.Save(&record).Error; err != nil {
+				return 0, false, nil, err
+			}
+			return record.ID, false, []string{oldDate, record.Date}, nil
 		}
-		endTime, err := time.Parse(time.RFC3339, seg.EndTime)
-		if err != nil {
-			continue
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, false, nil, err
 		}
+	}
 
-		// Validate: end > start
-		if !endTime.After(startTime) {
-			continue
+	sameSegment := db.Where("user_id = ? AND start_time = ? AND end_time = ? AND segment_type = ? AND app_name = ?",
+		record.UserID, record.StartTime, record.EndTime, record.SegmentType, record.AppName).
+		Session(&gorm.Session{}) // reusable for the re-read below
+	err := sameSegment.First(&existing).Error
+	if err == nil {
+		if existing.ClientID == "" && record.ClientID != "" {
+			db.Model(&existing).Update("client_id", record.ClientID)
 		}
+		return existing.ID, false, nil, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil, err
+	}
 
-		duration := int(endTime.Sub(startTime).Seconds())
-		date := startTime.Local().Format("2006-01-02")
-
-		// Privacy: filter sensitive window titles
-		windowTitle := filterWindowTitle(seg.WindowTitle)
-
-		record := models.ActivitySegment{
-			UserID:       userID,
-			StartTime:    startTime,
-			EndTime:      endTime,
-			Duration:     duration,
-			SegmentType:  seg.SegmentType,
-			AppName:      seg.AppName,
-			WindowTitle:  windowTitle,
-			MouseMoves:   seg.MouseMoves,
-			MouseClicks:  seg.MouseClicks,
-			Keystrokes:   seg.Keystrokes,
-			ScrollEvents: seg.ScrollEvents,
-			Date:         date,
+	if err := db.Create(&record).Error; err != nil {
+		// Lost a race with a concurrent resend of the same segment
+		switch {
+		case isUniqueViolation(err, "idx_segment_user_client"):
+			err = db.Where("user_id = ? AND client_id = ?", record.UserID, record.ClientID).First(&existing).Error
+		case isUniqueViolation(err, "idx_segment_natural_key"):
+			err = sameSegment.First(&existing).Error
 		}
+		if err != nil {
+			return 0, false, nil, err
+		}
+		return existing.ID, false, nil, nil
+	}
+	return record.ID, true, []string{record.Date}, nil
+}
+
+func ReceiveSegments(c echo.Context) error {
+	userID := mw.GetUserID(c)
 
-		if err := database.DB.Create(&record).Error; err != nil {
-			continue
+	key := strings.TrimSpace(c.Request().Header.Get("Idempotency-Key"))
+	if len(key) > 128 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Idempotency-Key is too long (128 characters max)"})
+	}
+	if key != "" {
+		var batch models.SegmentBatch
+		if orgDB(c).Where("user_id = ? AND idempotency_key = ?", userID, key).First(&batch).Error == nil {
+			c.Response().Header().Set("Idempotent-Replayed", "true")
+			return c.JSONBlob(http.StatusOK, []byte(batch.Response))
 		}
+	}
+
+	var segments []models.SegmentRequest
+	if err := c.Bind(&segments); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	resp := models.SegmentBatchResponse{Status: "ok", Results: []models.SegmentResult{}}
+	if len(segments) == 0 {
+		return c.JSON(http.StatusOK, resp)
+	}
 
-		affectedDates[date] = true
-		saved++
+	// Ensure agent_setup_done is true
+	orgDB(c).Model(&models.User{}).Where("id = ? AND agent_setup_done = false", userID).Update("agent_setup_done", true)
+
+	loc := userLocation(orgDB(c), userID)
+	affectedDates := map[string]bool{}
+	seen := map[string]int{} // client_id → index earlier in this batch
+
+	for i, seg := range segments {
+		result := models.SegmentResult{Index: i, ClientID: seg.ClientID}
+
+		record, msg := parseSegment(seg, userID, loc)
+		switch {
+		case msg != "":
+			result.Status = models.SegmentRejected
+			result.Error = msg
+			resp.Rejected++
+		case seg.ClientID != "" && seen[seg.ClientID] > 0:
+			result.Status = models.SegmentDuplicate
+			result.SegmentID = resp.Results[seen[seg.ClientID]-1].SegmentID
+			resp.Duplicates++
+		default:
+			id, created, dates, err := storeSegment(orgDB(c), record)
+			if err != nil {
+				// Nothing is remembered under the key, so the agent's retry is
+				// processed afresh; segments already stored come back as duplicates
+				for date := range affectedDates {
+					updateDailyAggregation(orgDB(c), userID, date)
+				}
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store segments"})
+			}
+			result.SegmentID = id
+			if created {
+				result.Status = models.SegmentAccepted
+				resp.Accepted++
+			} else {
+				result.Status = models.SegmentDuplicate
+				resp.Duplicates++
+			}
+			for _, d := range dates {
+				affectedDates[d] = true
+			}
+			if seg.ClientID != "" {
+				seen[seg.ClientID] = i + 1
+			}
+		}
+		resp.Results = append(resp.Results, result)
 	}
+	resp.Received = resp.Accepted + resp.Duplicates
 
 	// Update daily aggregations for affected dates
 	for date := range affectedDates {
-		updateDailyAggregation(userID, date)
+		updateDailyAggregation(orgDB(c), userID, date)
 	}
 
 	// Broadcast to WebSocket clients
-	if saved > 0 {
-		BroadcastMonitorUpdate("segments", map[string]interface{}{
+	if resp.Accepted > 0 {
+		BroadcastMonitorUpdate(mw.GetOrgID(c), "segments", map[string]interface{}{
 			"user_id":  userID,
-			"received": saved,
+			"received": resp.Accepted,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{"status": "ok", "received": saved})
+	if key != "" {
+		body, _ := json.Marshal(resp)
+		orgDB(c).Where("user_id = ? AND created_at < ?", userID, time.Now().Add(-segmentBatchRetention)).
+			Delete(&models.SegmentBatch{})
+		orgDB(c).Create(&models.SegmentBatch{UserID: userID, IdempotencyKey: key, Response: string(body)})
+	}
+
+	return c.JSON(http.StatusOK, resp)
 }
 
 // updateDailyAggregation recalculates the daily aggregation for a user+date
-func updateDailyAggregation(userID uint, date string) {
+func updateDailyAggregation(db *gorm.DB, userID uint, date string) {
 	type Sums struct {
 		TotalActive  int
 		TotalIdle    int
@@ -120,13 +250,13 @@ func updateDailyAggregation(userID uint, date string) {
 	}
 
 	var activeSums Sums
-	database.DB.Model(&models.ActivitySegment{}).
+	db.Model(&models.ActivitySegment{}).
 		Select("COALESCE(SUM(duration),0) as total_active, COALESCE(SUM(mouse_moves),0) as mouse_moves, COALESCE(SUM(mouse_clicks),0) as mouse_clicks, COALESCE(SUM(keystrokes),0) as keystrokes, COALESCE(SUM(scroll_events),0) as scroll_events").
 		Where("user_id = ? AND date = ? AND segment_type = 'active'", userID, date).
 		Scan(&activeSums)
 
 	var idleSums struct{ TotalIdle int }
-	database.DB.Model(&models.ActivitySegment{}).
+	db.Model(&models.ActivitySegment{}).
 		Select("COALESCE(SUM(duration),0) as total_idle").
 		Where("user_id = ? AND date = ? AND segment_type = 'idle'", userID, date).
 		Scan(&idleSums)
@@ -137,7 +267,7 @@ func updateDailyAggregation(userID uint, date string) {
 		Duration int    `json:"Duration"`
 	}
 	var topApps []AppDur
-	database.DB.Model(&models.ActivitySegment{}).
+	db.Model(&models.ActivitySegment{}).
 		Select("app_name, SUM(duration) as duration").
 		Where("user_id = ? AND date = ? AND app_name != '' AND segment_type = 'active'", userID, date).
 		Group("app_name").
@@ -148,7 +278,7 @@ func updateDailyAggregation(userID uint, date string) {
 	topAppsJSON, _ := json.Marshal(topApps)
 
 	agg := models.DailyAggregation{}
-	result := database.DB.Where("user_id = ? AND date = ?", userID, date).First(&agg)
+	result := db.Where("user_id = ? AND date = ?", userID, date).First(&agg)
 
 	agg.UserID = userID
 	agg.Date = date
@@ -162,20 +292,15 @@ func updateDailyAggregation(userID uint, date string) {
 	agg.UpdatedAt = time.Now()
 
 	if result.Error != nil {
-		database.DB.Create(&agg)
+		db.Create(&agg)
 	} else {
-		database.DB.Save(&agg)
+		db.Save(&agg)
 	}
 }
 
-// ─── GET /api/segments?user_id=X&date=YYYY-MM-DD — Admin: get segments for timeline ───
+// ─── GET /api/segments?user_id=X&date=YYYY-MM-DD — timeline:view: get segments for timeline ───
 
 func GetSegments(c echo.Context) error {
-	role := mw.GetUserRole(c)
-	if role != models.RoleAdmin {
-		return c.JSON(http.StatusForbidden, map[string]string{"error": "admin access required"})
-	}
-
 	userIDStr := c.QueryParam("user_id")
 	date := c.QueryParam("date")
 
@@ -187,13 +312,16 @@ func GetSegments(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user_id"})
 	}
+	if !canViewUser(c, uint(userID)) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not in your team"})
+	}
 
 	// Audit log
 	adminID := mw.GetUserID(c)
 	logAudit(adminID, "viewed_timeline", uint(userID), fmt.Sprintf("date=%s", date))
 
 	var segments []models.ActivitySegment
-	database.DB.Where("user_id = ? AND date = ?", userID, date).
+	orgDB(c).Where("user_id = ? AND date = ?", userID, date).
 		Order("start_time asc").
 		Find(&segments)
 
@@ -206,11 +334,11 @@ func GetMySegments(c echo.Context) error {
 	userID := mw.GetUserID(c)
 	date := c.QueryParam("date")
 	if date == "" {
-		date = time.Now().Format("2006-01-02")
+		date = todayIn(callerLocation(c))
 	}
 
 	var segments []models.ActivitySegment
-	database.DB.Where("user_id = ? AND date = ?", userID, date).
+	orgDB(c).Where("user_id = ? AND date = ?", userID, date).
 		Order("start_time asc").
 		Find(&segments)
 
@@ -222,55 +350,61 @@ func GetMySegments(c echo.Context) error {
 func GetAggregations(c echo.Context) error {
 	date := c.QueryParam("date")
 	if date == "" {
-		date = time.Now().Format("2006-01-02")
+		date = todayIn(callerLocation(c))
 	}
 
 	var aggregations []models.DailyAggregation
-	database.DB.Preload("User").Where("date = ?", date).Find(&aggregations)
+	orgDB(c).Preload("User").Where("date = ?", date).
+		Scopes(scopeToVisible(c, "user_id", false)).Find(&aggregations)
 
 	return c.JSON(http.StatusOK, aggregations)
 }
 
-// ─── GET /api/employee/:id/timeline?date=YYYY-MM-DD — Admin: full timeline ───
+// ─── GET /api/employee/:id/timeline?date=YYYY-MM-DD — timeline:view: full timeline ───
 
 func GetEmployeeTimeline(c echo.Context) error {
-	role := mw.GetUserRole(c)
-	if role != models.RoleAdmin {
-		return c.JSON(http.StatusForbidden, map[string]string{"error": "admin access required"})
-	}
-
 	idStr := c.Param("id")
 	employeeID, err := strconv.Atoi(idStr)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid employee id"})
 	}
 
+	if !canViewUser(c, uint(employeeID)) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not in your team"})
+	}
+
 	date := c.QueryParam("date")
 	if date == "" {
-		date = time.Now().Format("2006-01-02")
+		date = todayIn(userLocation(orgDB(c), uint(employeeID)))
 	}
 
 	adminID := mw.GetUserID(c)
 	logAudit(adminID, "viewed_employee_timeline", uint(employeeID), fmt.Sprintf("date=%s", date))
 
 	var segments []models.ActivitySegment
-	database.DB.Where("user_id = ? AND date = ?", employeeID, date).
+	orgDB(c).Where("user_id = ? AND date = ?", employeeID, date).
 		Order("start_time asc").
 		Find(&segments)
 
 	var agg models.DailyAggregation
-	database.DB.Where("user_id = ? AND date = ?", employeeID, date).First(&agg)
+	orgDB(c).Where("user_id = ? AND date = ?", employeeID, date).First(&agg)
 
 	return c.JSON(http.StatusOK, models.TimelineResponse{
 		Segments:    segments,
 		Aggregation: &agg,
+		Holiday:     userHolidays(orgDB(c), []uint{uint(employeeID)}, date, date)[uint(employeeID)][date],
 	})
 }
 
 // ─── Audit Logging ───────────────────────────────────────────
 
 func logAudit(adminID uint, action string, targetID uint, details string) {
+	// Entries belong to the acting admin's organization
+	var admin models.User
+	database.DB.Select("id", "org_id").First(&admin, adminID)
+
 	entry := models.AuditLog{
+		OrgID:    admin.OrgID,
 		AdminID:  adminID,
 		Action:   action,
 		TargetID: targetID,
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"teampulse/internal/database"
+	"teampulse/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// pgError stands in for the driver's error type
+type pgError struct{ code, message string }
+
+func (e pgError) Error() string    { return e.message }
+func (e pgError) SQLState() string { return e.code }
+
+func TestIsUniqueViolation(t *testing.T) {
+	dup := pgError{"23505", `ERROR: duplicate key value violates unique constraint "idx_segment_natural_key" (SQLSTATE 23505)`}
+	tests := []struct {
+		name  string
+		err   error
+		index string
+		want  bool
+	}{
+		{"named index", dup, "idx_segment_natural_key", true},
+		{"wrapped", fmt.Errorf("insert: %w", dup), "idx_segment_natural_key", true},
+		{"other index", dup, "idx_segment_user_client", false},
+		{"other error", pgError{"23503", `violates foreign key constraint "idx_segment_natural_key"`}, "idx_segment_natural_key", false},
+		{"not postgres", errors.New("idx_segment_natural_key"), "idx_segment_natural_key", false},
+	}
+	for _, tt := range tests {
+		if got := isUniqueViolation(tt.err, tt.index); got != tt.want {
+			t.Errorf("%s: isUniqueViolation() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStoreSegmentNaturalKeyRace(t *testing.T) {
+	mock := mockDB(t)
+	sameSegment := `SELECT \* FROM "activity_segments" WHERE user_id = \$1 AND start_time = \$2 AND end_time = \$3 AND segment_type = \$4 AND app_name = \$5 ORDER BY "activity_segments"."id" LIMIT \$6$`
+	mock.ExpectQuery(sameSegment).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	// A concurrent resend inserts the same segment first
+	mock.ExpectQuery(`INSERT INTO "activity_segments"`).
+		WillReturnError(pgError{"23505", `ERROR: duplicate key value violates unique constraint "idx_segment_natural_key" (SQLSTATE 23505)`})
+	mock.ExpectQuery(sameSegment).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	record := models.ActivitySegment{UserID: 9, StartTime: start, EndTime: start.Add(time.Minute),
+		SegmentType: "active", Date: "2026-03-02"}
+	id, created, dates, err := storeSegment(database.DB, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 42 || created || dates != nil {
+		t.Errorf("storeSegment() = %d, %v, %v; want the stored segment 42 as a duplicate", id, created, dates)
+	}
+}
+
+func TestReceiveSegmentsReplaysIdempotencyKey(t *testing.T) {
+	user := models.User{ID: 9, OrgID: 1, Role: models.RoleEmployee, IsActive: true}
+	stored := `{"accepted":1,"duplicates":0,"rejected":0}`
+
+	mock := mockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "segment_batches" WHERE user_id = \$1 AND idempotency_key = \$2`).
+		WithArgs(9, "batch-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "idempotency_key", "response"}).AddRow(1, 9, "batch-1", stored))
+
+	c, rec := newContext(http.MethodPost, "/api/segments", `[]`, &user)
+	c.Request().Header.Set("Idempotency-Key", "batch-1")
+	if err := ReceiveSegments(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != stored {
+		t.Errorf("got %d %s, want 200 %s", rec.Code, rec.Body, stored)
+	}
+	if rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("missing Idempotent-Replayed header")
+	}
+}
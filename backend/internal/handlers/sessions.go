@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ─── Web Sessions ────────────────────────────────────────────
+
+// startSession records a new web login and responds with its first
+// access token and refresh secret.
+func startSession(c echo.Context, user models.User) error {
+	secret := newRefreshSecret()
+	now := time.Now()
+	session := models.Session{
+		UserID:      user.ID,
+		RefreshHash: hashSecret(secret),
+		UserAgent:   c.Request().UserAgent(),
+		IP:          c.RealIP(),
+		ExpiresAt:   now.Add(mw.SessionTTL),
+		LastUsedAt:  &now,
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create session"})
+	}
+
+	token, expiresAt, err := mw.GenerateToken(user, session.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+	}
+
+	user.MFAEnrollmentRequired = mw.MFAEnrollmentRequired(user)
+	return c.JSON(http.StatusOK, models.LoginResponse{
+		Token:        token,
+		ExpiresAt:    expiresAt,
+		RefreshToken: secret,
+		User:         user,
+	})
+}
+
+// RefreshSession is a PUBLIC endpoint (no JWT required).
+// The browser trades its refresh secret for a new access token; the secret
+// is rotated on every use.
+func RefreshSession(c echo.Context) error {
+	var req models.RefreshRequest
+	if err := c.Bind(&req); err != nil || req.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "refresh_token is required"})
+	}
+
+	now := time.Now()
+	var session models.Session
+	err := database.DB.Where("refresh_hash = ? AND revoked_at IS NULL AND expires_at > ?", hashSecret(req.RefreshToken), now).
+		First(&session).Error
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "session expired"})
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ? AND is_active = true", session.UserID).First(&user).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user is inactive"})
+	}
+
+	// Conditional on the old hash so two concurrent refreshes can't both win
+	secret := newRefreshSecret()
+	result := database.DB.Model(&models.Session{}).
+		Where("id = ? AND refresh_hash = ?", session.ID, session.RefreshHash).
+		Updates(map[string]interface{}{
+			"refresh_hash": hashSecret(secret),
+			"last_used_at": now,
+			"ip":           c.RealIP(),
+		})
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "session expired"})
+	}
+
+	token, expiresAt, err := mw.GenerateToken(user, session.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+	}
+
+	user.MFAEnrollmentRequired = mw.MFAEnrollmentRequired(user)
+	return c.JSON(http.StatusOK, models.LoginResponse{
+		Token:        token,
+		ExpiresAt:    expiresAt,
+		RefreshToken: secret,
+		User:         user,
+	})
+}
+
+// Logout revokes the caller's current web session
+func Logout(c echo.Context) error {
+	sessionID := mw.GetSessionID(c)
+	if sessionID != 0 {
+		orgDB(c).Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", time.Now())
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "logged_out"})
+}
+
+// LogoutEverywhere revokes every session and agent device of the caller
+func LogoutEverywhere(c echo.Context) error {
+	revokeUserCredentials(mw.GetUserID(c))
+	return c.JSON(http.StatusOK, map[string]string{"status": "logged_out"})
+}
+
+// AdminLogoutEmployee — Admin: revoke every session and agent device of an employee
+func AdminLogoutEmployee(c echo.Context) error {
+	employeeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid employee id"})
+	}
+
+	revokeUserCredentials(uint(employeeID))
+	logAudit(mw.GetUserID(c), "logged_out_everywhere", uint(employeeID), "")
+
+	return c.JSON(http.StatusOK, map[string]string{"status": "logged_out"})
+}
+
+// ListMySessions returns the caller's active web sessions
+func ListMySessions(c echo.Context) error {
+	userID := mw.GetUserID(c)
+
+	var sessions []models.Session
+	orgDB(c).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at desc").
+		Find(&sessions)
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// revokeUserCredentials ends all web sessions, agent devices and API keys for a user
+func revokeUserCredentials(userID uint) {
+	now := time.Now()
+	database.DB.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now)
+	database.DB.Model(&models.Device{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now)
+	database.DB.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/payperiod"
+	"teampulse/internal/rounding"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ─── Org Settings ────────────────────────────────────────────
+
+// GetSettings — Admin: organisation-wide policy
+func GetSettings(c echo.Context) error {
+	return c.JSON(http.StatusOK, database.GetOrgSettings(mw.GetOrgID(c)))
+}
+
+// UpdateSettings — Admin: change organisation-wide policy. Only the fields
+// present in the request body are changed.
+func UpdateSettings(c echo.Context) error {
+	settings := database.GetOrgSettings(mw.GetOrgID(c))
+	id := settings.ID
+
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	settings.ID = id
+
+	if settings.MaxSessionHours < 0 || settings.MaxSessionHours > 72 || settings.IdleClockOutMinutes < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "max_session_hours must be 0-72 and idle_clock_out_minutes not negative"})
+	}
+	if settings.DailyOvertimeHours < 0 || settings.DailyDoubleTimeHours < 0 || settings.WeeklyOvertimeHours < 0 ||
+		(settings.DailyOvertimeHours > 0 && settings.DailyDoubleTimeHours > 0 && settings.DailyDoubleTimeHours < settings.DailyOvertimeHours) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "overtime thresholds must not be negative, and double time must start after overtime"})
+	}
+
+	if !payperiod.ValidKind(settings.PayPeriod) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "pay_period must be weekly, biweekly, semimonthly or monthly"})
+	}
+	anchor, err := time.Parse("2006-01-02", settings.PayPeriodAnchor)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "pay_period_anchor must be YYYY-MM-DD"})
+	}
+	if settings.PayPeriod == string(payperiod.Monthly) && anchor.Day() > payperiod.MaxAnchorDay {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "monthly pay periods must start on or before the 28th"})
+	}
+
+	if settings.AttendanceGraceMinutes < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "attendance_grace_minutes must not be negative"})
+	}
+	if settings.RoundingIntervalMinutes < 0 || settings.RoundingIntervalMinutes > 60 ||
+		settings.RoundingGraceMinutes < 0 || settings.RoundingGraceMinutes > settings.RoundingIntervalMinutes {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "rounding_interval_minutes must be 0-60 and rounding_grace_minutes 0 up to the interval"})
+	}
+	if !rounding.ValidMode(settings.RoundClockIn) || !rounding.ValidMode(settings.RoundClockOut) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "round_clock_in and round_clock_out must be nearest, up or down"})
+	}
+	if !validTimeZone(settings.TimeZone) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "time_zone must be an IANA zone such as Europe/London"})
+	}
+
+	if err := orgDB(c).Save(&settings).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save settings"})
+	}
+
+	logAudit(mw.GetUserID(c), "updated_settings", 0, "")
+	return c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ─── Shift Scheduling ────────────────────────────────────────
+// Holders of schedule:manage plan shifts for their team, one at a time or by
+// applying a weekly template. Attendance compares the schedule with what was
+// clocked.
+
+const maxShiftLength = 24 * time.Hour
+
+// shiftOverlaps reports whether userID already has a shift overlapping
+// [start, end), ignoring exceptID
+func shiftOverlaps(db *gorm.DB, userID uint, start, end time.Time, exceptID uint) bool {
+	var count int64
+	db.Model(&models.Shift{}).
+		Where("user_id = ? AND starts_at < ? AND ends_at > ? AND id != ?", userID, end, start, exceptID).
+		Count(&count)
+	return count > 0
+}
+
+// validateShift checks a shift request against the caller's view and the
+// user's existing shifts
+func validateShift(c echo.Context, req *models.ShiftRequest, exceptID uint) (int, string) {
+	req.Role = strings.TrimSpace(req.Role)
+	req.Location = strings.TrimSpace(req.Location)
+	if req.UserID == 0 || !canViewUser(c, req.UserID) {
+		return http.StatusBadRequest, "invalid user_id"
+	}
+	if req.StartsAt.IsZero() || !req.EndsAt.After(req.StartsAt) {
+		return http.StatusBadRequest, "ends_at must be after starts_at"
+	}
+	if req.EndsAt.Sub(req.StartsAt) > maxShiftLength {
+		return http.StatusBadRequest, "shifts can be at most 24 hours long"
+	}
+	if len(req.Role) > 100 || len(req.Location) > 100 {
+		return http.StatusBadRequest, "role and location are 100 characters max"
+	}
+	if shiftOverlaps(orgDB(c), req.UserID, req.StartsAt, req.EndsAt, exceptID) {
+		return http.StatusConflict, "overlaps another shift for this user"
+	}
+	return 0, ""
+}
+
+// shiftRange reads ?from= and ?to= (YYYY-MM-DD, inclusive) in loc, defaulting
+// to the two weeks starting today
+func shiftRange(c echo.Context, loc *time.Location) (time.Time, time.Time, bool) {
+	from, _ := dayBounds(todayIn(loc), loc)
+	to := from.AddDate(0, 0, 14)
+	if f := c.QueryParam("from"); f != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", f, loc)
+		if err != nil {
+			return from, to, false
+		}
+		from = parsed
+	}
+	if t := c.QueryParam("to"); t != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", t, loc)
+		if err != nil {
+			return from, to, false
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	return from, to, to.After(from)
+}
+
+// ListMyShifts — Employee: your shifts starting ?from= to ?to= (default the next two weeks)
+func ListMyShifts(c echo.Context) error {
+	from, to, ok := shiftRange(c, callerLocation(c))
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "from and to must be YYYY-MM-DD, from before to"})
+	}
+
+	var shifts []models.Shift
+	orgDB(c).Where("user_id = ? AND starts_at >= ? AND starts_at < ?", mw.GetUserID(c), from, to).
+		Order("starts_at asc").Find(&shifts)
+	return c.JSON(http.StatusOK, shifts)
+}
+
+// ListShifts — schedule:manage: the team's shifts starting ?from= to ?to=,
+// optionally for one ?user_id=
+func ListShifts(c echo.Context) error {
+	from, to, ok := shiftRange(c, callerLocation(c))
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "from and to must be YYYY-MM-DD, from before to"})
+	}
+
+	q := orgDB(c).Preload("User").Where("starts_at >= ? AND starts_at < ?", from, to).
+		Scopes(scopeToVisible(c, "user_id", true))
+	if id := c.QueryParam("user_id"); id != "" {
+		q = q.Where("user_id = ?", id)
+	}
+
+	var shifts []models.Shift
+	q.Order("starts_at asc, user_id asc").Limit(1000).Find(&shifts)
+	return c.JSON(http.StatusOK, shifts)
+}
+
+// CreateShift — schedule:manage: `{user_id, starts_at, ends_at, role, location, notes}`
+func CreateShift(c echo.Context) error {
+	var req models.ShiftRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if status, msg := validateShift(c, &req, 0); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	shift := models.Shift{
+		UserID:    req.UserID,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Role:      req.Role,
+		Location:  req.Location,
+		Notes:     req.Notes,
+		CreatedBy: mw.GetUserID(c),
+	}
+	orgDB(c).Create(&shift)
+	return c.JSON(http.StatusCreated, shift)
+}
+
+// loadShift finds a shift the caller may change
+func loadShift(c echo.Context) (models.Shift, int, string) {
+	var shift models.Shift
+	if err := orgDB(c).First(&shift, c.Param("id")).Error; err != nil {
+		return shift, http.StatusNotFound, "shift not found"
+	}
+	if !canViewUser(c, shift.UserID) {
+		return shift, http.StatusForbidden, "not in your team"
+	}
+	return shift, 0, ""
+}
+
+// UpdateShift — schedule:manage: move or reassign a shift (same body as create)
+func UpdateShift(c echo.Context) error {
+	shift, status, msg := loadShift(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	var req models.ShiftRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if status, msg := validateShift(c, &req, shift.ID); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	shift.UserID = req.UserID
+	shift.StartsAt = req.StartsAt
+	shift.EndsAt = req.EndsAt
+	shift.Role = req.Role
+	shift.Location = req.Location
+	shift.Notes = req.Notes
+	orgDB(c).Omit("User").Save(&shift)
+	return c.JSON(http.StatusOK, shift)
+}
+
+// DeleteShift — schedule:manage
+func DeleteShift(c echo.Context) error {
+	shift, status, msg := loadShift(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	orgDB(c).Delete(&shift)
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ─── Shift Templates ─────────────────────────────────────────
+
+type shiftTemplateRequest struct {
+	Name  string             `json:"name"`
+	Slots []models.ShiftSlot `json:"slots"`
+}
+
+// validateShiftTemplate normalises a template request and checks its slots
+func validateShiftTemplate(c echo.Context, req *shiftTemplateRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required (100 characters max)"
+	}
+	if len(req.Slots) == 0 {
+		return "at least one slot is required"
+	}
+	for i, slot := range req.Slots {
+		if slot.Day < 0 || slot.Day > 6 {
+			return fmt.Sprintf("slot %d: day must be 0-6", i)
+		}
+		if _, err := time.Parse("15:04", slot.Start); err != nil {
+			return fmt.Sprintf("slot %d: start must be HH:MM", i)
+		}
+		if _, err := time.Parse("15:04", slot.End); err != nil {
+			return fmt.Sprintf("slot %d: end must be HH:MM", i)
+		}
+		if slot.UserID == 0 || !canViewUser(c, slot.UserID) {
+			return fmt.Sprintf("slot %d: invalid user_id", i)
+		}
+		req.Slots[i].Role = strings.TrimSpace(slot.Role)
+		req.Slots[i].Location = strings.TrimSpace(slot.Location)
+	}
+	return ""
+}
+
+// ListShiftTemplates — schedule:manage
+func ListShiftTemplates(c echo.Context) error {
+	var templates []models.ShiftTemplate
+	orgDB(c).Order("name asc").Find(&templates)
+	return c.JSON(http.StatusOK, templates)
+}
+
+// CreateShiftTemplate — schedule:manage: `{name, slots: [{day, start, end, user_id, role, location}]}`
+func CreateShiftTemplate(c echo.Context) error {
+	var req shiftTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateShiftTemplate(c, &req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	tmpl := models.ShiftTemplate{Name: req.Name, Slots: req.Slots}
+	if err := orgDB(c).Create(&tmpl).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "template name already exists"})
+	}
+	return c.JSON(http.StatusCreated, tmpl)
+}
+
+// UpdateShiftTemplate — schedule:manage: replace a template's name and slots
+func UpdateShiftTemplate(c echo.Context) error {
+	var tmpl models.ShiftTemplate
+	if err := orgDB(c).First(&tmpl, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+
+	var req shiftTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if msg := validateShiftTemplate(c, &req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	tmpl.Name = req.Name
+	tmpl.Slots = req.Slots
+	if err := orgDB(c).Save(&tmpl).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "template name already exists"})
+	}
+	return c.JSON(http.StatusOK, tmpl)
+}
+
+// DeleteShiftTemplate — schedule:manage. Shifts already created from it stay.
+func DeleteShiftTemplate(c echo.Context) error {
+	var tmpl models.ShiftTemplate
+	if err := orgDB(c).First(&tmpl, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	orgDB(c).Delete(&tmpl)
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ApplyShiftTemplate — schedule:manage: create the template's shifts for the
+// week starting `{week_start}`. Slots for users outside the caller's team, or
+// clashing with an existing shift, are skipped.
+func ApplyShiftTemplate(c echo.Context) error {
+	var tmpl models.ShiftTemplate
+	if err := orgDB(c).First(&tmpl, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+
+	var req models.ApplyShiftTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	weekStart, err := time.Parse("2006-01-02", req.WeekStart)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "week_start must be YYYY-MM-DD"})
+	}
+
+	userIDs := make([]uint, 0, len(tmpl.Slots))
+	for _, slot := range tmpl.Slots {
+		userIDs = append(userIDs, slot.UserID)
+	}
+	locs := userLocations(orgDB(c), userIDs)
+
+	created := []models.Shift{}
+	skipped := 0
+	for _, slot := range tmpl.Slots {
+		day := weekStart.AddDate(0, 0, slot.Day).Format("2006-01-02")
+		loc := locs[slot.UserID]
+		start, errStart := time.ParseInLocation("2006-01-02 15:04", day+" "+slot.Start, loc)
+		end, errEnd := time.ParseInLocation("2006-01-02 15:04", day+" "+slot.End, loc)
+		if errStart != nil || errEnd != nil || !canViewUser(c, slot.UserID) {
+			skipped++
+			continue
+		}
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1) // overnight
+		}
+		if shiftOverlaps(orgDB(c), slot.UserID, start, end, 0) {
+			skipped++
+			continue
+		}
+
+		shift := models.Shift{
+			UserID:     slot.UserID,
+			StartsAt:   start,
+			EndsAt:     end,
+			Role:       slot.Role,
+			Location:   slot.Location,
+			TemplateID: &tmpl.ID,
+			CreatedBy:  mw.GetUserID(c),
+		}
+		orgDB(c).Create(&shift)
+		created = append(created, shift)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"created": created,
+		"skipped": skipped,
+	})
+}
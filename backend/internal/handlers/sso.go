@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"teampulse/internal/database"
+	"teampulse/internal/models"
+	"teampulse/internal/oidc"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ─── OpenID Connect SSO ──────────────────────────────────────
+//
+// Configured with OIDC_ISSUER, OIDC_CLIENT_ID and (for confidential clients)
+// OIDC_CLIENT_SECRET. The provider serves the organization named by OIDC_ORG
+// (its slug; the default organization if unset), and users are only matched
+// or created there: by provider subject, then by email, and unknown users
+// only when OIDC_JIT_PROVISIONING=true.
+
+const oidcLoginTTL = 10 * time.Minute
+
+// oidcStateCookie ties a login's state to the browser that started it, so a
+// callback URL from someone else's login is refused
+const oidcStateCookie = "tp_oidc_state"
+
+var (
+	oidcMu       sync.Mutex
+	oidcProvider *oidc.Provider
+)
+
+func oidcEnabled() bool {
+	return os.Getenv("OIDC_ISSUER") != "" && os.Getenv("OIDC_CLIENT_ID") != ""
+}
+
+// getOIDCProvider discovers the provider on first use. A failed discovery is
+// retried on the next login rather than cached.
+func getOIDCProvider(c echo.Context) (*oidc.Provider, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	if oidcProvider != nil {
+		return oidcProvider, nil
+	}
+
+	redirectURL := os.Getenv("OIDC_REDIRECT_URL")
+	if redirectURL == "" {
+		redirectURL = appURL() + "/api/auth/oidc/callback"
+	}
+	scopes := strings.Fields(os.Getenv("OIDC_SCOPES"))
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	p, err := oidc.Discover(c.Request().Context(), oidc.Config{
+		Issuer:       os.Getenv("OIDC_ISSUER"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	oidcProvider = p
+	return p, nil
+}
+
+// GetSSOConfig is a PUBLIC endpoint telling the login page whether to offer SSO
+func GetSSOConfig(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{"enabled": oidcEnabled()})
+}
+
+// OIDCLogin is a PUBLIC endpoint that starts an SSO login by redirecting the
+// browser to the provider with a fresh state, nonce and PKCE challenge.
+func OIDCLogin(c echo.Context) error {
+	if !oidcEnabled() {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "sso is not configured"})
+	}
+	provider, err := getOIDCProvider(c)
+	if err != nil {
+		log.Printf("ERROR: oidc discovery failed: %v", err)
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "sso provider unavailable"})
+	}
+
+	verifier, challenge := oidc.NewPKCE()
+	req := models.OIDCLoginRequest{
+		State:        oidc.RandomString(24),
+		Nonce:        oidc.RandomString(24),
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcLoginTTL),
+	}
+	if err := database.DB.Create(&req).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to start sso login"})
+	}
+	c.SetCookie(stateCookie(c, req.State, int(oidcLoginTTL.Seconds())))
+
+	return c.Redirect(http.StatusFound, provider.AuthCodeURL(req.State, req.Nonce, challenge))
+}
+
+// stateCookie carries the login state between OIDCLogin and OIDCCallback;
+// maxAge -1 deletes it
+func stateCookie(c echo.Context, state string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/auth/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// OIDCCallback is a PUBLIC endpoint the provider redirects back to. It
+// verifies the ID token, maps it onto a user and sends the browser back to
+// the SPA with a single-use ticket in the URL fragment, which never reaches
+// servers, logs or Referer headers.
+func OIDCCallback(c echo.Context) error {
+	fail := func(reason string) error {
+		return c.Redirect(http.StatusFound, "/?sso_error="+url.QueryEscape(reason))
+	}
+
+	cookie, cookieErr := c.Cookie(oidcStateCookie)
+	c.SetCookie(stateCookie(c, "", -1))
+
+	if e := c.QueryParam("error"); e != "" {
+		return fail(e)
+	}
+
+	state := c.QueryParam("state")
+	if cookieErr != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		return fail("login expired, please try again")
+	}
+
+	now := time.Now()
+	var req models.OIDCLoginRequest
+	err := database.DB.Where("state = ? AND completed_at IS NULL AND expires_at > ?", state, now).
+		First(&req).Error
+	if err != nil {
+		return fail("login expired, please try again")
+	}
+	// Claim the state so the callback can't be replayed
+	result := database.DB.Model(&models.OIDCLoginRequest{}).
+		Where("id = ? AND completed_at IS NULL", req.ID).
+		Update("completed_at", now)
+	if result.RowsAffected == 0 {
+		return fail("login expired, please try again")
+	}
+
+	provider, err := getOIDCProvider(c)
+	if err != nil {
+		log.Printf("ERROR: oidc discovery failed: %v", err)
+		return fail("sso provider unavailable")
+	}
+
+	claims, err := provider.Exchange(c.Request().Context(), c.QueryParam("code"), req.CodeVerifier, req.Nonce)
+	if err != nil {
+		log.Printf("WARN: oidc callback rejected: %v", err)
+		return fail("sso login failed")
+	}
+
+	user, err := findOrProvisionOIDCUser(claims)
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	ticket := newRefreshSecret()
+	ticketHash := hashSecret(ticket)
+	database.DB.Model(&req).Updates(map[string]interface{}{
+		"user_id":     user.ID,
+		"ticket_hash": ticketHash,
+		"expires_at":  now.Add(time.Minute),
+	})
+
+	return c.Redirect(http.StatusFound, "/#sso_ticket="+ticket)
+}
+
+// ExchangeSSOTicket is a PUBLIC endpoint. The SPA trades the ticket from the
+// callback redirect for a session (or an MFA challenge).
+func ExchangeSSOTicket(c echo.Context) error {
+	var req models.SSOTicketRequest
+	if err := c.Bind(&req); err != nil || req.Ticket == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ticket is required"})
+	}
+
+	now := time.Now()
+	var login models.OIDCLoginRequest
+	err := database.DB.Where("ticket_hash = ? AND used_at IS NULL AND expires_at > ?", hashSecret(req.Ticket), now).
+		First(&login).Error
+	if err != nil || login.UserID == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired sso ticket"})
+	}
+	result := database.DB.Model(&models.OIDCLoginRequest{}).
+		Where("id = ? AND used_at IS NULL", login.ID).
+		Update("used_at", now)
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired sso ticket"})
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ? AND is_active = true", *login.UserID).First(&user).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "account is deactivated"})
+	}
+
+	return completeLogin(c, user)
+}
+
+// oidcOrg is the organization the SSO provider signs people in to
+func oidcOrg() (uint, error) {
+	slug := os.Getenv("OIDC_ORG")
+	if slug == "" {
+		return 1, nil
+	}
+	var org models.Organization
+	if err := database.DB.Where("slug = ? AND is_active = true", slug).First(&org).Error; err != nil {
+		log.Printf("ERROR: OIDC_ORG %q is not an active organization", slug)
+		return 0, errors.New("sso is misconfigured")
+	}
+	return org.ID, nil
+}
+
+// findOrProvisionOIDCUser maps provider claims onto a TeamPulse user in the
+// provider's organization: by linked subject, then by (verified) email, then
+// by just-in-time creation.
+func findOrProvisionOIDCUser(claims *oidc.IDTokenClaims) (models.User, error) {
+	var user models.User
+	orgID, err := oidcOrg()
+	if err != nil {
+		return user, err
+	}
+	db := database.ForOrg(orgID)
+
+	err = db.Where("oidc_subject = ?", claims.Subject).First(&user).Error
+	if err == nil {
+		if !user.IsActive {
+			return user, errors.New("account is deactivated")
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, errors.New("sso login failed")
+	}
+
+	emailAddr := strings.ToLower(strings.TrimSpace(claims.Email))
+	// Linking or creating by email is only safe when the provider vouches for it
+	if emailAddr == "" || claims.EmailVerified == nil || !*claims.EmailVerified {
+		return user, errors.New("sso account has no verified email")
+	}
+
+	subject := claims.Subject
+	err = db.Where("LOWER(email) = ?", emailAddr).First(&user).Error
+	if err == nil {
+		if !user.IsActive {
+			return user, errors.New("account is deactivated")
+		}
+		if user.OIDCSubject != nil {
+			return user, errors.New("account is linked to a different sso identity")
+		}
+		db.Model(&user).Update("oidc_subject", subject)
+		return user, nil
+	}
+
+	if os.Getenv("OIDC_JIT_PROVISIONING") != "true" {
+		return user, errors.New("no TeamPulse account for " + emailAddr)
+	}
+
+	role := models.Role(os.Getenv("OIDC_DEFAULT_ROLE"))
+	if role == "" {
+		role = models.RoleEmployee
+	}
+	if !validRole(role) {
+		log.Printf("ERROR: OIDC_DEFAULT_ROLE %q is not a valid role", role)
+		return user, errors.New("sso provisioning is misconfigured")
+	}
+	name := claims.Name
+	if name == "" {
+		name = emailAddr
+	}
+
+	// SSO users never see this password; they can set one via forgot-password
+	hash, _ := bcrypt.GenerateFromPassword([]byte(newRefreshSecret()), bcrypt.DefaultCost)
+	user = models.User{
+		Email:       emailAddr,
+		Password:    string(hash),
+		Name:        name,
+		Role:        role,
+		IsActive:    true,
+		OIDCSubject: &subject,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return user, errors.New("failed to provision account")
+	}
+	log.Printf("INFO: provisioned %s from sso", emailAddr)
+	return user, nil
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeIdP is an OpenID provider whose token endpoint signs in subject with nonce
+func fakeIdP(t *testing.T, subject, nonce string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 srv.URL,
+				"authorization_endpoint": srv.URL + "/authorize",
+				"token_endpoint":         srv.URL + "/token",
+				"jwks_uri":               srv.URL + "/jwks",
+			})
+		case "/token":
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+				"iss": srv.URL, "aud": "teampulse", "sub": subject, "nonce": nonce,
+				"email": "ana@example.com", "email_verified": true,
+				"exp": time.Now().Add(time.Minute).Unix(),
+			})
+			raw, _ := token.SignedString(key)
+			json.NewEncoder(w).Encode(map[string]string{"id_token": raw})
+		case "/jwks":
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv("OIDC_ISSUER", srv.URL)
+	t.Setenv("OIDC_CLIENT_ID", "teampulse")
+	t.Setenv("OIDC_REDIRECT_URL", "http://app.test/api/auth/oidc/callback")
+	t.Setenv("OIDC_ORG", "")
+	oidcProvider = nil
+	t.Cleanup(func() { oidcProvider = nil })
+}
+
+// stateCookieCleared reports whether the response deletes the state cookie
+func stateCookieCleared(rec *httptest.ResponseRecorder) bool {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == oidcStateCookie && c.MaxAge < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		cookie string
+	}{
+		{"no cookie", "?state=abc&code=x", ""},
+		{"cookie from another login", "?state=abc&code=x", "xyz"},
+		{"no state", "?code=x", "abc"},
+		{"empty cookie and state", "?state=&code=x", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// database.DB is nil, so any query would panic: the login must be
+			// refused before the state is looked up
+			c, rec := newContext(http.MethodGet, "/api/auth/oidc/callback"+tt.query, "", nil)
+			if tt.cookie != "" {
+				c.Request().AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+			}
+
+			if err := OIDCCallback(c); err != nil {
+				t.Fatal(err)
+			}
+			if loc := rec.Header().Get("Location"); rec.Code != http.StatusFound || !strings.HasPrefix(loc, "/?sso_error=") {
+				t.Errorf("got %d to %q, want a redirect with sso_error", rec.Code, loc)
+			}
+			if !stateCookieCleared(rec) {
+				t.Error("state cookie was not cleared")
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackReturnsTicketInFragment(t *testing.T) {
+	fakeIdP(t, "idp|ana", "nonce-1")
+	mock := mockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "o_id_c_login_requests" WHERE state = \$1 AND completed_at IS NULL`).
+		WithArgs("abc", sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "state", "nonce", "code_verifier", "expires_at"}).
+			AddRow(4, "abc", "nonce-1", "verifier", time.Now().Add(time.Minute)))
+	mock.ExpectExec(`UPDATE "o_id_c_login_requests" SET "completed_at"=\$1 WHERE id = \$2 AND completed_at IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE oidc_subject = \$1`).
+		WithArgs("idp|ana", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "email", "is_active"}).AddRow(7, 1, "ana@example.com", true))
+	mock.ExpectExec(`UPDATE "o_id_c_login_requests" SET .*"ticket_hash"=.*"user_id"=`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	c, rec := newContext(http.MethodGet, "/api/auth/oidc/callback?state=abc&code=x", "", nil)
+	c.Request().AddCookie(&http.Cookie{Name: oidcStateCookie, Value: "abc"})
+	if err := OIDCCallback(c); err != nil {
+		t.Fatal(err)
+	}
+
+	loc := rec.Header().Get("Location")
+	if rec.Code != http.StatusFound || !strings.HasPrefix(loc, "/#sso_ticket=") || len(loc) <= len("/#sso_ticket=") {
+		t.Errorf("got %d to %q, want the ticket in the fragment", rec.Code, loc)
+	}
+	if !stateCookieCleared(rec) {
+		t.Error("state cookie was not cleared")
+	}
+}
+
+func TestOIDCLoginSetsStateCookie(t *testing.T) {
+	fakeIdP(t, "idp|ana", "")
+	mock := mockDB(t)
+	mock.ExpectQuery(`INSERT INTO "o_id_c_login_requests"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	c, rec := newContext(http.MethodGet, "/api/auth/oidc/login", "", nil)
+	if err := OIDCLogin(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusFound {
+		t.Fatalf("got %d, want a redirect to the provider", rec.Code)
+	}
+
+	var cookie *http.Cookie
+	for _, ck := range rec.Result().Cookies() {
+		if ck.Name == oidcStateCookie {
+			cookie = ck
+		}
+	}
+	if cookie == nil || cookie.Value == "" {
+		t.Fatal("no state cookie set")
+	}
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode || cookie.MaxAge <= 0 {
+		t.Errorf("state cookie = %+v, want HttpOnly, SameSite=Lax and a lifetime", cookie)
+	}
+	if !strings.Contains(rec.Header().Get("Location"), "state="+cookie.Value) {
+		t.Errorf("provider redirect %q doesn't carry the cookie's state", rec.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+	"teampulse/internal/policy"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ─── Reporting Hierarchy ──────────────────────────────────────
+// Users point at their manager via ManagerID. Holders of scope:all see
+// everyone; everyone else sees their whole reporting line (reports of reports
+// too) plus themselves. Permissions decide what they may do within that view.
+
+// reportIDs returns everyone below managerID in the reporting line
+func reportIDs(managerID uint) []uint {
+	seen := map[uint]bool{managerID: true}
+	var ids []uint
+	frontier := []uint{managerID}
+
+	for len(frontier) > 0 {
+		var next []uint
+		database.DB.Model(&models.User{}).Where("manager_id IN ?", frontier).Pluck("id", &next)
+
+		frontier = nil
+		for _, id := range next {
+			if !seen[id] { // guard against cycles in bad data
+				seen[id] = true
+				ids = append(ids, id)
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return ids
+}
+
+// visibleUserIDs lists whose records the caller may read; nil means everyone.
+// includeSelf controls whether the caller's own records are part of the view.
+func visibleUserIDs(c echo.Context, includeSelf bool) []uint {
+	if mw.Can(c, policy.ScopeAll) {
+		return nil
+	}
+
+	userID := mw.GetUserID(c)
+	ids := reportIDs(userID)
+	if includeSelf {
+		ids = append(ids, userID)
+	}
+	if ids == nil {
+		ids = []uint{} // no reports: match nothing, not everything
+	}
+	return ids
+}
+
+// scopeToVisible is a GORM scope restricting column (a user id) to the caller's view
+func scopeToVisible(c echo.Context, column string, includeSelf bool) func(*gorm.DB) *gorm.DB {
+	ids := visibleUserIDs(c, includeSelf)
+	return func(db *gorm.DB) *gorm.DB {
+		if ids == nil {
+			return db
+		}
+		if len(ids) == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where(column+" IN ?", ids)
+	}
+}
+
+// canViewUser reports whether the caller may see targetID's records
+func canViewUser(c echo.Context, targetID uint) bool {
+	ids := visibleUserIDs(c, true)
+	if ids == nil {
+		// Everyone in the org, which still rules out other tenants' ids
+		var count int64
+		orgDB(c).Model(&models.User{}).Where("id = ?", targetID).Count(&count)
+		return count > 0
+	}
+	for _, id := range ids {
+		if id == targetID {
+			return true
+		}
+	}
+	return false
+}
+
+// canActOn reports whether the caller may modify a record owned by ownerID:
+// their own records always, anyone else's in view with perm
+func canActOn(c echo.Context, perm policy.Permission, ownerID uint) bool {
+	if ownerID == mw.GetUserID(c) {
+		return true
+	}
+	return mw.Can(c, perm) && canViewUser(c, ownerID)
+}
+
+// validManager checks that managerID can sit above userID (0 for a new user)
+// without creating a loop in the reporting line
+func validManager(c echo.Context, userID uint, managerID *uint) bool {
+	if managerID == nil {
+		return true
+	}
+	if *managerID == userID {
+		return false
+	}
+
+	var manager models.User
+	if err := orgDB(c).Where("is_active = true").First(&manager, *managerID).Error; err != nil {
+		return false
+	}
+	if userID == 0 {
+		return true
+	}
+	for _, id := range reportIDs(userID) {
+		if id == *managerID {
+			return false
+		}
+	}
+	return true
+}
@@ -1,65 +1,122 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"teampulse/internal/database"
 	mw "teampulse/internal/middleware"
 	"teampulse/internal/models"
+	"teampulse/internal/policy"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
-func todayStr() string {
-	return time.Now().Format("2006-01-02")
-}
-
 // ─── Clock In/Out ─────────────────────────────────────────────
 
-func ClockIn(c echo.Context) error {
-	userID := mw.GetUserID(c)
+var (
+	errAlreadyClockedIn = errors.New("already clocked in")
+	errNotClockedIn     = errors.New("not clocked in")
+)
 
-	// Check if already clocked in
+// clockIn opens a time entry for userID from sourceIP, subject to their
+// clock-in network policies. kioskID records the terminal used, if any.
+func clockIn(db *gorm.DB, userID uint, kioskID *uint, sourceIP string) (models.TimeEntry, error) {
 	var existing models.TimeEntry
-	result := database.DB.Where("user_id = ? AND clock_out IS NULL", userID).First(&existing)
-	if result.Error == nil {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "already clocked in"})
+	if db.Where("user_id = ? AND clock_out IS NULL", userID).First(&existing).Error == nil {
+		return existing, errAlreadyClockedIn
 	}
-
 	entry := models.TimeEntry{
-		UserID:  userID,
-		ClockIn: time.Now(),
-		Date:    todayStr(),
+		UserID:   userID,
+		ClockIn:  time.Now(),
+		KioskID:  kioskID,
+		SourceIP: sourceIP,
+	}
+	roundPunches(db, &entry)
+	entry.Date = entryDate(db, entry)
+	if periodLocked(db, userID, entry.Date) {
+		return existing, errPeriodLocked
+	}
+	offNetwork, err := checkNetwork(db, userID, sourceIP)
+	if err != nil {
+		return existing, err
 	}
-	database.DB.Create(&entry)
+	entry.OffNetwork = offNetwork
 
-	return c.JSON(http.StatusOK, entry)
+	db.Create(&entry)
+	return entry, nil
 }
 
-func ClockOut(c echo.Context) error {
-	userID := mw.GetUserID(c)
-
+// clockOut closes userID's open time entry now
+func clockOut(db *gorm.DB, userID uint) (models.TimeEntry, error) {
 	var entry models.TimeEntry
-	result := database.DB.Where("user_id = ? AND clock_out IS NULL", userID).First(&entry)
-	if result.Error != nil {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "not clocked in"})
+	if err := db.Where("user_id = ? AND clock_out IS NULL", userID).First(&entry).Error; err != nil {
+		return entry, errNotClockedIn
 	}
 
-	now := time.Now()
-	duration := int64(now.Sub(entry.ClockIn).Seconds())
-	entry.ClockOut = &now
-	entry.Duration = duration
-	database.DB.Save(&entry)
+	if !closeTimeEntry(db, &entry, time.Now()) {
+		return entry, errNotClockedIn
+	}
+	return entry, nil
+}
+
+// closeTimeEntry ends an open entry at the given time, along with any break
+// in progress and running task timer. It returns false, changing nothing, if
+// the entry was closed in the meantime (a clock-out racing the auto
+// clock-out job).
+func closeTimeEntry(db *gorm.DB, entry *models.TimeEntry, at time.Time) bool {
+	db.Where("time_entry_id = ?", entry.ID).Order("started_at asc").Find(&entry.Breaks)
+	_, unpaid := breakSecondsWithin(entry.Breaks, entry.ClockIn, at)
+	entry.ClockOut = &at
+	roundPunches(db, entry)
+	start, end := paidSpan(*entry)
+	entry.Duration = spanSeconds(start, end, unpaid)
+
+	result := db.Model(&models.TimeEntry{}).
+		Where("id = ? AND clock_out IS NULL", entry.ID).
+		Updates(map[string]interface{}{
+			"clock_out":         entry.ClockOut,
+			"rounded_clock_in":  entry.RoundedClockIn,
+			"rounded_clock_out": entry.RoundedClockOut,
+			"duration":          entry.Duration,
+			"auto_closed":       entry.AutoClosed,
+			"auto_close_reason": entry.AutoCloseReason,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
 
-	// Also stop any running task timers
-	database.DB.Model(&models.TaskTime{}).
+	endBreak(db, entry.ID, at)
+	db.Where("time_entry_id = ?", entry.ID).Order("started_at asc").Find(&entry.Breaks)
+	stopTaskTimers(db, entry.UserID, at)
+	return true
+}
+
+// stopTaskTimers stops any task timers the user still has running
+func stopTaskTimers(db *gorm.DB, userID uint, at time.Time) {
+	db.Model(&models.TaskTime{}).
 		Where("user_id = ? AND stopped_at IS NULL", userID).
 		Updates(map[string]interface{}{
-			"stopped_at":       now,
+			"stopped_at":       at,
 			"duration_seconds": 0, // will recalculate
 		})
+}
 
+func ClockIn(c echo.Context) error {
+	entry, err := clockIn(orgDB(c), mw.GetUserID(c), nil, c.RealIP())
+	if err != nil {
+		return c.JSON(clockInStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, entry)
+}
+
+func ClockOut(c echo.Context) error {
+	entry, err := clockOut(orgDB(c), mw.GetUserID(c))
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
 	return c.JSON(http.StatusOK, entry)
 }
 
@@ -67,30 +124,164 @@ func GetClockStatus(c echo.Context) error {
 	userID := mw.GetUserID(c)
 
 	var entry models.TimeEntry
-	result := database.DB.Where("user_id = ? AND clock_out IS NULL", userID).First(&entry)
+	result := orgDB(c).Where("user_id = ? AND clock_out IS NULL", userID).First(&entry)
 
 	status := map[string]interface{}{
 		"clocked_in": result.Error == nil,
+		"on_break":   false,
 	}
 	if result.Error == nil {
 		status["entry"] = entry
 		status["elapsed_seconds"] = int64(time.Since(entry.ClockIn).Seconds())
+		status["worked_seconds"] = workedSeconds(orgDB(c), entry)
+
+		var brk models.Break
+		if orgDB(c).Where("time_entry_id = ? AND ended_at IS NULL", entry.ID).First(&brk).Error == nil {
+			status["on_break"] = true
+			status["break"] = brk
+		}
 	}
 
 	return c.JSON(http.StatusOK, status)
 }
 
-func GetTimeEntries(c echo.Context) error {
+// ─── Breaks ───────────────────────────────────────────────────
+// A break pauses a clock session without ending it. Unpaid breaks are
+// deducted from the session's duration; paid ones only get recorded.
+
+// endBreak closes the open break of a time entry, if there is one
+func endBreak(db *gorm.DB, entryID uint, at time.Time) (models.Break, bool) {
+	var brk models.Break
+	if db.Where("time_entry_id = ? AND ended_at IS NULL", entryID).First(&brk).Error != nil {
+		return brk, false
+	}
+	if at.Before(brk.StartedAt) {
+		at = brk.StartedAt
+	}
+	brk.EndedAt = &at
+	brk.Duration = int64(at.Sub(brk.StartedAt).Seconds())
+	db.Save(&brk)
+	return brk, true
+}
+
+// breakSeconds totals paid and unpaid break time, counting a break still in
+// progress up to until
+func breakSeconds(breaks []models.Break, until time.Time) (paid, unpaid int64) {
+	for _, b := range breaks {
+		secs := b.Duration
+		if b.EndedAt == nil {
+			secs = int64(until.Sub(b.StartedAt).Seconds())
+		}
+		if b.Type == models.BreakPaid {
+			paid += secs
+		} else {
+			unpaid += secs
+		}
+	}
+	return paid, unpaid
+}
+
+// breakSecondsWithin totals paid and unpaid break time falling inside
+// [from, to], counting a break still in progress up to to
+func breakSecondsWithin(breaks []models.Break, from, to time.Time) (paid, unpaid int64) {
+	for _, b := range breaks {
+		start, end := b.StartedAt, to
+		if b.EndedAt != nil && b.EndedAt.Before(to) {
+			end = *b.EndedAt
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if !end.After(start) {
+			continue
+		}
+		secs := int64(end.Sub(start).Seconds())
+		if b.Type == models.BreakPaid {
+			paid += secs
+		} else {
+			unpaid += secs
+		}
+	}
+	return paid, unpaid
+}
+
+// workedSeconds is an entry's rounded time net of unpaid breaks; open
+// sessions count up to now
+func workedSeconds(db *gorm.DB, entry models.TimeEntry) int64 {
+	if entry.ClockOut != nil {
+		return entry.Duration
+	}
+	var breaks []models.Break
+	db.Where("time_entry_id = ?", entry.ID).Find(&breaks)
+	_, unpaid := breakSeconds(breaks, time.Now())
+	start, end := paidSpan(entry)
+	return spanSeconds(start, end, unpaid)
+}
+
+// StartBreak starts a break `{type: "paid"|"unpaid"}` in the caller's open session
+func StartBreak(c echo.Context) error {
 	userID := mw.GetUserID(c)
-	role := mw.GetUserRole(c)
+
+	var req models.BreakStartRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if req.Type == "" {
+		req.Type = models.BreakUnpaid
+	}
+	if req.Type != models.BreakPaid && req.Type != models.BreakUnpaid {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "type must be paid or unpaid"})
+	}
+
+	var entry models.TimeEntry
+	if err := orgDB(c).Where("user_id = ? AND clock_out IS NULL", userID).First(&entry).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "not clocked in"})
+	}
+	if orgDB(c).Where("time_entry_id = ? AND ended_at IS NULL", entry.ID).First(&models.Break{}).Error == nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "already on a break"})
+	}
+
+	brk := models.Break{
+		TimeEntryID: entry.ID,
+		UserID:      userID,
+		Type:        req.Type,
+		StartedAt:   time.Now(),
+	}
+	orgDB(c).Create(&brk)
+
+	return c.JSON(http.StatusOK, brk)
+}
+
+// EndBreak ends the break in progress in the caller's open session
+func EndBreak(c echo.Context) error {
+	var entry models.TimeEntry
+	if err := orgDB(c).Where("user_id = ? AND clock_out IS NULL", mw.GetUserID(c)).First(&entry).Error; err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "not clocked in"})
+	}
+
+	brk, ok := endBreak(orgDB(c), entry.ID, time.Now())
+	if !ok {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "not on a break"})
+	}
+	return c.JSON(http.StatusOK, brk)
+}
+
+func GetTimeEntries(c echo.Context) error {
 	date := c.QueryParam("date")
 
 	var entries []models.TimeEntry
-	q := database.DB.Preload("User").Order("clock_in desc")
+	q := orgDB(c).Preload("User").Order("clock_in desc")
 
-	// Employees see only their own, admins see all
-	if role != models.RoleAdmin {
-		q = q.Where("user_id = ?", userID)
+	// Employees see only their own, managers their team's, admins all
+	q = q.Scopes(scopeToVisible(c, "user_id", true))
+
+	// Sessions closed by the auto clock-out job, for review
+	if c.QueryParam("auto_closed") == "true" {
+		q = q.Where("auto_closed = true")
+	}
+	// Clock-ins from outside the allowed networks not yet reviewed
+	if c.QueryParam("off_network") == "true" {
+		q = q.Where("off_network = true AND network_reviewer_id IS NULL")
 	}
 
 	if date != "" {
@@ -117,7 +308,7 @@ func RecordActivityPing(c echo.Context) error {
 
 	// Get current time entry
 	var entry models.TimeEntry
-	result := database.DB.Where("user_id = ? AND clock_out IS NULL", userID).First(&entry)
+	result := orgDB(c).Where("user_id = ? AND clock_out IS NULL", userID).First(&entry)
 	if result.Error != nil {
 		return c.JSON(http.StatusConflict, map[string]string{"error": "not clocked in"})
 	}
@@ -133,26 +324,29 @@ func RecordActivityPing(c echo.Context) error {
 		Keystrokes:   req.Keystrokes,
 		ScrollEvents: req.ScrollEvents,
 	}
-	database.DB.Create(&ping)
+	orgDB(c).Create(&ping)
 
 	return c.JSON(http.StatusOK, map[string]string{"status": "recorded"})
 }
 
 // ─── Clock Sessions ──────────────────────────────────────────
 
-// GetClockSessions — Admin: returns time entries enriched with segment stats
+// GetClockSessions — Admin/Manager: returns time entries enriched with segment stats
 func GetClockSessions(c echo.Context) error {
 	date := c.QueryParam("date")
 	if date == "" {
-		date = todayStr()
+		date = todayIn(callerLocation(c))
 	}
 
 	var entries []models.TimeEntry
-	database.DB.Preload("User").Where("date = ?", date).Order("clock_in desc").Find(&entries)
+	orgDB(c).Preload("User").Where("date = ?", date).
+		Scopes(scopeToVisible(c, "user_id", false)).
+		Order("clock_in desc").Find(&entries)
 
+	buckets := entryBuckets(orgDB(c), overtimeRules(mw.GetOrgID(c)), entryUserIDs(entries), date, date)
 	sessions := make([]models.ClockSessionResponse, 0, len(entries))
 	for _, entry := range entries {
-		sess := buildSessionResponse(entry)
+		sess := buildSessionResponse(entry, buckets.total(entry.ID))
 		sessions = append(sessions, sess)
 	}
 
@@ -164,27 +358,36 @@ func GetMyClockSessions(c echo.Context) error {
 	userID := mw.GetUserID(c)
 	date := c.QueryParam("date")
 	if date == "" {
-		date = todayStr()
+		date = todayIn(callerLocation(c))
 	}
 
 	var entries []models.TimeEntry
-	database.DB.Preload("User").Where("user_id = ? AND date = ?", userID, date).Order("clock_in desc").Find(&entries)
+	orgDB(c).Preload("User").Where("user_id = ? AND date = ?", userID, date).Order("clock_in desc").Find(&entries)
 
+	buckets := entryBuckets(orgDB(c), overtimeRules(mw.GetOrgID(c)), []uint{userID}, date, date)
 	sessions := make([]models.ClockSessionResponse, 0, len(entries))
 	for _, entry := range entries {
-		sess := buildSessionResponse(entry)
+		sess := buildSessionResponse(entry, buckets.total(entry.ID))
 		sessions = append(sessions, sess)
 	}
 
 	return c.JSON(http.StatusOK, sessions)
 }
 
-func buildSessionResponse(entry models.TimeEntry) models.ClockSessionResponse {
+func buildSessionResponse(entry models.TimeEntry, buckets models.HourBuckets) models.ClockSessionResponse {
 	clockOut := time.Now()
 	if entry.ClockOut != nil {
 		clockOut = *entry.ClockOut
 	}
 
+	database.DB.Where("time_entry_id = ?", entry.ID).Order("started_at asc").Find(&entry.Breaks)
+	paid, unpaid := breakSeconds(entry.Breaks, clockOut)
+	worked := entry.Duration
+	if entry.ClockOut == nil {
+		start, end := paidSpan(entry)
+		worked = spanSeconds(start, end, unpaid)
+	}
+
 	// Aggregate active/idle seconds from segments within this session window
 	type Sums struct {
 		ActiveSeconds int
@@ -220,7 +423,11 @@ func buildSessionResponse(entry models.TimeEntry) models.ClockSessionResponse {
 		Find(&segments)
 
 	return models.ClockSessionResponse{
+		HourBuckets:        buckets,
 		TimeEntry:          entry,
+		WorkedSeconds:      worked,
+		PaidBreakSeconds:   paid,
+		UnpaidBreakSeconds: unpaid,
 		TotalActiveSeconds: sums.ActiveSeconds,
 		TotalIdleSeconds:   sums.IdleSeconds,
 		TotalMouseClicks:   sums.MouseClicks,
@@ -230,22 +437,23 @@ func buildSessionResponse(entry models.TimeEntry) models.ClockSessionResponse {
 	}
 }
 
-// GetActivityStats - admin endpoint to view activity percentages
+// GetActivityStats - activity:view endpoint to view activity percentages
 func GetActivityStats(c echo.Context) error {
 	date := c.QueryParam("date")
 	if date == "" {
-		date = todayStr()
+		date = todayIn(callerLocation(c))
 	}
 
 	// Get all time entries for the date
 	var entries []models.TimeEntry
-	database.DB.Preload("User").Where("date = ?", date).Find(&entries)
+	orgDB(c).Preload("User").Where("date = ?", date).
+		Scopes(scopeToVisible(c, "user_id", false)).Find(&entries)
 
 	var stats []models.ActivityStat
 	for _, entry := range entries {
 		var totalPings, activePings int64
-		database.DB.Model(&models.ActivityPing{}).Where("time_entry_id = ?", entry.ID).Count(&totalPings)
-		database.DB.Model(&models.ActivityPing{}).Where("time_entry_id = ? AND is_active = true", entry.ID).Count(&activePings)
+		orgDB(c).Model(&models.ActivityPing{}).Where("time_entry_id = ?", entry.ID).Count(&totalPings)
+		orgDB(c).Model(&models.ActivityPing{}).Where("time_entry_id = ? AND is_active = true", entry.ID).Count(&activePings)
 
 		pct := float64(0)
 		if totalPings > 0 {
@@ -260,7 +468,7 @@ func GetActivityStats(c echo.Context) error {
 			ScrollEvents int
 		}
 		var sums EventSums
-		database.DB.Model(&models.ActivityPing{}).
+		orgDB(c).Model(&models.ActivityPing{}).
 			Select("COALESCE(SUM(mouse_moves),0) as mouse_moves, COALESCE(SUM(mouse_clicks),0) as mouse_clicks, COALESCE(SUM(keystrokes),0) as keystrokes, COALESCE(SUM(scroll_events),0) as scroll_events").
 			Where("time_entry_id = ?", entry.ID).
 			Scan(&sums)
@@ -287,31 +495,38 @@ func StartTaskTimer(c echo.Context) error {
 	userID := mw.GetUserID(c)
 	taskID := c.Param("id")
 
+	var task models.Task
+	if err := orgDB(c).First(&task, taskID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "task not found"})
+	}
+	if !canEditTask(c, policy.TaskWrite, task) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not your task"})
+	}
+
 	// Stop any other running timer for this user
 	now := time.Now()
-	database.DB.Model(&models.TaskTime{}).
+	orgDB(c).Model(&models.TaskTime{}).
 		Where("user_id = ? AND stopped_at IS NULL", userID).
 		Updates(map[string]interface{}{
 			"stopped_at": now,
 		})
 	// Recalculate durations for stopped timers
 	var stopped []models.TaskTime
-	database.DB.Where("user_id = ? AND stopped_at IS NOT NULL AND duration_seconds = 0", userID).Find(&stopped)
+	orgDB(c).Where("user_id = ? AND stopped_at IS NOT NULL AND duration_seconds = 0", userID).Find(&stopped)
 	for _, s := range stopped {
 		if s.StoppedAt != nil {
 			dur := int64(s.StoppedAt.Sub(s.StartedAt).Seconds())
-			database.DB.Model(&s).Update("duration_seconds", dur)
+			orgDB(c).Model(&s).Update("duration_seconds", dur)
 		}
 	}
 
 	tt := models.TaskTime{
 		UserID:    userID,
+		TaskID:    task.ID,
 		StartedAt: now,
 	}
-	// Parse task ID
-	database.DB.Raw("SELECT ?::integer", taskID).Scan(&tt.TaskID)
 
-	database.DB.Create(&tt)
+	orgDB(c).Create(&tt)
 	return c.JSON(http.StatusOK, tt)
 }
 
@@ -320,14 +535,14 @@ func StopTaskTimer(c echo.Context) error {
 
 	now := time.Now()
 	var tt models.TaskTime
-	result := database.DB.Where("user_id = ? AND stopped_at IS NULL", userID).First(&tt)
+	result := orgDB(c).Where("user_id = ? AND stopped_at IS NULL", userID).First(&tt)
 	if result.Error != nil {
 		return c.JSON(http.StatusConflict, map[string]string{"error": "no active timer"})
 	}
 
 	tt.StoppedAt = &now
 	tt.Duration = int64(now.Sub(tt.StartedAt).Seconds())
-	database.DB.Save(&tt)
+	orgDB(c).Save(&tt)
 
 	return c.JSON(http.StatusOK, tt)
 }
@@ -336,7 +551,7 @@ func GetActiveTimer(c echo.Context) error {
 	userID := mw.GetUserID(c)
 
 	var tt models.TaskTime
-	result := database.DB.Preload("User").Where("user_id = ? AND stopped_at IS NULL", userID).First(&tt)
+	result := orgDB(c).Preload("User").Where("user_id = ? AND stopped_at IS NULL", userID).First(&tt)
 
 	if result.Error != nil {
 		return c.JSON(http.StatusOK, map[string]interface{}{"active": false})
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"teampulse/internal/database"
+	"teampulse/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCloseTimeEntryAlreadyClosed(t *testing.T) {
+	mock := mockDB(t)
+	// Lookups for breaks, rounding and zone aren't expected and fail, which
+	// leaves the defaults; only the close itself matters here
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "time_entries" SET`) + `.* WHERE id = \$\d+ AND clock_out IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	log := &sqlLog{Interface: logger.Discard}
+	db := database.DB.Session(&gorm.Session{Logger: log})
+
+	clockIn := time.Now().Add(-13 * time.Hour)
+	entry := models.TimeEntry{ID: 7, UserID: 3, ClockIn: clockIn, AutoClosed: true, AutoCloseReason: autoCloseMaxLength}
+	if closeTimeEntry(db, &entry, clockIn.Add(12*time.Hour)) {
+		t.Fatal("closeTimeEntry() = true for an entry closed in the meantime")
+	}
+	if log.ran(`UPDATE "breaks"`) || log.ran(`UPDATE "task_times"`) {
+		t.Errorf("breaks or task timers touched after a lost race:\n%s", strings.Join(log.statements, "\n"))
+	}
+}
+
+func TestCloseTimeEntry(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "time_entries" SET`) + `.* WHERE id = \$\d+ AND clock_out IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "task_times" SET`) + `.* WHERE user_id = \$\d+ AND stopped_at IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	clockIn := time.Now().Add(-2 * time.Hour)
+	entry := models.TimeEntry{ID: 7, UserID: 3, ClockIn: clockIn}
+	if !closeTimeEntry(database.DB, &entry, clockIn.Add(time.Hour)) {
+		t.Fatal("closeTimeEntry() = false for an open entry")
+	}
+	if entry.Duration != 3600 {
+		t.Errorf("Duration = %d, want 3600", entry.Duration)
+	}
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+	"teampulse/internal/payperiod"
+	"teampulse/internal/policy"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ─── Timesheets ──────────────────────────────────────────────
+// Each user has one timesheet per pay period. It goes draft → submitted →
+// approved, or back to rejected for resubmission; an approver can reopen an
+// approved sheet. While a sheet is approved its time entries can't change.
+
+var errPeriodLocked = errors.New("this pay period's timesheet is approved and locked")
+
+// paySchedule reads the org's pay period settings, falling back to
+// Monday-to-Sunday weeks
+func paySchedule(orgID uint) payperiod.Schedule {
+	settings := database.GetOrgSettings(orgID)
+	schedule := payperiod.Schedule{Kind: payperiod.Kind(settings.PayPeriod)}
+	if !payperiod.ValidKind(settings.PayPeriod) {
+		schedule.Kind = payperiod.Weekly
+	}
+	anchor, err := time.Parse("2006-01-02", settings.PayPeriodAnchor)
+	if err != nil {
+		anchor, _ = time.Parse("2006-01-02", payperiod.DefaultAnchor)
+	}
+	schedule.Anchor = anchor
+	return schedule
+}
+
+// payPeriod returns the first and last day (inclusive) of the org's pay
+// period containing date
+func payPeriod(orgID uint, date time.Time) (string, string) {
+	start, end := paySchedule(orgID).Containing(date)
+	return start.Format("2006-01-02"), end.Format("2006-01-02")
+}
+
+// periodLocked reports whether userID's timesheet covering date is approved
+func periodLocked(db *gorm.DB, userID uint, date string) bool {
+	var count int64
+	db.Model(&models.Timesheet{}).
+		Where("user_id = ? AND period_start <= ? AND period_end >= ? AND status = ?", userID, date, date, models.TimesheetApproved).
+		Count(&count)
+	return count > 0
+}
+
+// timesheetFor loads or starts userID's timesheet for the period containing date
+func timesheetFor(db *gorm.DB, orgID, userID uint, date time.Time) models.Timesheet {
+	start, end := payPeriod(orgID, date)
+	var sheet models.Timesheet
+	db.Where(models.Timesheet{UserID: userID, PeriodStart: start}).
+		Attrs(models.Timesheet{PeriodEnd: end, Status: models.TimesheetDraft}).
+		FirstOrCreate(&sheet)
+	return sheet
+}
+
+// findTimesheet is timesheetFor without the write: a period nobody has
+// opened comes back as an unsaved draft
+func findTimesheet(db *gorm.DB, orgID, userID uint, date time.Time) models.Timesheet {
+	start, end := payPeriod(orgID, date)
+	var sheet models.Timesheet
+	if db.Where("user_id = ? AND period_start = ?", userID, start).First(&sheet).Error != nil {
+		sheet = models.Timesheet{OrgID: orgID, UserID: userID, PeriodStart: start, PeriodEnd: end, Status: models.TimesheetDraft}
+	}
+	return sheet
+}
+
+// buildTimesheet rolls up the period's time entries and task time. Hours are
+// split at local midnight, so a shift running into or out of the period counts
+// only the part worked inside it; entries count where they started.
+func buildTimesheet(db *gorm.DB, sheet models.Timesheet) models.TimesheetResponse {
+	resp := models.TimesheetResponse{Timesheet: sheet, Days: []models.TimesheetDay{}, Tasks: []models.TimesheetTask{}}
+
+	var entries []models.TimeEntry
+	db.Where("user_id = ? AND date BETWEEN ? AND ?", sheet.UserID, dayBefore(sheet.PeriodStart), sheet.PeriodEnd).
+		Order("clock_in asc").Find(&entries)
+	loc := userLocation(db, sheet.UserID)
+
+	byDay := map[string]*models.TimesheetDay{}
+	start, _ := time.Parse("2006-01-02", sheet.PeriodStart)
+	for d := start; d.Format("2006-01-02") <= sheet.PeriodEnd; d = d.AddDate(0, 0, 1) {
+		resp.Days = append(resp.Days, models.TimesheetDay{Date: d.Format("2006-01-02")})
+	}
+	for i := range resp.Days {
+		byDay[resp.Days[i].Date] = &resp.Days[i]
+	}
+
+	buckets := entryBuckets(db, overtimeRules(sheet.OrgID), []uint{sheet.UserID}, sheet.PeriodStart, sheet.PeriodEnd)
+
+	var total int64
+	entryCount := 0
+	resp.Entries = []models.TimeEntry{}
+	for _, e := range entries {
+		inPeriod := false
+		for _, share := range splitByDay(db, e, loc) {
+			day, ok := byDay[share.Date]
+			if !ok {
+				continue
+			}
+			inPeriod = true
+			day.Seconds += share.Seconds
+			total += share.Seconds
+			addBuckets(&resp.HourBuckets, buckets[e.ID][share.Date])
+		}
+		if day, ok := byDay[e.Date]; ok {
+			day.Entries++
+			entryCount++
+			inPeriod = true
+		}
+		if !inPeriod {
+			continue
+		}
+		resp.Entries = append(resp.Entries, e)
+		if e.ClockOut == nil {
+			resp.OpenEntry = true
+		}
+	}
+
+	for date, name := range userHolidays(db, []uint{sheet.UserID}, sheet.PeriodStart, sheet.PeriodEnd)[sheet.UserID] {
+		byDay[date].Holiday = name
+	}
+
+	var leaveTotal int64
+	for date, secs := range leaveByDay(db.Where("user_id = ?", sheet.UserID), sheet.PeriodStart, sheet.PeriodEnd)[sheet.UserID] {
+		byDay[date].LeaveSeconds = secs
+		leaveTotal += secs
+	}
+
+	// Task time by when the timer ran, in the user's zone; running timers
+	// count up to now
+	periodStart, _ := time.ParseInLocation("2006-01-02", sheet.PeriodStart, loc)
+	periodEnd, _ := time.ParseInLocation("2006-01-02", sheet.PeriodEnd, loc)
+	db.Model(&models.TaskTime{}).
+		Select(`task_times.task_id, tasks.title,
+		        CAST(SUM(EXTRACT(EPOCH FROM (COALESCE(task_times.stopped_at, NOW()) - task_times.started_at))) AS BIGINT) AS seconds`).
+		Joins("JOIN tasks ON tasks.id = task_times.task_id").
+		Where("task_times.user_id = ? AND task_times.started_at >= ? AND task_times.started_at < ?",
+			sheet.UserID, periodStart, periodEnd.AddDate(0, 0, 1)).
+		Group("task_times.task_id, tasks.title").
+		Order("seconds desc").
+		Scan(&resp.Tasks)
+
+	// Approved sheets keep the totals they were approved with
+	if sheet.Status != models.TimesheetApproved {
+		resp.Timesheet.TotalSeconds = total
+		resp.Timesheet.EntryCount = entryCount
+		resp.Timesheet.LeaveSeconds = leaveTotal
+	}
+	return resp
+}
+
+// GetMyTimesheet — Employee: your timesheet for the period containing ?date= (default today)
+func GetMyTimesheet(c echo.Context) error {
+	orgID, userID := mw.GetOrgID(c), mw.GetUserID(c)
+	today := time.Now().In(callerLocation(c))
+	if d := c.QueryParam("date"); d != "" {
+		date, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "date must be YYYY-MM-DD"})
+		}
+		// Only the current period's sheet is started on read
+		current, _ := payPeriod(orgID, today)
+		if start, _ := payPeriod(orgID, date); start != current {
+			return c.JSON(http.StatusOK, buildTimesheet(orgDB(c), findTimesheet(orgDB(c), orgID, userID, date)))
+		}
+	}
+
+	sheet := timesheetFor(orgDB(c), orgID, userID, today)
+	return c.JSON(http.StatusOK, buildTimesheet(orgDB(c), sheet))
+}
+
+// GetTimesheet returns one timesheet to its owner or to holders of
+// time:approve over them
+func GetTimesheet(c echo.Context) error {
+	var sheet models.Timesheet
+	if err := orgDB(c).Preload("User").First(&sheet, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "timesheet not found"})
+	}
+	if !canActOn(c, policy.TimeApprove, sheet.UserID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "not in your team"})
+	}
+	return c.JSON(http.StatusOK, buildTimesheet(orgDB(c), sheet))
+}
+
+// ListTimesheets — time:approve: timesheets of the caller's team, by
+// ?status= (default submitted) and optionally ?period_start=
+func ListTimesheets(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status == "" {
+		status = string(models.TimesheetSubmitted)
+	}
+
+	q := orgDB(c).Preload("User").Where("status = ?", status).
+		Scopes(scopeToVisible(c, "user_id", false))
+	if start := c.QueryParam("period_start"); start != "" {
+		q = q.Where("period_start = ?", start)
+	}
+
+	var sheets []models.Timesheet
+	q.Order("period_start desc, user_id asc").Limit(500).Find(&sheets)
+	return c.JSON(http.StatusOK, sheets)
+}
+
+// SubmitTimesheet — Employee: send your timesheet for approval
+func SubmitTimesheet(c echo.Context) error {
+	var sheet models.Timesheet
+	if err := orgDB(c).Where("id = ? AND user_id = ?", c.Param("id"), mw.GetUserID(c)).First(&sheet).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "timesheet not found"})
+	}
+	if sheet.Status != models.TimesheetDraft && sheet.Status != models.TimesheetRejected {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "timesheet is already " + string(sheet.Status)})
+	}
+
+	resp := buildTimesheet(orgDB(c), sheet)
+	if resp.OpenEntry {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "clock out before submitting this timesheet"})
+	}
+
+	now := time.Now()
+	res := orgDB(c).Model(&models.Timesheet{}).
+		Where("id = ? AND status IN ?", sheet.ID, []models.TimesheetStatus{models.TimesheetDraft, models.TimesheetRejected}).
+		Updates(map[string]interface{}{
+			"status":        models.TimesheetSubmitted,
+			"submitted_at":  now,
+			"total_seconds": resp.Timesheet.TotalSeconds,
+			"entry_count":   resp.Timesheet.EntryCount,
+			"leave_seconds": resp.Timesheet.LeaveSeconds,
+		})
+	if res.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to submit timesheet"})
+	}
+	if res.RowsAffected == 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "timesheet was changed by someone else"})
+	}
+	orgDB(c).First(&sheet, sheet.ID)
+	return c.JSON(http.StatusOK, sheet)
+}
+
+// reviewTimesheet moves a team member's timesheet from one of from to status
+func reviewTimesheet(c echo.Context, action string, status models.TimesheetStatus, from ...models.TimesheetStatus) error {
+	var sheet models.Timesheet
+	if err := orgDB(c).First(&sheet, c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "timesheet not found"})
+	}
+	reviewerID := mw.GetUserID(c)
+	if sheet.UserID == reviewerID || !canViewUser(c, sheet.UserID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "you can't review this timesheet"})
+	}
+
+	allowed := false
+	for _, s := range from {
+		allowed = allowed || sheet.Status == s
+	}
+	if !allowed {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "timesheet is " + string(sheet.Status)})
+	}
+
+	var req models.ReviewRequest
+	c.Bind(&req)
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      status,
+		"reviewer_id": reviewerID,
+		"reviewed_at": now,
+		"review_note": req.Note,
+	}
+	if status == models.TimesheetApproved {
+		resp := buildTimesheet(orgDB(c), sheet)
+		if resp.OpenEntry {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "a session in this period is still open"})
+		}
+		updates["total_seconds"] = resp.Timesheet.TotalSeconds
+		updates["entry_count"] = resp.Timesheet.EntryCount
+		updates["leave_seconds"] = resp.Timesheet.LeaveSeconds
+	}
+	// Another reviewer may have moved the sheet since it was loaded
+	res := orgDB(c).Model(&models.Timesheet{}).Where("id = ? AND status IN ?", sheet.ID, from).Updates(updates)
+	if res.Error != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update timesheet"})
+	}
+	if res.RowsAffected == 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "timesheet was changed by someone else"})
+	}
+	orgDB(c).First(&sheet, sheet.ID)
+
+	logAudit(reviewerID, action, sheet.UserID, sheet.PeriodStart)
+	return c.JSON(http.StatusOK, sheet)
+}
+
+// ApproveTimesheet — time:approve: approve a submitted timesheet `{note}`,
+// locking its time entries
+func ApproveTimesheet(c echo.Context) error {
+	return reviewTimesheet(c, "approved_timesheet", models.TimesheetApproved, models.TimesheetSubmitted)
+}
+
+// RejectTimesheet — time:approve: send a submitted timesheet back `{note}`
+func RejectTimesheet(c echo.Context) error {
+	return reviewTimesheet(c, "rejected_timesheet", models.TimesheetRejected, models.TimesheetSubmitted)
+}
+
+// ReopenTimesheet — time:approve: unlock an approved timesheet for changes `{note}`
+func ReopenTimesheet(c echo.Context) error {
+	return reviewTimesheet(c, "reopened_timesheet", models.TimesheetDraft, models.TimesheetApproved)
+}
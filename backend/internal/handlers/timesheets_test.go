@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"teampulse/internal/database"
+	"teampulse/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestReviewTimesheetChangedMeanwhile(t *testing.T) {
+	admin := models.User{ID: 1, OrgID: 1, Role: models.RoleAdmin, IsActive: true}
+
+	mock := mockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "timesheets" WHERE "timesheets"."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "status"}).AddRow(5, 9, models.TimesheetSubmitted))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE id = \$1`).
+		WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec(`UPDATE "timesheets" SET .* WHERE id = \$\d+ AND status IN \(\$\d+\)`).
+		WithArgs("", sqlmock.AnyArg(), 1, models.TimesheetRejected, sqlmock.AnyArg(), 5, models.TimesheetSubmitted).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	c, rec := newContext(http.MethodPost, "/api/timesheets/5/reject", `{}`, &admin)
+	c.SetParamNames("id")
+	c.SetParamValues("5")
+	if err := RejectTimesheet(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("got %d %s, want %d", rec.Code, rec.Body, http.StatusConflict)
+	}
+}
+
+func TestGetMyTimesheetOnlyStartsCurrentPeriod(t *testing.T) {
+	user := models.User{ID: 9, OrgID: 1, Role: models.RoleEmployee, IsActive: true}
+	today := time.Now().UTC().Format("2006-01-02")
+
+	tests := []struct {
+		date    string
+		started bool
+	}{
+		{"", true},
+		{today, true},
+		{"2019-06-12", false},
+		{"2099-06-12", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			// Other lookups aren't expected and fail, so the sheet is built
+			// empty; the log shows whether one was inserted
+			mock := mockDB(t)
+			mock.ExpectQuery(`SELECT \* FROM "timesheets" WHERE .*user_id.*period_start`).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			log := &sqlLog{Interface: logger.Discard}
+			database.DB = database.DB.Session(&gorm.Session{Logger: log})
+
+			target := "/api/timesheets/me"
+			if tt.date != "" {
+				target += "?date=" + tt.date
+			}
+			c, rec := newContext(http.MethodGet, target, "", &user)
+			if err := GetMyTimesheet(c); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got %d %s", rec.Code, rec.Body)
+			}
+			if started := log.ran(`INSERT INTO "timesheets"`); started != tt.started {
+				t.Errorf("timesheet inserted = %v, want %v", started, tt.started)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"teampulse/internal/database"
+	mw "teampulse/internal/middleware"
+	"teampulse/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ─── Time Zones ──────────────────────────────────────────────
+// Every Date column is the owner's local calendar day. A user's zone is their
+// own IANA zone, else the org default, else UTC.
+
+// validTimeZone reports whether name is an IANA zone; "" means unset
+func validTimeZone(name string) bool {
+	if name == "" {
+		return true
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// loadZone resolves an IANA zone name, or nil when empty or unknown
+func loadZone(name string) *time.Location {
+	if name == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// orgLocation is the org's default zone
+func orgLocation(orgID uint) *time.Location {
+	if loc := loadZone(database.GetOrgSettings(orgID).TimeZone); loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// userLocation is the zone userID's days are counted in
+func userLocation(db *gorm.DB, userID uint) *time.Location {
+	var user models.User
+	db.Select("id", "org_id", "time_zone").First(&user, userID)
+	if loc := loadZone(user.TimeZone); loc != nil {
+		return loc
+	}
+	return orgLocation(user.OrgID)
+}
+
+// callerLocation is the zone of the user making the request
+func callerLocation(c echo.Context) *time.Location {
+	return userLocation(orgDB(c), mw.GetUserID(c))
+}
+
+// dateIn is t's calendar day in loc, as YYYY-MM-DD
+func dateIn(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02")
+}
+
+// todayIn is the current calendar day in loc
+func todayIn(loc *time.Location) string {
+	return dateIn(time.Now(), loc)
+}
+
+// dayBounds returns the start of date in loc and the start of the next day
+func dayBounds(date string, loc *time.Location) (time.Time, time.Time) {
+	start, _ := time.ParseInLocation("2006-01-02", date, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// SetMyTimeZone sets or clears the caller's IANA time zone `{time_zone}`.
+// Existing entries keep the dates they were filed under.
+func SetMyTimeZone(c echo.Context) error {
+	var req struct {
+		TimeZone string `json:"time_zone"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	req.TimeZone = strings.TrimSpace(req.TimeZone)
+	if !validTimeZone(req.TimeZone) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "time_zone must be an IANA zone such as Europe/London"})
+	}
+
+	orgDB(c).Model(&models.User{}).Where("id = ?", mw.GetUserID(c)).Update("time_zone", req.TimeZone)
+	return c.JSON(http.StatusOK, map[string]string{"time_zone": req.TimeZone})
+}
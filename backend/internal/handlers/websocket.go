@@ -5,30 +5,31 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"time"
 
 	mw "teampulse/internal/middleware"
-	"teampulse/internal/models"
+	"teampulse/internal/policy"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/net/websocket"
 )
 
 // ─── WebSocket Hub ───────────────────────────────────────────
 
+// wsHub tracks monitor connections by organization so updates never cross tenants
 type wsHub struct {
 	mu      sync.RWMutex
-	clients map[*websocket.Conn]bool
+	clients map[*websocket.Conn]uint // conn → org ID
 }
 
 var monitorHub = &wsHub{
-	clients: make(map[*websocket.Conn]bool),
+	clients: make(map[*websocket.Conn]uint),
 }
 
-func (h *wsHub) register(ws *websocket.Conn) {
+func (h *wsHub) register(ws *websocket.Conn, orgID uint) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.clients[ws] = true
+	h.clients[ws] = orgID
 }
 
 func (h *wsHub) unregister(ws *websocket.Conn) {
@@ -38,18 +39,21 @@ func (h *wsHub) unregister(ws *websocket.Conn) {
 	ws.Close()
 }
 
-func (h *wsHub) broadcast(msg []byte) {
+func (h *wsHub) broadcast(orgID uint, msg []byte) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	for ws := range h.clients {
+	for ws, clientOrg := range h.clients {
+		if clientOrg != orgID {
+			continue
+		}
 		if err := websocket.Message.Send(ws, string(msg)); err != nil {
 			go h.unregister(ws)
 		}
 	}
 }
 
-// BroadcastMonitorUpdate sends a monitoring update to all connected admin clients
-func BroadcastMonitorUpdate(eventType string, data interface{}) {
+// BroadcastMonitorUpdate sends a monitoring update to the org's connected admin clients
+func BroadcastMonitorUpdate(orgID uint, eventType string, data interface{}) {
 	msg, err := json.Marshal(map[string]interface{}{
 		"type": eventType,
 		"data": data,
@@ -57,17 +61,27 @@ func BroadcastMonitorUpdate(eventType string, data interface{}) {
 	if err != nil {
 		return
 	}
-	monitorHub.broadcast(msg)
+	monitorHub.broadcast(orgID, msg)
 }
 
 // ─── WebSocket Endpoint ──────────────────────────────────────
 
+// wsRecheckInterval is how often an open monitor socket checks that the
+// login behind it is still live and still allowed the feed
+var wsRecheckInterval = time.Minute
+
 // MonitorWebSocket handles admin WebSocket connections for live monitoring
 func MonitorWebSocket(c echo.Context) error {
+	orgID := mw.GetOrgID(c)
+	claims, _ := c.Get("ws_claims").(*mw.JWTClaims)
 	websocket.Handler(func(ws *websocket.Conn) {
-		monitorHub.register(ws)
+		monitorHub.register(ws, orgID)
 		defer monitorHub.unregister(ws)
 
+		done := make(chan struct{})
+		defer close(done)
+		go closeWhenRevoked(ws, claims, done)
+
 		log.Printf("WebSocket client connected (total: %d)", len(monitorHub.clients))
 
 		// Keep connection alive by reading (blocks until disconnect)
@@ -85,6 +99,38 @@ func MonitorWebSocket(c echo.Context) error {
 	return nil
 }
 
+// closeWhenRevoked closes ws once its session or device is revoked, or its
+// user loses the monitor feed, until done is closed
+func closeWhenRevoked(ws *websocket.Conn, claims *mw.JWTClaims, done <-chan struct{}) {
+	ticker := time.NewTicker(wsRecheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if !canMonitor(claims) {
+				log.Printf("WebSocket client closed: access revoked")
+				ws.Close()
+				return
+			}
+		}
+	}
+}
+
+// canMonitor reports whether the login behind claims may watch the org-wide
+// monitor feed
+func canMonitor(claims *mw.JWTClaims) bool {
+	if claims == nil {
+		return false
+	}
+	user, err := mw.Authenticate(claims)
+	if err != nil {
+		return false
+	}
+	return mw.Permissions(user).Has(policy.ActivityView, policy.ScopeAll)
+}
+
 // WsAuthMiddleware checks JWT from query param for WebSocket connections
 func WsAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -94,25 +140,22 @@ func WsAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "token required"})
 		}
 
-		// Validate token manually
-		t, err := jwt.ParseWithClaims(token, &mw.JWTClaims{}, func(t *jwt.Token) (interface{}, error) {
-			return mw.GetJWTSecret(), nil
-		})
-		if err != nil || !t.Valid {
+		claims, err := mw.ParseToken(token)
+		if err != nil {
 			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
 		}
 
-		claims, ok := t.Claims.(*mw.JWTClaims)
-		if !ok {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid claims"})
+		user, err := mw.Authenticate(claims)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
 		}
 
-		if claims.Role != models.RoleAdmin {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin access required"})
+		// The monitor feed is org-wide, so it needs scope:all as well
+		mw.SetUser(c, user)
+		if !mw.Can(c, policy.ActivityView, policy.ScopeAll) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "missing permission activity:view"})
 		}
-
-		c.Set("user_id", claims.UserID)
-		c.Set("user_role", claims.Role)
+		c.Set("ws_claims", claims)
 
 		return next(c)
 	}
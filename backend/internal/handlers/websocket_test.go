@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mw "teampulse/internal/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+func TestMonitorWebSocketClosesWhenSessionRevoked(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "sessions" WHERE id = \$1 AND user_id = \$2 AND revoked_at IS NULL`).
+		WithArgs(3, 1, sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	prev := wsRecheckInterval
+	wsRecheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { wsRecheckInterval = prev })
+
+	e := echo.New()
+	e.GET("/ws", func(c echo.Context) error {
+		c.Set("org_id", uint(1))
+		c.Set("ws_claims", &mw.JWTClaims{UserID: 1, SessionID: 3})
+		return MonitorWebSocket(c)
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	ws, err := websocket.Dial(url, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg string
+	err = websocket.Message.Receive(ws, &msg)
+	var netErr net.Error
+	if err == nil || (errors.As(err, &netErr) && netErr.Timeout()) {
+		t.Fatalf("socket still open after its session was revoked: %v", err)
+	}
+}
+
+func TestCanMonitorWithoutClaims(t *testing.T) {
+	if canMonitor(nil) {
+		t.Error("canMonitor(nil) = true")
+	}
+}
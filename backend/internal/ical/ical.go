@@ -0,0 +1,145 @@
+// Package ical reads the all-day events of an iCalendar (RFC 5545) file,
+// which is how holiday calendars are published. Only VEVENT dates, SUMMARY
+// and UID are used; recurrence rules are not expanded.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is one calendar entry. Start and End are YYYY-MM-DD; End is the last
+// day it covers (inclusive), unlike DTEND.
+type Event struct {
+	UID     string
+	Summary string
+	Start   string
+	End     string
+}
+
+// Days lists the dates the event covers, in order
+func (e Event) Days() []string {
+	from, _ := time.Parse("2006-01-02", e.Start)
+	to, _ := time.Parse("2006-01-02", e.End)
+	var days []string
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format("2006-01-02"))
+	}
+	return days
+}
+
+// maxEventDays is the most days one event covers, so a malformed DTEND
+// can't produce years of holidays
+const maxEventDays = 31
+
+var ErrNotCalendar = errors.New("not an iCalendar file")
+
+// Parse reads every VEVENT with a usable DTSTART
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, ErrNotCalendar
+	}
+
+	var events []Event
+	var cur *Event
+	var endExclusive bool
+	for _, line := range lines {
+		name, value := splitLine(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			cur, endExclusive = &Event{}, false
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if cur != nil && cur.Start != "" {
+				events = append(events, finish(*cur, endExclusive))
+			}
+			cur = nil
+		case cur == nil:
+			continue
+		case name == "UID":
+			cur.UID = value
+		case name == "SUMMARY":
+			cur.Summary = unescape(value)
+		case name == "DTSTART":
+			cur.Start = parseDate(value)
+		case name == "DTEND":
+			cur.End = parseDate(value)
+			// DTEND excludes its day for all-day events; a timed event ends
+			// on the day it names
+			endExclusive = !strings.Contains(value, "T")
+		}
+	}
+	return events, nil
+}
+
+// finish fills in and bounds an event's last day
+func finish(e Event, endExclusive bool) Event {
+	start, _ := time.Parse("2006-01-02", e.Start)
+	end, err := time.Parse("2006-01-02", e.End)
+	if err != nil {
+		end = start
+	} else if endExclusive {
+		end = end.AddDate(0, 0, -1)
+	}
+	if end.Before(start) {
+		end = start
+	}
+	if end.Sub(start) >= maxEventDays*24*time.Hour {
+		end = start.AddDate(0, 0, maxEventDays-1)
+	}
+	e.End = end.Format("2006-01-02")
+	return e
+}
+
+// unfold joins continuation lines (those starting with a space or tab) onto
+// the line before
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, sc.Err()
+}
+
+// splitLine breaks "NAME;PARAMS:VALUE" into its upper-cased name and value
+func splitLine(line string) (string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	name, _, _ := strings.Cut(head, ";")
+	return strings.ToUpper(name), value
+}
+
+// parseDate reads the date part of a DATE or DATE-TIME value
+func parseDate(value string) string {
+	if len(value) < 8 {
+		return ""
+	}
+	d, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return ""
+	}
+	return d.Format("2006-01-02")
+}
+
+var unescaper = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescape(value string) string {
+	return strings.TrimSpace(unescaper.Replace(value))
+}
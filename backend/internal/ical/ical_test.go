@@ -0,0 +1,137 @@
+package ical
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// calendar wraps lines in a VCALENDAR with CRLF endings
+func calendar(lines ...string) string {
+	all := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0", "X-WR-CALNAME:Holidays"}, lines...)
+	return strings.Join(append(all, "END:VCALENDAR"), "\r\n") + "\r\n"
+}
+
+// event is a VEVENT with the given properties
+func event(props ...string) []string {
+	return append(append([]string{"BEGIN:VEVENT"}, props...), "END:VEVENT")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Event
+	}{
+		{
+			name: "single all-day event",
+			in:   calendar(event("UID:xmas", "SUMMARY:Christmas Day", "DTSTART;VALUE=DATE:20261225", "DTEND;VALUE=DATE:20261226")...),
+			want: []Event{{UID: "xmas", Summary: "Christmas Day", Start: "2026-12-25", End: "2026-12-25"}},
+		},
+		{
+			name: "date-only DTEND is exclusive",
+			in:   calendar(event("SUMMARY:Break", "DTSTART;VALUE=DATE:20261224", "DTEND;VALUE=DATE:20261227")...),
+			want: []Event{{Summary: "Break", Start: "2026-12-24", End: "2026-12-26"}},
+		},
+		{
+			name: "timed DTEND is inclusive",
+			in:   calendar(event("SUMMARY:Offsite", "DTSTART:20261225T090000Z", "DTEND:20261226T170000Z")...),
+			want: []Event{{Summary: "Offsite", Start: "2026-12-25", End: "2026-12-26"}},
+		},
+		{
+			name: "no DTEND is one day",
+			in:   calendar(event("SUMMARY:Boxing Day", "DTSTART;VALUE=DATE:20261226")...),
+			want: []Event{{Summary: "Boxing Day", Start: "2026-12-26", End: "2026-12-26"}},
+		},
+		{
+			name: "DTEND before DTSTART",
+			in:   calendar(event("DTSTART;VALUE=DATE:20261226", "DTEND;VALUE=DATE:20261220")...),
+			want: []Event{{Start: "2026-12-26", End: "2026-12-26"}},
+		},
+		{
+			name: "31 days are kept",
+			in:   calendar(event("DTSTART;VALUE=DATE:20260101", "DTEND;VALUE=DATE:20260201")...),
+			want: []Event{{Start: "2026-01-01", End: "2026-01-31"}},
+		},
+		{
+			name: "longer events are capped at 31 days",
+			in:   calendar(event("DTSTART;VALUE=DATE:20260101", "DTEND;VALUE=DATE:20270101")...),
+			want: []Event{{Start: "2026-01-01", End: "2026-01-31"}},
+		},
+		{
+			name: "folded lines",
+			in:   calendar(event("UID:new-year@exam", "\tple.com", "SUMMARY:New Year's ", " Day", "DTSTART;VALUE=DATE:20270101")...),
+			want: []Event{{UID: "new-year@example.com", Summary: "New Year's Day", Start: "2027-01-01", End: "2027-01-01"}},
+		},
+		{
+			name: "escaped text",
+			in:   calendar(event(`SUMMARY:Christmas\, observed\nOffice closed`, "DTSTART;VALUE=DATE:20261228")...),
+			want: []Event{{Summary: "Christmas, observed Office closed", Start: "2026-12-28", End: "2026-12-28"}},
+		},
+		{
+			name: "byte order mark and bare newlines",
+			in:   "\ufeff" + strings.ReplaceAll(calendar(event("DTSTART;VALUE=DATE:20260704")...), "\r\n", "\n"),
+			want: []Event{{Start: "2026-07-04", End: "2026-07-04"}},
+		},
+		{
+			name: "names are case-insensitive",
+			in:   "begin:vcalendar\r\nbegin:vevent\r\ndtstart;value=date:20260704\r\nsummary:Independence Day\r\nend:vevent\r\nend:vcalendar\r\n",
+			want: []Event{{Summary: "Independence Day", Start: "2026-07-04", End: "2026-07-04"}},
+		},
+		{
+			name: "events without a usable DTSTART are skipped",
+			in: calendar(append(
+				event("SUMMARY:No start"),
+				event("SUMMARY:Bad start", "DTSTART:2026")...)...),
+			want: nil,
+		},
+		{
+			name: "properties outside an event are ignored",
+			in:   calendar(append([]string{"UID:calendar", "DTSTART:20260101"}, event("DTSTART;VALUE=DATE:20260525")...)...),
+			want: []Event{{Start: "2026-05-25", End: "2026-05-25"}},
+		},
+		{
+			name: "several events in order",
+			in: calendar(append(
+				event("UID:a", "DTSTART;VALUE=DATE:20261225"),
+				event("UID:b", "DTSTART;VALUE=DATE:20260101")...)...),
+			want: []Event{{UID: "a", Start: "2026-12-25", End: "2026-12-25"}, {UID: "b", Start: "2026-01-01", End: "2026-01-01"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNotCalendar(t *testing.T) {
+	for _, in := range []string{"", "\r\n\r\n", "<html><body>Holidays</body></html>", "BEGIN:VEVENT\r\nEND:VEVENT\r\n"} {
+		if _, err := Parse(strings.NewReader(in)); !errors.Is(err, ErrNotCalendar) {
+			t.Errorf("Parse(%q) error = %v, want ErrNotCalendar", in, err)
+		}
+	}
+}
+
+func TestDays(t *testing.T) {
+	tests := []struct {
+		event Event
+		want  []string
+	}{
+		{Event{Start: "2026-12-25", End: "2026-12-25"}, []string{"2026-12-25"}},
+		{Event{Start: "2026-12-30", End: "2027-01-02"}, []string{"2026-12-30", "2026-12-31", "2027-01-01", "2027-01-02"}},
+	}
+	for _, tt := range tests {
+		if got := tt.event.Days(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.Days() = %v, want %v", tt.event, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package leave keeps paid time off balances: hours accrue at the end of each
+// pay period up to a cap, and at the turn of the year only a limited amount
+// carries over.
+package leave
+
+import (
+	"time"
+
+	"teampulse/internal/payperiod"
+)
+
+// Policy is how one leave type accrues
+type Policy struct {
+	AccrualHours      float64  // credited per completed pay period
+	MaxBalanceHours   float64  // accrual stops at this balance; 0 = no cap
+	MaxCarryoverHours *float64 // balance kept into a new year; nil = all of it
+}
+
+// Balance is one person's standing in one leave type
+type Balance struct {
+	Hours      float64
+	LastPeriod time.Time // start of the last pay period credited
+	Year       int       // calendar year the balance belongs to
+}
+
+// Start opens a balance on today: the current pay period is the first to be
+// credited, once it completes
+func Start(schedule payperiod.Schedule, today time.Time) Balance {
+	start, _ := schedule.Containing(today)
+	previous, _ := schedule.Containing(start.AddDate(0, 0, -1))
+	return Balance{LastPeriod: previous, Year: today.Year()}
+}
+
+// Accrue credits every pay period completed since the last one credited
+// before today, applying the year-end carryover limit as years go by
+func Accrue(policy Policy, schedule payperiod.Schedule, b Balance, today time.Time) Balance {
+	if b.LastPeriod.IsZero() {
+		fresh := Start(schedule, today)
+		b.LastPeriod, b.Year = fresh.LastPeriod, fresh.Year
+		return b
+	}
+	_, lastEnd := schedule.Containing(b.LastPeriod)
+	for {
+		start, end := schedule.Containing(lastEnd.AddDate(0, 0, 1))
+		if !end.Before(calendarDay(today)) {
+			break
+		}
+		b = rollYear(policy, b, end.Year())
+		if policy.AccrualHours > 0 {
+			b.Hours += policy.AccrualHours
+			if policy.MaxBalanceHours > 0 && b.Hours > policy.MaxBalanceHours {
+				b.Hours = max(policy.MaxBalanceHours, b.Hours-policy.AccrualHours)
+			}
+		}
+		b.LastPeriod = start
+		lastEnd = end
+	}
+	return rollYear(policy, b, today.Year())
+}
+
+// rollYear applies the carryover limit when the balance enters a later year
+func rollYear(policy Policy, b Balance, year int) Balance {
+	if year <= b.Year {
+		return b
+	}
+	if policy.MaxCarryoverHours != nil && b.Hours > *policy.MaxCarryoverHours {
+		b.Hours = *policy.MaxCarryoverHours
+	}
+	b.Year = year
+	return b
+}
+
+// Workdays lists the Monday-to-Friday dates from start to end inclusive
+// (YYYY-MM-DD), leaving out any in skip
+func Workdays(start, end string, skip map[string]bool) []string {
+	from, err1 := time.Parse("2006-01-02", start)
+	to, err2 := time.Parse("2006-01-02", end)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	var days []string
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday || skip[date] {
+			continue
+		}
+		days = append(days, date)
+	}
+	return days
+}
+
+func calendarDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
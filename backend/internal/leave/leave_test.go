@@ -0,0 +1,153 @@
+package leave
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"teampulse/internal/payperiod"
+)
+
+func day(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+func hours(h float64) *float64 { return &h }
+
+// weekly periods run Monday to Sunday
+var weekly = payperiod.Schedule{Kind: payperiod.Weekly, Anchor: day(2024, 1, 1)}
+
+func TestStart(t *testing.T) {
+	got := Start(weekly, day(2026, 3, 4))
+	want := Balance{LastPeriod: day(2026, 2, 23), Year: 2026}
+	if got != want {
+		t.Errorf("Start() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAccrue(t *testing.T) {
+	opened := Start(weekly, day(2026, 3, 4)) // first credit is the week of 2 March
+
+	tests := []struct {
+		name   string
+		policy Policy
+		b      Balance
+		today  time.Time
+		want   Balance
+	}{
+		{
+			name:   "new balance only starts",
+			policy: Policy{AccrualHours: 8},
+			b:      Balance{Hours: 5},
+			today:  day(2026, 3, 4),
+			want:   Balance{Hours: 5, LastPeriod: day(2026, 2, 23), Year: 2026},
+		},
+		{
+			name:   "period not yet complete",
+			policy: Policy{AccrualHours: 8},
+			b:      opened,
+			today:  day(2026, 3, 8),
+			want:   opened,
+		},
+		{
+			name:   "one completed period",
+			policy: Policy{AccrualHours: 8},
+			b:      opened,
+			today:  day(2026, 3, 9),
+			want:   Balance{Hours: 8, LastPeriod: day(2026, 3, 2), Year: 2026},
+		},
+		{
+			name:   "several completed periods",
+			policy: Policy{AccrualHours: 8},
+			b:      opened,
+			today:  day(2026, 3, 23),
+			want:   Balance{Hours: 24, LastPeriod: day(2026, 3, 16), Year: 2026},
+		},
+		{
+			name:   "accrual stops at the cap",
+			policy: Policy{AccrualHours: 8, MaxBalanceHours: 20},
+			b:      opened,
+			today:  day(2026, 3, 23),
+			want:   Balance{Hours: 20, LastPeriod: day(2026, 3, 16), Year: 2026},
+		},
+		{
+			name:   "a balance over the cap isn't cut",
+			policy: Policy{AccrualHours: 8, MaxBalanceHours: 20},
+			b:      Balance{Hours: 30, LastPeriod: opened.LastPeriod, Year: 2026},
+			today:  day(2026, 3, 9),
+			want:   Balance{Hours: 30, LastPeriod: day(2026, 3, 2), Year: 2026},
+		},
+		{
+			name:   "no accrual still moves the period on",
+			policy: Policy{},
+			b:      Balance{Hours: 4, LastPeriod: opened.LastPeriod, Year: 2026},
+			today:  day(2026, 3, 9),
+			want:   Balance{Hours: 4, LastPeriod: day(2026, 3, 2), Year: 2026},
+		},
+		{
+			name:   "carryover limit before the new year's first credit",
+			policy: Policy{AccrualHours: 8, MaxCarryoverHours: hours(16)},
+			b:      Balance{Hours: 40, LastPeriod: day(2026, 12, 21), Year: 2026},
+			today:  day(2027, 1, 5),
+			want:   Balance{Hours: 24, LastPeriod: day(2026, 12, 28), Year: 2027},
+		},
+		{
+			name:   "no carryover limit keeps everything",
+			policy: Policy{AccrualHours: 8},
+			b:      Balance{Hours: 40, LastPeriod: day(2026, 12, 21), Year: 2026},
+			today:  day(2027, 1, 5),
+			want:   Balance{Hours: 48, LastPeriod: day(2026, 12, 28), Year: 2027},
+		},
+		{
+			name:   "carryover applies on the new year without a completed period",
+			policy: Policy{AccrualHours: 8, MaxCarryoverHours: hours(16)},
+			b:      Balance{Hours: 40, LastPeriod: day(2026, 12, 28), Year: 2026},
+			today:  day(2027, 1, 2),
+			want:   Balance{Hours: 16, LastPeriod: day(2026, 12, 28), Year: 2027},
+		},
+		{
+			name:   "zero carryover",
+			policy: Policy{AccrualHours: 8, MaxCarryoverHours: hours(0)},
+			b:      Balance{Hours: 40, LastPeriod: day(2026, 12, 28), Year: 2026},
+			today:  day(2027, 1, 2),
+			want:   Balance{Hours: 0, LastPeriod: day(2026, 12, 28), Year: 2027},
+		},
+		{
+			name:   "carryover under the limit is kept",
+			policy: Policy{MaxCarryoverHours: hours(16)},
+			b:      Balance{Hours: 10, LastPeriod: day(2026, 12, 28), Year: 2026},
+			today:  day(2027, 1, 2),
+			want:   Balance{Hours: 10, LastPeriod: day(2026, 12, 28), Year: 2027},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Accrue(tt.policy, weekly, tt.b, tt.today); got != tt.want {
+				t.Errorf("Accrue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkdays(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		skip       map[string]bool
+		want       []string
+	}{
+		{"skips the weekend", "2026-03-05", "2026-03-10", nil, []string{"2026-03-05", "2026-03-06", "2026-03-09", "2026-03-10"}},
+		{"skips listed days", "2026-03-05", "2026-03-10", map[string]bool{"2026-03-09": true}, []string{"2026-03-05", "2026-03-06", "2026-03-10"}},
+		{"single day", "2026-03-05", "2026-03-05", nil, []string{"2026-03-05"}},
+		{"weekend only", "2026-03-07", "2026-03-08", nil, nil},
+		{"end before start", "2026-03-10", "2026-03-05", nil, nil},
+		{"bad date", "2026-03-05", "next week", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Workdays(tt.start, tt.end, tt.skip); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Workdays(%s, %s) = %v, want %v", tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
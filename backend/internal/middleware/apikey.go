@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"teampulse/internal/database"
+	"teampulse/internal/models"
+)
+
+// APIKeyPrefix starts every personal API key, so JWTMiddleware can tell
+// them from access tokens and secret scanners can spot leaked ones
+const APIKeyPrefix = "tpk_"
+
+// apiKeyTouchInterval throttles last-used writes for busy scripts
+const apiKeyTouchInterval = time.Minute
+
+// HashAPIKey returns the stored form of a key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuthenticateAPIKey resolves a live, unexpired key and its owner, and
+// records when and from where it was last used
+func AuthenticateAPIKey(raw, ip string) (models.APIKey, models.User, error) {
+	now := time.Now()
+
+	var key models.APIKey
+	err := database.DB.Where("key_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", HashAPIKey(raw), now).
+		First(&key).Error
+	if err != nil {
+		return models.APIKey{}, models.User{}, errors.New("invalid, expired or revoked api key")
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ? AND is_active = true", key.UserID).First(&user).Error; err != nil {
+		return models.APIKey{}, models.User{}, errors.New("user is inactive")
+	}
+	if err := database.DB.Where("id = ? AND is_active = true", user.OrgID).First(&models.Organization{}).Error; err != nil {
+		return models.APIKey{}, models.User{}, errors.New("organization is suspended")
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval || key.LastUsedIP != ip {
+		database.DB.Model(&key).Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip})
+	}
+	return key, user, nil
+}
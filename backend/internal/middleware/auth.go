@@ -1,12 +1,15 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"teampulse/internal/database"
 	"teampulse/internal/models"
+	"teampulse/internal/policy"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
@@ -17,6 +20,12 @@ type JWTClaims struct {
 	Email  string      `json:"email"`
 	Role   models.Role `json:"role"`
 	Name   string      `json:"name"`
+	// SessionID is set on access tokens issued to a web login
+	SessionID uint `json:"sid,omitempty"`
+	// DeviceID is set on access tokens issued to a desktop agent install
+	DeviceID uint `json:"device_id,omitempty"`
+	// Purpose marks tokens that are not access tokens (e.g. "mfa" challenges)
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -28,23 +37,137 @@ func GetJWTSecret() []byte {
 	return []byte(secret)
 }
 
-func GenerateToken(user models.User) (string, error) {
+// AccessTokenTTL is the lifetime of access tokens issued to browsers and
+// desktop agents. Both renew them with their refresh secret.
+const AccessTokenTTL = 15 * time.Minute
+
+// SessionTTL is how long a web login's refresh secret stays valid
+const SessionTTL = 30 * 24 * time.Hour
+
+func signClaims(user models.User, sessionID, deviceID uint) (string, time.Time, error) {
+	expiresAt := time.Now().Add(AccessTokenTTL)
 	claims := JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
-		Name:   user.Name,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		Name:      user.Name,
+		SessionID: sessionID,
+		DeviceID:  deviceID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(GetJWTSecret())
+	signed, err := token.SignedString(GetJWTSecret())
+	return signed, expiresAt, err
+}
+
+// GenerateToken issues a short-lived access token bound to a web session
+func GenerateToken(user models.User, sessionID uint) (string, time.Time, error) {
+	return signClaims(user, sessionID, 0)
 }
 
-// JWTMiddleware validates the Bearer token and injects claims into context
+// GenerateDeviceToken issues a short-lived access token bound to an agent device
+func GenerateDeviceToken(user models.User, deviceID uint) (string, time.Time, error) {
+	return signClaims(user, 0, deviceID)
+}
+
+// MFAChallengeTTL is how long a user has to enter their second factor after
+// a successful password check
+const MFAChallengeTTL = 5 * time.Minute
+
+// GenerateMFAChallenge issues a token proving the password step of login
+// succeeded. It can only be redeemed at /api/auth/mfa/verify.
+func GenerateMFAChallenge(user models.User) (string, time.Time, error) {
+	expiresAt := time.Now().Add(MFAChallengeTTL)
+	claims := JWTClaims{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Purpose: "mfa",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(GetJWTSecret())
+	return signed, expiresAt, err
+}
+
+// ParseMFAChallenge validates a token issued by GenerateMFAChallenge
+func ParseMFAChallenge(raw string) (*JWTClaims, error) {
+	claims, err := ParseToken(raw)
+	if err != nil || claims.Purpose != "mfa" {
+		return nil, errors.New("invalid or expired mfa challenge")
+	}
+	return claims, nil
+}
+
+// MFAEnrollmentRequired reports whether org policy obliges the user to enrol
+// in MFA before using the app
+func MFAEnrollmentRequired(user models.User) bool {
+	return user.Role == models.RoleAdmin && !user.MFAEnabled && database.GetOrgSettings(user.OrgID).RequireAdminMFA
+}
+
+// ParseToken validates the signature and expiry of an access token
+func ParseToken(raw string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(raw, &JWTClaims{}, func(t *jwt.Token) (interface{}, error) {
+		return GetJWTSecret(), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// Authenticate checks that the session or device behind the token is still
+// live and returns the user as currently stored, so revocation, deactivation
+// and role changes apply immediately rather than at token expiry.
+func Authenticate(claims *JWTClaims) (models.User, error) {
+	if claims.Purpose != "" {
+		return models.User{}, errors.New("invalid token")
+	}
+
+	now := time.Now()
+	switch {
+	case claims.SessionID != 0:
+		var session models.Session
+		err := database.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", claims.SessionID, claims.UserID, now).
+			First(&session).Error
+		if err != nil {
+			return models.User{}, errors.New("session revoked")
+		}
+	case claims.DeviceID != 0:
+		var device models.Device
+		err := database.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", claims.DeviceID, claims.UserID).
+			First(&device).Error
+		if err != nil {
+			return models.User{}, errors.New("device revoked")
+		}
+	default:
+		// Tokens minted before sessions existed can't be revoked, so refuse them
+		return models.User{}, errors.New("session expired")
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ? AND is_active = true", claims.UserID).First(&user).Error; err != nil {
+		return models.User{}, errors.New("user is inactive")
+	}
+	if err := database.DB.Where("id = ? AND is_active = true", user.OrgID).First(&models.Organization{}).Error; err != nil {
+		return models.User{}, errors.New("organization is suspended")
+	}
+	return user, nil
+}
+
+// JWTMiddleware validates the Bearer token, an access token or a personal API
+// key, and injects the authenticated user into context
 func JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		auth := c.Request().Header.Get("Authorization")
@@ -57,46 +180,92 @@ func JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid authorization format"})
 		}
 
-		token, err := jwt.ParseWithClaims(parts[1], &JWTClaims{}, func(t *jwt.Token) (interface{}, error) {
-			return GetJWTSecret(), nil
-		})
-		if err != nil || !token.Valid {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired token"})
+		if strings.HasPrefix(parts[1], APIKeyPrefix) {
+			key, user, err := AuthenticateAPIKey(parts[1], c.RealIP())
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+			}
+			if !policy.ScopesAllow(key.Scopes, c.Request().Method, c.Path()) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "api key scopes do not cover this endpoint"})
+			}
+			SetUser(c, user)
+			c.Set("api_key_id", key.ID)
+			return next(c)
 		}
 
-		claims, ok := token.Claims.(*JWTClaims)
-		if !ok {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token claims"})
+		claims, err := ParseToken(parts[1])
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
-		c.Set("user_name", claims.Name)
+		user, err := Authenticate(claims)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
 
-		return next(c)
-	}
-}
+		// Browsers may only use the auth endpoints until the account is set up
+		if claims.SessionID != 0 && !strings.HasPrefix(c.Path(), "/api/auth/") {
+			if user.MustChangePassword {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "password change required"})
+			}
+			if MFAEnrollmentRequired(user) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "mfa enrollment required"})
+			}
+		}
 
-// AdminOnly restricts access to admin role
-func AdminOnly(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		role, ok := c.Get("user_role").(models.Role)
-		if !ok || role != models.RoleAdmin {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin access required"})
+		SetUser(c, user)
+		if claims.SessionID != 0 {
+			c.Set("session_id", claims.SessionID)
+		}
+		if claims.DeviceID != 0 {
+			c.Set("device_id", claims.DeviceID)
 		}
+
 		return next(c)
 	}
 }
 
+// SetUser injects the authenticated user into context
+func SetUser(c echo.Context, user models.User) {
+	c.Set("user_id", user.ID)
+	c.Set("org_id", user.OrgID)
+	c.Set("platform_admin", user.IsPlatformAdmin)
+	c.Set("user_email", user.Email)
+	c.Set("user_role", user.Role)
+	c.Set("user_name", user.Name)
+	c.Set("permissions", Permissions(user))
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(c echo.Context) uint {
 	id, _ := c.Get("user_id").(uint)
 	return id
 }
 
+// GetOrgID extracts the caller's organization ID from context
+func GetOrgID(c echo.Context) uint {
+	id, _ := c.Get("org_id").(uint)
+	return id
+}
+
+// PlatformAdminOnly restricts organization management to platform operators
+func PlatformAdminOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ok, _ := c.Get("platform_admin").(bool); !ok {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "platform admin access required"})
+		}
+		return next(c)
+	}
+}
+
 // GetUserRole extracts role from context
 func GetUserRole(c echo.Context) models.Role {
 	role, _ := c.Get("user_role").(models.Role)
 	return role
 }
+
+// GetSessionID extracts the web session ID from context (0 for agent tokens)
+func GetSessionID(c echo.Context) uint {
+	id, _ := c.Get("session_id").(uint)
+	return id
+}
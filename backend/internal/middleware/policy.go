@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"teampulse/internal/database"
+	"teampulse/internal/models"
+	"teampulse/internal/policy"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Permissions resolves the user's built-in role plus any custom role
+func Permissions(user models.User) policy.Set {
+	var extra []string
+	if user.CustomRoleID != nil {
+		var role models.CustomRole
+		if err := database.ForOrg(user.OrgID).First(&role, *user.CustomRoleID).Error; err == nil {
+			extra = role.Permissions
+		}
+	}
+	return policy.Resolve(user.Role, extra)
+}
+
+// Require rejects requests from users lacking any of perms
+func Require(perms ...policy.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, p := range perms {
+				if !Can(c, p) {
+					return c.JSON(http.StatusForbidden, map[string]string{"error": "missing permission " + string(p)})
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// Can reports whether the authenticated user holds every permission in perms
+func Can(c echo.Context, perms ...policy.Permission) bool {
+	set, _ := c.Get("permissions").(policy.Set)
+	return set.Has(perms...)
+}
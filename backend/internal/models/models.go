@@ -12,42 +12,471 @@ type Role string
 
 const (
 	RoleAdmin    Role = "admin"
+	RoleManager  Role = "manager" // sees their reporting line, not the whole org
 	RoleEmployee Role = "employee"
 )
 
 type User struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Email     string         `gorm:"uniqueIndex;not null" json:"email"`
-	Password  string         `gorm:"not null" json:"-"`
-	Name      string         `gorm:"not null" json:"name"`
-	Role      Role           `gorm:"not null;default:employee" json:"role"`
-	Title     string         `json:"title"`
-	PIN       string         `gorm:"size:6" json:"-"`
+	ID                 uint           `gorm:"primaryKey" json:"id"`
+	OrgID              uint           `gorm:"not null;default:1;index" json:"org_id"`
+	Email              string         `gorm:"uniqueIndex;not null" json:"email"`
+	Password           string         `gorm:"not null" json:"-"`
+	Name               string         `gorm:"not null" json:"name"`
+	Role               Role           `gorm:"not null;default:employee" json:"role"`
+	Title              string         `json:"title"`
+	TimeZone           string         `gorm:"size:64" json:"time_zone"` // IANA zone for date bucketing; empty = org default
+	Location           string         `gorm:"size:100" json:"location"` // office or site; picks holiday calendars
+	ManagerID          *uint          `gorm:"index" json:"manager_id"`
+	CustomRoleID       *uint          `gorm:"index" json:"custom_role_id"`            // extra permissions on top of Role
+	IsPlatformAdmin    bool           `gorm:"default:false" json:"is_platform_admin"` // may create and manage organizations
+	PIN                string         `gorm:"size:60" json:"-"`                       // bcrypt of the 6-digit kiosk PIN
+	PINFailures        int            `gorm:"default:0" json:"-"`
+	PINLockedUntil     *time.Time     `json:"-"`
+	MFAFailures        int            `gorm:"default:0" json:"-"`
+	MFALockedUntil     *time.Time     `json:"-"`
 	IsActive           bool           `gorm:"default:true" json:"is_active"`
 	AgentSetupDone     bool           `gorm:"default:false" json:"agent_setup_done"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	MustChangePassword bool           `gorm:"default:false" json:"must_change_password"` // still on an admin-issued password
+	MFAEnabled         bool           `gorm:"default:false" json:"mfa_enabled"`
+	TOTPSecret         string         `json:"-"`                    // base32; set at enrollment, active once MFAEnabled
+	TOTPLastStep       int64          `json:"-"`                    // last accepted TOTP step, to refuse replays
+	OIDCSubject        *string        `gorm:"uniqueIndex" json:"-"` // "sub" at the SSO provider, once linked
+	SCIMExternalID     string         `gorm:"index" json:"-"`       // directory's id for the user
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// MFAEnrollmentRequired is computed per request from OrgSettings
+	MFAEnrollmentRequired bool `gorm:"-" json:"mfa_enrollment_required,omitempty"`
+	// Permissions is the resolved policy set, filled in by GetMe
+	Permissions []string `gorm:"-" json:"permissions,omitempty"`
 }
 
-// ─── Time Clock ───────────────────────────────────────────────
+// CustomRole is an admin-defined bundle of permissions assigned to users on
+// top of their built-in role
+type CustomRole struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	OrgID       uint      `gorm:"not null;default:1;uniqueIndex:idx_role_org_name" json:"org_id"`
+	Name        string    `gorm:"not null;uniqueIndex:idx_role_org_name" json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `gorm:"serializer:json" json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
 
-type TimeEntry struct {
+// Session is a web login. The browser holds a rotating refresh secret and
+// trades it for short-lived access tokens; revoking the row logs it out.
+type Session struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	RefreshHash string     `gorm:"not null;uniqueIndex;size:64" json:"-"` // sha256 of current refresh secret
+	UserAgent   string     `json:"user_agent"`
+	IP          string     `json:"ip"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// APIKey is a long-lived personal credential for scripts and integrations.
+// It acts as its owner, narrowed to Scopes; only a hash of the key is kept.
+type APIKey struct {
 	ID         uint       `gorm:"primaryKey" json:"id"`
+	OrgID      uint       `gorm:"not null;default:1;index" json:"org_id"`
 	UserID     uint       `gorm:"not null;index" json:"user_id"`
-	User       User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	ClockIn    time.Time  `gorm:"not null" json:"clock_in"`
-	ClockOut   *time.Time `json:"clock_out"`
-	Duration   int64      `json:"duration_seconds"` // computed on clock-out
-	Notes      string     `json:"notes"`
-	Date       string     `gorm:"not null;index;size:10" json:"date"` // YYYY-MM-DD for easy filtering
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null;size:16" json:"prefix"`        // first characters of the key, to recognise it
+	KeyHash    string     `gorm:"not null;uniqueIndex;size:64" json:"-"` // sha256 of the full key
+	Scopes     []string   `gorm:"serializer:json" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at"`
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
+// PasswordResetToken is a single-use link emailed by the forgot-password flow
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"not null;uniqueIndex;size:64" json:"-"` // sha256 of the emailed token
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MFARecoveryCode is a single-use fallback for a lost authenticator
+type MFARecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null;size:64" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// OIDCLoginRequest tracks one SSO round trip: the state/nonce/PKCE verifier
+// sent to the provider, then a single-use ticket the SPA trades for a session.
+type OIDCLoginRequest struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	State        string     `gorm:"not null;uniqueIndex" json:"-"`
+	Nonce        string     `gorm:"not null" json:"-"`
+	CodeVerifier string     `gorm:"not null" json:"-"`
+	UserID       *uint      `json:"user_id"`
+	TicketHash   *string    `gorm:"uniqueIndex;size:64" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	UsedAt       *time.Time `json:"used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ─── Organizations ────────────────────────────────────────────
+
+// Organization is a tenant. Every org-owned row carries its OrgID and is only
+// reachable through database.ForOrg; ID 1 is the default org that pre-tenant
+// data was migrated into.
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Slug      string    `gorm:"not null;uniqueIndex;size:64" json:"slug"`
+	IsActive  bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ─── Settings ─────────────────────────────────────────────────
+
+// OrgSettings holds organisation-wide policy, one row per organisation
+type OrgSettings struct {
+	ID                  uint       `gorm:"primaryKey" json:"-"`
+	OrgID               uint       `gorm:"not null;default:1;uniqueIndex" json:"-"`
+	RequireAdminMFA     bool       `gorm:"default:false" json:"require_admin_mfa"`
+	SCIMTokenHash       string     `gorm:"size:64;index" json:"-"` // sha256 of the directory's provisioning token
+	SCIMTokenSetAt      *time.Time `json:"scim_token_set_at"`
+	MaxSessionHours     int        `gorm:"default:16" json:"max_session_hours"`     // auto clock-out after this long; 0 = never
+	IdleClockOutMinutes int        `gorm:"default:0" json:"idle_clock_out_minutes"` // auto clock-out agent users this long after the agent's last report; 0 = off
+	// Overtime thresholds in hours; 0 turns a rule off
+	DailyOvertimeHours     float64 `gorm:"default:8" json:"daily_overtime_hours"`
+	DailyDoubleTimeHours   float64 `gorm:"default:12" json:"daily_double_time_hours"`
+	WeeklyOvertimeHours    float64 `gorm:"default:40" json:"weekly_overtime_hours"`
+	PayPeriod              string  `gorm:"size:16;default:weekly" json:"pay_period"`            // weekly, biweekly, semimonthly or monthly
+	PayPeriodAnchor        string  `gorm:"size:10;default:2024-01-01" json:"pay_period_anchor"` // first day of any one period
+	TimeZone               string  `gorm:"size:64;default:UTC" json:"time_zone"`                // IANA zone for users without their own
+	AttendanceGraceMinutes int     `gorm:"default:5" json:"attendance_grace_minutes"`           // lateness and early leaving within this are not flagged
+	// Punch rounding: to multiples of the interval, per punch nearest, up or
+	// down; a punch within the grace minutes of a boundary snaps to it
+	RoundingIntervalMinutes int       `gorm:"default:0" json:"rounding_interval_minutes"` // 0 = off
+	RoundClockIn            string    `gorm:"size:8;default:nearest" json:"round_clock_in"`
+	RoundClockOut           string    `gorm:"size:8;default:nearest" json:"round_clock_out"`
+	RoundingGraceMinutes    int       `gorm:"default:0" json:"rounding_grace_minutes"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// ─── Groups ───────────────────────────────────────────────────
+
+// Group is a directory group pushed over SCIM
+type Group struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	OrgID       uint      `gorm:"not null;default:1;uniqueIndex:idx_group_org_name" json:"org_id"`
+	DisplayName string    `gorm:"not null;uniqueIndex:idx_group_org_name" json:"display_name"`
+	ExternalID  string    `gorm:"index" json:"external_id"`
+	Members     []User    `gorm:"many2many:group_members" json:"members,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ─── Time Clock ───────────────────────────────────────────────
+
+type TimeEntry struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	OrgID             uint       `gorm:"not null;default:1;index" json:"org_id"`
+	UserID            uint       `gorm:"not null;index" json:"user_id"`
+	User              User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ClockIn           time.Time  `gorm:"not null" json:"clock_in"` // raw punches, kept for audit
+	ClockOut          *time.Time `json:"clock_out"`
+	RoundedClockIn    *time.Time `json:"rounded_clock_in"` // punches under the org's rounding; worked time counts these
+	RoundedClockOut   *time.Time `json:"rounded_clock_out"`
+	Duration          int64      `json:"duration_seconds"` // computed on clock-out from the rounded times, net of unpaid breaks
+	Notes             string     `json:"notes"`
+	Date              string     `gorm:"not null;index;size:10" json:"date"`     // YYYY-MM-DD for easy filtering
+	KioskID           *uint      `json:"kiosk_id"`                               // set when clocked in at a kiosk
+	AutoClosed        bool       `gorm:"default:false;index" json:"auto_closed"` // ended by the auto clock-out job, needs review
+	AutoCloseReason   string     `json:"auto_close_reason,omitempty"`            // max_length or no_activity
+	SourceIP          string     `gorm:"size:45" json:"source_ip"`               // client address at clock-in
+	OffNetwork        bool       `gorm:"default:false;index" json:"off_network"` // clocked in outside a flag-mode policy's networks
+	NetworkReviewerID *uint      `json:"network_reviewer_id,omitempty"`          // who cleared the off-network flag
+	Breaks            []Break    `gorm:"foreignKey:TimeEntryID" json:"breaks,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type BreakType string
+
+const (
+	BreakPaid   BreakType = "paid"   // short rest break, counts as worked time
+	BreakUnpaid BreakType = "unpaid" // meal period, deducted from the session
+)
+
+// Break is a pause inside a clock session
+type Break struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	OrgID       uint       `gorm:"not null;default:1;index" json:"org_id"`
+	TimeEntryID uint       `gorm:"not null;index" json:"time_entry_id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Type        BreakType  `gorm:"not null;default:unpaid" json:"type"`
+	StartedAt   time.Time  `gorm:"not null" json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at"`
+	Duration    int64      `json:"duration_seconds"` // computed when the break ends
+}
+
+type CorrectionStatus string
+
+const (
+	CorrectionPending  CorrectionStatus = "pending"
+	CorrectionApproved CorrectionStatus = "approved"
+	CorrectionRejected CorrectionStatus = "rejected"
+)
+
+// TimeEntryCorrection is an employee's request to change the times of one of
+// their entries, e.g. after forgetting to clock out
+type TimeEntryCorrection struct {
+	ID               uint             `gorm:"primaryKey" json:"id"`
+	OrgID            uint             `gorm:"not null;default:1;index" json:"org_id"`
+	TimeEntryID      uint             `gorm:"not null;index" json:"time_entry_id"`
+	TimeEntry        TimeEntry        `gorm:"foreignKey:TimeEntryID" json:"time_entry,omitempty"`
+	UserID           uint             `gorm:"not null;index" json:"user_id"`
+	User             User             `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ProposedClockIn  time.Time        `gorm:"not null" json:"proposed_clock_in"`
+	ProposedClockOut time.Time        `gorm:"not null" json:"proposed_clock_out"`
+	Reason           string           `gorm:"not null" json:"reason"`
+	Status           CorrectionStatus `gorm:"not null;default:pending;index" json:"status"`
+	ReviewerID       *uint            `json:"reviewer_id"`
+	ReviewNote       string           `json:"review_note"`
+	ReviewedAt       *time.Time       `json:"reviewed_at"`
+	CreatedAt        time.Time        `json:"created_at"`
+}
+
+// TimeEntryRevision keeps the values a time entry had before an edit
+type TimeEntryRevision struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	OrgID        uint       `gorm:"not null;default:1;index" json:"org_id"`
+	TimeEntryID  uint       `gorm:"not null;index" json:"time_entry_id"`
+	ClockIn      time.Time  `gorm:"not null" json:"clock_in"`
+	ClockOut     *time.Time `json:"clock_out"`
+	Duration     int64      `json:"duration_seconds"`
+	Date         string     `gorm:"size:10" json:"date"`
+	ChangedBy    uint       `gorm:"not null" json:"changed_by"`
+	CorrectionID *uint      `json:"correction_id"`
+	Reason       string     `json:"reason"`
+	CreatedAt    time.Time  `json:"created_at"` // when the change was made
+}
+
+// ─── Timesheets ───────────────────────────────────────────────
+
+type TimesheetStatus string
+
+const (
+	TimesheetDraft     TimesheetStatus = "draft"
+	TimesheetSubmitted TimesheetStatus = "submitted"
+	TimesheetApproved  TimesheetStatus = "approved" // entries in the period are locked
+	TimesheetRejected  TimesheetStatus = "rejected"
+)
+
+// Timesheet is one user's hours for one pay period. Totals are rolled up from
+// TimeEntry and TaskTime; they are snapshotted when the sheet is approved.
+type Timesheet struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	OrgID        uint            `gorm:"not null;default:1;index" json:"org_id"`
+	UserID       uint            `gorm:"not null;uniqueIndex:idx_timesheet_user_period" json:"user_id"`
+	User         User            `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	PeriodStart  string          `gorm:"not null;size:10;uniqueIndex:idx_timesheet_user_period" json:"period_start"` // YYYY-MM-DD
+	PeriodEnd    string          `gorm:"not null;size:10" json:"period_end"`                                         // inclusive
+	Status       TimesheetStatus `gorm:"not null;default:draft;index" json:"status"`
+	TotalSeconds int64           `json:"total_seconds"`
+	EntryCount   int             `json:"entry_count"`
+	LeaveSeconds int64           `json:"leave_seconds"` // approved leave, on top of TotalSeconds
+	SubmittedAt  *time.Time      `json:"submitted_at"`
+	ReviewerID   *uint           `json:"reviewer_id"`
+	ReviewedAt   *time.Time      `json:"reviewed_at"`
+	ReviewNote   string          `json:"review_note"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// ─── Scheduling ───────────────────────────────────────────────
+
+// Shift is a scheduled block of work for one user
+type Shift struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	OrgID      uint      `gorm:"not null;default:1;index" json:"org_id"`
+	UserID     uint      `gorm:"not null;index:idx_shift_user_start" json:"user_id"`
+	User       User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	StartsAt   time.Time `gorm:"not null;index:idx_shift_user_start" json:"starts_at"`
+	EndsAt     time.Time `gorm:"not null" json:"ends_at"`
+	Role       string    `gorm:"size:100" json:"role"` // position worked, e.g. "Cashier"
+	Location   string    `gorm:"size:100" json:"location"`
+	Notes      string    `json:"notes"`
+	TemplateID *uint     `json:"template_id"` // set when created from a weekly template
+	CreatedBy  uint      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ShiftSlot is one recurring shift in a weekly template. Day counts from the
+// week the template is applied to (0 = its first day); Start and End are
+// HH:MM in the user's time zone, and an End at or before Start runs overnight.
+type ShiftSlot struct {
+	Day      int    `json:"day"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	UserID   uint   `json:"user_id"`
+	Role     string `json:"role"`
+	Location string `json:"location"`
+}
+
+// ShiftTemplate is a reusable week of shifts
+type ShiftTemplate struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	OrgID     uint        `gorm:"not null;default:1;uniqueIndex:idx_shift_template_org_name" json:"org_id"`
+	Name      string      `gorm:"not null;uniqueIndex:idx_shift_template_org_name" json:"name"`
+	Slots     []ShiftSlot `gorm:"serializer:json" json:"slots"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// ─── Leave ────────────────────────────────────────────────────
+
+// LeaveType is a kind of time off. Paid types draw on a balance that accrues
+// each pay period; unpaid types are only recorded.
+type LeaveType struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	OrgID             uint      `gorm:"not null;default:1;uniqueIndex:idx_leave_type_org_name" json:"org_id"`
+	Name              string    `gorm:"not null;uniqueIndex:idx_leave_type_org_name" json:"name"`
+	Paid              bool      `gorm:"not null" json:"paid"`
+	AccrualHours      float64   `gorm:"default:0" json:"accrual_hours"`     // credited per completed pay period
+	MaxBalanceHours   float64   `gorm:"default:0" json:"max_balance_hours"` // accrual stops here; 0 = no cap
+	MaxCarryoverHours *float64  `json:"max_carryover_hours"`                // kept into a new year; null = all
+	IsActive          bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// LeaveBalance is one user's hours in one paid leave type. Accrual is brought
+// up to date whenever the balance is read or drawn on.
+type LeaveBalance struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	OrgID       uint      `gorm:"not null;default:1;index" json:"org_id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_leave_balance_user_type" json:"user_id"`
+	LeaveTypeID uint      `gorm:"not null;uniqueIndex:idx_leave_balance_user_type" json:"leave_type_id"`
+	LeaveType   LeaveType `gorm:"foreignKey:LeaveTypeID" json:"leave_type,omitempty"`
+	Hours       float64   `json:"hours"`
+	LastPeriod  time.Time `json:"last_period"` // start of the last pay period credited
+	Year        int       `json:"year"`        // calendar year the balance belongs to
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type LeaveStatus string
+
+const (
+	LeavePending   LeaveStatus = "pending"
+	LeaveApproved  LeaveStatus = "approved" // hours taken from the balance
+	LeaveRejected  LeaveStatus = "rejected"
+	LeaveCancelled LeaveStatus = "cancelled" // withdrawn; approved hours are refunded
+)
+
+// LeaveRequest asks for whole workdays off from StartDate to EndDate
+type LeaveRequest struct {
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	OrgID       uint        `gorm:"not null;default:1;index" json:"org_id"`
+	UserID      uint        `gorm:"not null;index" json:"user_id"`
+	User        User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	LeaveTypeID uint        `gorm:"not null;index" json:"leave_type_id"`
+	LeaveType   LeaveType   `gorm:"foreignKey:LeaveTypeID" json:"leave_type,omitempty"`
+	StartDate   string      `gorm:"not null;size:10;index" json:"start_date"` // YYYY-MM-DD
+	EndDate     string      `gorm:"not null;size:10;index" json:"end_date"`   // inclusive
+	HoursPerDay float64     `gorm:"not null" json:"hours_per_day"`
+	Days        []string    `gorm:"serializer:json" json:"days"` // the workdays covered
+	TotalHours  float64     `json:"total_hours"`
+	Reason      string      `json:"reason"`
+	Status      LeaveStatus `gorm:"not null;default:pending;index" json:"status"`
+	ReviewerID  *uint       `json:"reviewer_id"`
+	ReviewedAt  *time.Time  `json:"reviewed_at"`
+	ReviewNote  string      `json:"review_note"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// ─── Clock-In Networks ────────────────────────────────────────
+
+type ClockInMode string
+
+const (
+	ClockInReject ClockInMode = "reject" // refuse clock-ins from other networks
+	ClockInFlag   ClockInMode = "flag"   // allow them but mark the entry for review
+)
+
+// ClockInPolicy limits where its users may clock in from. It targets one
+// user, a built-in role or a custom role; policies naming a user take the
+// place of any for their roles.
+type ClockInPolicy struct {
+	ID           uint        `gorm:"primaryKey" json:"id"`
+	OrgID        uint        `gorm:"not null;default:1;uniqueIndex:idx_clock_policy_org_name" json:"org_id"`
+	Name         string      `gorm:"not null;uniqueIndex:idx_clock_policy_org_name" json:"name"`
+	UserID       *uint       `gorm:"index" json:"user_id"`
+	Role         Role        `gorm:"size:20" json:"role"`
+	CustomRoleID *uint       `gorm:"index" json:"custom_role_id"`
+	CIDRs        []string    `gorm:"serializer:json" json:"cidrs"` // allowed ranges, e.g. 203.0.113.0/24
+	Mode         ClockInMode `gorm:"not null;default:reject" json:"mode"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// ─── Holidays ─────────────────────────────────────────────────
+
+// HolidayCalendar is a set of days off. One with no Location applies to the
+// whole org; otherwise only to users at that location.
+type HolidayCalendar struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrgID     uint      `gorm:"not null;default:1;uniqueIndex:idx_holiday_calendar_org_name" json:"org_id"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_holiday_calendar_org_name" json:"name"`
+	Location  string    `gorm:"size:100;index" json:"location"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Holiday is one day in a calendar
+type Holiday struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	OrgID      uint      `gorm:"not null;default:1;index" json:"org_id"`
+	CalendarID uint      `gorm:"not null;uniqueIndex:idx_holiday_calendar_date" json:"calendar_id"`
+	Date       string    `gorm:"not null;size:10;uniqueIndex:idx_holiday_calendar_date" json:"date"` // YYYY-MM-DD
+	Name       string    `gorm:"not null" json:"name"`
+	UID        string    `json:"-"` // iCalendar UID it was imported from
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ─── Payroll ──────────────────────────────────────────────────
+
+// PayrollColumn is one column of a payroll export: a field and an optional
+// header. The "task_hours" field expands to one column per task.
+type PayrollColumn struct {
+	Field  string `json:"field"`
+	Header string `json:"header,omitempty"`
+}
+
+// PayrollTemplate is a saved column layout for the payroll CSV export
+type PayrollTemplate struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	OrgID     uint            `gorm:"not null;default:1;uniqueIndex:idx_payroll_template_org_name" json:"org_id"`
+	Name      string          `gorm:"not null;uniqueIndex:idx_payroll_template_org_name" json:"name"`
+	Columns   []PayrollColumn `gorm:"serializer:json" json:"columns"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
 // ─── Activity Tracking ────────────────────────────────────────
 
 type ActivityPing struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
+	OrgID        uint      `gorm:"not null;default:1;index" json:"org_id"`
 	UserID       uint      `gorm:"not null;index" json:"user_id"`
 	TimeEntryID  uint      `gorm:"index" json:"time_entry_id"`
 	Timestamp    time.Time `gorm:"not null" json:"timestamp"`
@@ -76,6 +505,7 @@ const (
 
 type Task struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
+	OrgID       uint           `gorm:"not null;default:1;index" json:"org_id"`
 	Title       string         `gorm:"not null" json:"title"`
 	Description string         `json:"description"`
 	AssigneeID  *uint          `gorm:"index" json:"assignee_id"`
@@ -95,6 +525,7 @@ type Task struct {
 
 type TaskTime struct {
 	ID        uint       `gorm:"primaryKey" json:"id"`
+	OrgID     uint       `gorm:"not null;default:1;index" json:"org_id"`
 	TaskID    uint       `gorm:"not null;index" json:"task_id"`
 	UserID    uint       `gorm:"not null;index" json:"user_id"`
 	User      User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -107,6 +538,7 @@ type TaskTime struct {
 
 type KPI struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrgID     uint      `gorm:"not null;default:1;index" json:"org_id"`
 	UserID    uint      `gorm:"not null;index" json:"user_id"`
 	User      User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Metric    string    `gorm:"not null" json:"metric"`
@@ -122,6 +554,7 @@ type KPI struct {
 
 type Standup struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrgID     uint      `gorm:"not null;default:1;index" json:"org_id"`
 	UserID    uint      `gorm:"not null;index" json:"user_id"`
 	User      User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Date      string    `gorm:"not null;index;size:10" json:"date"`
@@ -135,6 +568,7 @@ type Standup struct {
 
 type AgentSetupToken struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrgID     uint      `gorm:"not null;default:1;index" json:"org_id"`
 	UserID    uint      `gorm:"not null;index" json:"user_id"`
 	Code      string    `gorm:"not null;uniqueIndex;size:8" json:"code"`
 	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
@@ -142,10 +576,44 @@ type AgentSetupToken struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ─── Agent Devices ────────────────────────────────────────────
+
+// Device is a registered desktop agent install. The agent holds a rotating
+// refresh secret and trades it for short-lived access tokens.
+type Device struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	OrgID       uint       `gorm:"not null;default:1;index" json:"org_id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	User        User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Name        string     `json:"name"`                                  // hostname reported by the agent
+	Platform    string     `json:"platform"`                              // win32, darwin, linux
+	RefreshHash string     `gorm:"not null;uniqueIndex;size:64" json:"-"` // sha256 of current refresh secret
+	LastSeenAt  *time.Time `json:"last_seen_at"`
+	LastIP      string     `json:"last_ip"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ─── Kiosks ───────────────────────────────────────────────────
+
+// Kiosk is a shared terminal registered by an admin. Its secret only lets it
+// clock people in and out by PIN; it never receives a user token.
+type Kiosk struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	OrgID      uint       `gorm:"not null;default:1;index" json:"org_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	TokenHash  string     `gorm:"not null;uniqueIndex;size:64" json:"-"` // sha256 of the kiosk secret
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	LastIP     string     `json:"last_ip"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
 // ─── Agent Tracking (Desktop) ─────────────────────────────────
 
 type AgentHeartbeat struct {
 	ID                uint      `gorm:"primaryKey" json:"id"`
+	OrgID             uint      `gorm:"not null;default:1;index" json:"org_id"`
 	UserID            uint      `gorm:"not null;index" json:"user_id"`
 	User              User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Timestamp         time.Time `gorm:"not null" json:"timestamp"`
@@ -166,16 +634,142 @@ type LoginRequest struct {
 }
 
 type RegisterRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Name     string `json:"name"`
-	Title    string `json:"title"`
-	Role     Role   `json:"role"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	Role      Role   `json:"role"`
+	ManagerID *uint  `json:"manager_id"`
+	TimeZone  string `json:"time_zone"` // IANA zone; empty = org default
+}
+
+type CreateOrganizationRequest struct {
+	Name          string `json:"name"`
+	Slug          string `json:"slug"`
+	AdminName     string `json:"admin_name"`
+	AdminEmail    string `json:"admin_email"`
+	AdminPassword string `json:"admin_password"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+	User         User      `json:"user"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"` // TOTP code or recovery code
+}
+
+type SSOTicketRequest struct {
+	Ticket string `json:"ticket"`
+}
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type DeviceTokenRequest struct {
+	DeviceID     uint   `json:"device_id"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type DeviceAuthResponse struct {
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+	DeviceID     uint      `json:"device_id"`
+	User         User      `json:"user"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreateAPIKeyResponse carries the full key, which is only ever shown once
+type CreateAPIKeyResponse struct {
+	Key    string `json:"key"`
+	APIKey APIKey `json:"api_key"`
+}
+
+type CorrectionRequest struct {
+	ClockIn  time.Time `json:"clock_in"`
+	ClockOut time.Time `json:"clock_out"`
+	Reason   string    `json:"reason"`
+}
+
+type ReviewRequest struct {
+	Note string `json:"note"`
+}
+
+type BreakStartRequest struct {
+	Type BreakType `json:"type"`
+}
+
+type LeaveRequestBody struct {
+	LeaveTypeID uint    `json:"leave_type_id"`
+	StartDate   string  `json:"start_date"`
+	EndDate     string  `json:"end_date"`
+	HoursPerDay float64 `json:"hours_per_day"` // default 8
+	Reason      string  `json:"reason"`
+}
+
+type LeaveAdjustRequest struct {
+	UserID      uint    `json:"user_id"`
+	LeaveTypeID uint    `json:"leave_type_id"`
+	Hours       float64 `json:"hours"` // added to the balance; negative deducts
+	Note        string  `json:"note"`
+}
+
+type ShiftRequest struct {
+	UserID   uint      `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Role     string    `json:"role"`
+	Location string    `json:"location"`
+	Notes    string    `json:"notes"`
+}
+
+type ApplyShiftTemplateRequest struct {
+	WeekStart string `json:"week_start"` // YYYY-MM-DD, day 0 of the template
+}
+
+type KioskPINRequest struct {
+	UserID uint   `json:"user_id"`
+	PIN    string `json:"pin"`
 }
 
 type ActivityPingRequest struct {
@@ -188,24 +782,52 @@ type ActivityPingRequest struct {
 }
 
 type DashboardStats struct {
-	TotalEmployees  int64          `json:"total_employees"`
-	ClockedIn       int64          `json:"clocked_in"`
-	TotalHoursToday float64        `json:"total_hours_today"`
-	TasksDoneToday  int64          `json:"tasks_done_today"`
-	PendingTasks    int64          `json:"pending_tasks"`
-	TeamStatus      []TeamMember   `json:"team_status"`
-	RecentActivity  []ActivityStat `json:"recent_activity"`
+	TotalEmployees  int64             `json:"total_employees"`
+	ClockedIn       int64             `json:"clocked_in"`
+	TotalHoursToday float64           `json:"total_hours_today"`
+	TasksDoneToday  int64             `json:"tasks_done_today"`
+	PendingTasks    int64             `json:"pending_tasks"`
+	Attendance      AttendanceSummary `json:"attendance"`
+	TeamStatus      []TeamMember      `json:"team_status"`
+	RecentActivity  []ActivityStat    `json:"recent_activity"`
 }
 
 type TeamMember struct {
-	ID            uint    `json:"id"`
-	Name          string  `json:"name"`
-	Title         string  `json:"title"`
-	IsClockedIn   bool    `json:"is_clocked_in"`
-	HoursToday    float64 `json:"hours_today"`
-	ActiveMinutes int     `json:"active_minutes_today"`
-	IdleMinutes   int     `json:"idle_minutes_today"`
-	ActiveTask    *string `json:"active_task"`
+	ID              uint     `json:"id"`
+	Name            string   `json:"name"`
+	Title           string   `json:"title"`
+	IsClockedIn     bool     `json:"is_clocked_in"`
+	HoursToday      float64  `json:"hours_today"`
+	ActiveMinutes   int      `json:"active_minutes_today"`
+	IdleMinutes     int      `json:"idle_minutes_today"`
+	ActiveTask      *string  `json:"active_task"`
+	AttendanceFlags []string `json:"attendance_flags"`  // today's late, early_departure, no_show, unscheduled
+	Holiday         string   `json:"holiday,omitempty"` // today is a holiday for them
+}
+
+// ─── Attendance DTOs ─────────────────────────────────────────
+
+// AttendanceRecord is one scheduled shift and how it was worked, or (with no
+// Shift) a session worked outside any shift
+type AttendanceRecord struct {
+	UserID           uint     `json:"user_id"`
+	UserName         string   `json:"user_name"`
+	Date             string   `json:"date"`
+	Shift            *Shift   `json:"shift"`
+	TimeEntryIDs     []uint   `json:"time_entry_ids"`
+	Flags            []string `json:"flags"`
+	LateMinutes      int      `json:"late_minutes"`
+	LeftEarlyMinutes int      `json:"left_early_minutes"`
+	Holiday          string   `json:"holiday,omitempty"`  // shift falls on a holiday; not a no-show
+	OnLeave          bool     `json:"on_leave,omitempty"` // approved leave that day; not a no-show
+}
+
+type AttendanceSummary struct {
+	Scheduled       int `json:"scheduled"`
+	Late            int `json:"late"`
+	EarlyDepartures int `json:"early_departures"`
+	NoShows         int `json:"no_shows"`
+	Unscheduled     int `json:"unscheduled"`
 }
 
 type ActivityStat struct {
@@ -261,9 +883,11 @@ type UserAppUsage struct {
 // ActivitySegment replaces raw heartbeat pings with proper time blocks
 type ActivitySegment struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
-	UserID       uint      `gorm:"not null;index" json:"user_id"`
+	OrgID        uint      `gorm:"not null;default:1;index" json:"org_id"`
+	UserID       uint      `gorm:"not null;index;index:idx_segment_user_start;uniqueIndex:idx_segment_user_client,where:client_id <> ''" json:"user_id"`
 	User         User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	StartTime    time.Time `gorm:"not null" json:"start_time"`
+	ClientID     string    `gorm:"size:64;uniqueIndex:idx_segment_user_client" json:"client_id,omitempty"` // agent-generated; retries with it update instead of adding
+	StartTime    time.Time `gorm:"not null;index:idx_segment_user_start" json:"start_time"`
 	EndTime      time.Time `gorm:"not null" json:"end_time"`
 	Duration     int       `json:"duration_seconds"`
 	SegmentType  string    `gorm:"not null" json:"segment_type"` // "active", "idle", "app_usage"
@@ -280,6 +904,7 @@ type ActivitySegment struct {
 // DailyAggregation pre-computed daily summary per user
 type DailyAggregation struct {
 	ID                 uint      `gorm:"primaryKey" json:"id"`
+	OrgID              uint      `gorm:"not null;default:1;index" json:"org_id"`
 	UserID             uint      `gorm:"not null;uniqueIndex:idx_user_date" json:"user_id"`
 	User               User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Date               string    `gorm:"not null;uniqueIndex:idx_user_date;size:10" json:"date"`
@@ -296,6 +921,7 @@ type DailyAggregation struct {
 // AuditLog tracks admin actions for privacy compliance
 type AuditLog struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrgID     uint      `gorm:"not null;default:1;index" json:"org_id"`
 	AdminID   uint      `gorm:"not null" json:"admin_id"`
 	Action    string    `gorm:"not null" json:"action"`
 	TargetID  uint      `json:"target_id"`
@@ -305,7 +931,19 @@ type AuditLog struct {
 
 // ─── Segment DTOs ────────────────────────────────────────────
 
+// SegmentBatch remembers the response to an agent batch sent with an
+// Idempotency-Key, so a retry of it is answered without ingesting again
+type SegmentBatch struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrgID          uint      `gorm:"not null;default:1;index" json:"org_id"`
+	UserID         uint      `gorm:"not null;uniqueIndex:idx_segment_batch_user_key" json:"user_id"`
+	IdempotencyKey string    `gorm:"not null;size:128;uniqueIndex:idx_segment_batch_user_key" json:"idempotency_key"`
+	Response       string    `gorm:"type:text" json:"-"` // JSON body sent back the first time
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+}
+
 type SegmentRequest struct {
+	ClientID     string `json:"client_id"` // optional unique ID from the agent
 	StartTime    string `json:"start_time"`
 	EndTime      string `json:"end_time"`
 	SegmentType  string `json:"segment_type"`
@@ -317,9 +955,36 @@ type SegmentRequest struct {
 	ScrollEvents int    `json:"scroll_events"`
 }
 
+type SegmentStatus string
+
+const (
+	SegmentAccepted  SegmentStatus = "accepted"
+	SegmentDuplicate SegmentStatus = "duplicate" // already stored; a client_id match is updated in place
+	SegmentRejected  SegmentStatus = "rejected"
+)
+
+// SegmentResult is the outcome for one segment of a batch, by its position
+type SegmentResult struct {
+	Index     int           `json:"index"`
+	ClientID  string        `json:"client_id,omitempty"`
+	Status    SegmentStatus `json:"status"`
+	SegmentID uint          `json:"segment_id,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+type SegmentBatchResponse struct {
+	Status     string          `json:"status"`
+	Received   int             `json:"received"` // accepted + duplicate
+	Accepted   int             `json:"accepted"`
+	Duplicates int             `json:"duplicates"`
+	Rejected   int             `json:"rejected"`
+	Results    []SegmentResult `json:"results"`
+}
+
 type TimelineResponse struct {
 	Segments    []ActivitySegment `json:"segments"`
 	Aggregation *DailyAggregation `json:"aggregation"`
+	Holiday     string            `json:"holiday,omitempty"` // the day's holiday name for this employee
 }
 
 // ─── Clock Session DTOs ──────────────────────────────────────
@@ -329,8 +994,42 @@ type AppDur struct {
 	Duration int    `json:"duration"`
 }
 
+type TimesheetDay struct {
+	Date         string `json:"date"`
+	Seconds      int64  `json:"seconds"`
+	Entries      int    `json:"entries"`
+	LeaveSeconds int64  `json:"leave_seconds"`
+	Holiday      string `json:"holiday,omitempty"`
+}
+
+type TimesheetTask struct {
+	TaskID  uint   `json:"task_id"`
+	Title   string `json:"title"`
+	Seconds int64  `json:"seconds"`
+}
+
+// HourBuckets splits worked seconds by pay rate under the org's overtime rules
+type HourBuckets struct {
+	RegularSeconds    int64 `json:"regular_seconds"`
+	OvertimeSeconds   int64 `json:"overtime_seconds"`
+	DoubleTimeSeconds int64 `json:"double_time_seconds"`
+}
+
+type TimesheetResponse struct {
+	HourBuckets
+	Timesheet Timesheet       `json:"timesheet"`
+	Days      []TimesheetDay  `json:"days"`
+	Tasks     []TimesheetTask `json:"tasks"`
+	Entries   []TimeEntry     `json:"entries"`
+	OpenEntry bool            `json:"open_entry"` // a session in the period is still running
+}
+
 type ClockSessionResponse struct {
+	HourBuckets
 	TimeEntry          TimeEntry         `json:"time_entry"`
+	WorkedSeconds      int64             `json:"worked_seconds"` // session length minus unpaid breaks
+	PaidBreakSeconds   int64             `json:"paid_break_seconds"`
+	UnpaidBreakSeconds int64             `json:"unpaid_break_seconds"`
 	TotalActiveSeconds int               `json:"total_active_seconds"`
 	TotalIdleSeconds   int               `json:"total_idle_seconds"`
 	TotalMouseClicks   int               `json:"total_mouse_clicks"`
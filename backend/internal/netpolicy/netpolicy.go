@@ -0,0 +1,48 @@
+// Package netpolicy matches client addresses against allowed network ranges
+// for clock-in restrictions.
+package netpolicy
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+var ErrInvalidRange = errors.New("not an IP address or CIDR range")
+
+// Normalize parses a CIDR range, or a single address taken as a one-address
+// range, and returns it in canonical form
+func Normalize(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return "", ErrInvalidRange
+		}
+		if ip.To4() != nil {
+			return ip.String() + "/32", nil
+		}
+		return ip.String() + "/128", nil
+	}
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return "", ErrInvalidRange
+	}
+	return network.String(), nil
+}
+
+// Allowed reports whether ip falls in any of ranges. Unparseable ranges or
+// addresses never match.
+func Allowed(ranges []string, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, r := range ranges {
+		_, network, err := net.ParseCIDR(r)
+		if err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package netpolicy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"10.0.0.5", "10.0.0.5/32"},
+		{"  10.0.0.5\t", "10.0.0.5/32"},
+		{"10.1.2.3/8", "10.0.0.0/8"},
+		{"192.168.1.0/24", "192.168.1.0/24"},
+		{"2001:db8::1", "2001:db8::1/128"},
+		{"2001:DB8:0:0::/32", "2001:db8::/32"},
+		{"::ffff:10.0.0.1", "10.0.0.1/32"},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "office", "10.0.0.256", "10.0.0.0/33", "10.0.0.0/", "2001:db8::/129"} {
+		if got, err := Normalize(in); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("Normalize(%q) = %q, %v, want ErrInvalidRange", in, got, err)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	ranges := []string{"not a range", "10.0.0.0/8", "192.168.1.5/32", "2001:db8::/32"}
+
+	tests := []struct {
+		ranges []string
+		ip     string
+		want   bool
+	}{
+		{ranges, "10.200.3.4", true},
+		{ranges, "11.0.0.1", false},
+		{ranges, "192.168.1.5", true},
+		{ranges, "192.168.1.6", false},
+		{ranges, "2001:db8::42", true},
+		{ranges, "2001:db9::1", false},
+		{ranges, "::ffff:10.1.1.1", true},
+		{ranges, "", false},
+		{ranges, "localhost", false},
+		{nil, "10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := Allowed(tt.ranges, tt.ip); got != tt.want {
+			t.Errorf("Allowed(%v, %q) = %v, want %v", tt.ranges, tt.ip, got, tt.want)
+		}
+	}
+}
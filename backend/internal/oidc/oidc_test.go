@@ -0,0 +1,196 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://idp.example.com"
+	testClientID = "teampulse"
+	testNonce    = "n-0S6_WzA2Mj"
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// sign issues an ID token; edit adjusts the default, valid claims
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, edit func(jwt.MapClaims)) string {
+	t.Helper()
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            testIssuer,
+		"aud":            testClientID,
+		"sub":            "user-123",
+		"email":          "ana@example.com",
+		"email_verified": true,
+		"nonce":          testNonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+	if edit != nil {
+		edit(claims)
+	}
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	otherKey := newRSAKey(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Provider{
+		cfg:         Config{Issuer: testIssuer, ClientID: testClientID},
+		keys:        map[string]interface{}{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey},
+		keysFetched: time.Now(),
+	}
+	ago := func(d time.Duration) int64 { return time.Now().Add(-d).Unix() }
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"RS256", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", nil), true},
+		{"ES256", sign(t, jwt.SigningMethodES256, ecKey, "ec", nil), true},
+		{"audience list", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { c["aud"] = []string{"other", testClientID} }), true},
+		{"expired within leeway", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { c["exp"] = ago(30 * time.Second) }), true},
+
+		{"wrong issuer", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }), false},
+		{"wrong audience", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { c["aud"] = "someone-else" }), false},
+		{"no audience", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { delete(c, "aud") }), false},
+		{"nonce mismatch", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { c["nonce"] = "replayed" }), false},
+		{"no nonce", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { delete(c, "nonce") }), false},
+		{"expired", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { c["exp"] = ago(2 * time.Minute) }), false},
+		{"no expiry", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { delete(c, "exp") }), false},
+		{"no subject", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", func(c jwt.MapClaims) { delete(c, "sub") }), false},
+		{"HS256 with a public key as secret", sign(t, jwt.SigningMethodHS256, rsaKey.PublicKey.N.Bytes(), "rsa", nil), false},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "rsa", nil), false},
+		{"RS384 is not allowed", sign(t, jwt.SigningMethodRS384, rsaKey, "rsa", nil), false},
+		{"signed by another key", sign(t, jwt.SigningMethodRS256, otherKey, "rsa", nil), false},
+		{"unknown key id", sign(t, jwt.SigningMethodRS256, rsaKey, "gone", nil), false},
+		{"garbage", "not.a.token", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := p.Verify(context.Background(), tt.token, testNonce)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if claims.Subject != "user-123" || claims.Email != "ana@example.com" || claims.EmailVerified == nil || !*claims.EmailVerified {
+					t.Errorf("Verify() claims = %+v", claims)
+				}
+			} else if err == nil {
+				t.Errorf("Verify() accepted the token")
+			}
+		})
+	}
+}
+
+// jwksServer serves key as an RS256 signing key with kid and counts fetches
+func jwksServer(t *testing.T, kid string, key *rsa.PrivateKey, fetches *int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kid": "enc", "kty": "RSA", "use": "enc", "n": "AQAB", "e": "AQAB"},
+			{
+				"kid": kid, "kty": "RSA", "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVerifyFetchesKeys(t *testing.T) {
+	key := newRSAKey(t)
+	fetches := 0
+	srv := jwksServer(t, "rotated", key, &fetches)
+	p := &Provider{cfg: Config{Issuer: testIssuer, ClientID: testClientID}, client: srv.Client(), jwksURI: srv.URL}
+
+	if _, err := p.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, key, "rotated", nil), testNonce); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if _, err := p.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, key, "rotated", nil), testNonce); err != nil {
+		t.Fatalf("Verify() with a cached key error = %v", err)
+	}
+	// An unknown kid right after a fetch is refused without hammering the provider
+	if _, err := p.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, key, "unknown", nil), testNonce); err == nil {
+		t.Error("Verify() accepted an unknown key id")
+	}
+	if fetches != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", fetches)
+	}
+	if _, ok := p.keys["enc"]; ok {
+		t.Error("encryption key was loaded as a signing key")
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	var srv *httptest.Server
+	issuer := ""
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"jwks_uri":               srv.URL + "/jwks",
+		})
+	}))
+	defer srv.Close()
+
+	issuer = srv.URL
+	p, err := Discover(context.Background(), Config{Issuer: srv.URL, ClientID: testClientID, RedirectURL: "https://app/cb", Scopes: []string{"openid", "email"}})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	u := p.AuthCodeURL("st", "nn", "ch")
+	for _, want := range []string{srv.URL + "/authorize?", "state=st", "nonce=nn", "code_challenge=ch", "code_challenge_method=S256", "scope=openid+email"} {
+		if !strings.Contains(u, want) {
+			t.Errorf("AuthCodeURL() = %s, missing %s", u, want)
+		}
+	}
+
+	issuer = "https://impostor.example.com"
+	if _, err := Discover(context.Background(), Config{Issuer: srv.URL}); err == nil {
+		t.Error("Discover() accepted metadata for another issuer")
+	}
+}
@@ -0,0 +1,78 @@
+// Package overtime splits worked time into regular, overtime and double-time
+// buckets under daily and weekly thresholds (e.g. over 8h a day or 40h a week
+// is overtime, over 12h a day is double time).
+package overtime
+
+import "time"
+
+// Rules are the thresholds in seconds; 0 turns a rule off
+type Rules struct {
+	DailyOvertime   int64
+	DailyDoubleTime int64
+	WeeklyOvertime  int64
+}
+
+// Work is worked seconds on one day (YYYY-MM-DD), e.g. a time entry or the
+// part of one that fell on that day
+type Work struct {
+	Date    string
+	Seconds int64
+}
+
+// Buckets is how one entry's seconds were classified
+type Buckets struct {
+	Regular    int64
+	Overtime   int64
+	DoubleTime int64
+}
+
+// WeekStart returns the Monday of date's workweek, as YYYY-MM-DD
+func WeekStart(date string) string {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	offset := (int(d.Weekday()) + 6) % 7 // days since Monday
+	return d.AddDate(0, 0, -offset).Format("2006-01-02")
+}
+
+// Classify buckets one user's work, which must be in chronological order.
+// Daily rules apply first; regular time past the weekly threshold then
+// becomes overtime. Weeks run Monday to Sunday, so pass the whole week up to
+// the entries you are interested in.
+func Classify(rules Rules, work []Work) []Buckets {
+	out := make([]Buckets, len(work))
+	dayTotal := map[string]int64{}
+	weekRegular := map[string]int64{}
+
+	for i, w := range work {
+		before := dayTotal[w.Date]
+		after := before + w.Seconds
+		dayTotal[w.Date] = after
+
+		var b Buckets
+		regularEnd, overtimeEnd := after, after
+		if rules.DailyOvertime > 0 {
+			regularEnd = min(after, rules.DailyOvertime)
+		}
+		if rules.DailyDoubleTime > 0 {
+			overtimeEnd = min(after, rules.DailyDoubleTime)
+			regularEnd = min(regularEnd, overtimeEnd)
+		}
+		b.Regular = max(0, regularEnd-before)
+		b.Overtime = max(0, overtimeEnd-max(before, regularEnd))
+		b.DoubleTime = w.Seconds - b.Regular - b.Overtime
+
+		if rules.WeeklyOvertime > 0 {
+			week := WeekStart(w.Date)
+			room := max(0, rules.WeeklyOvertime-weekRegular[week])
+			if b.Regular > room {
+				b.Overtime += b.Regular - room
+				b.Regular = room
+			}
+			weekRegular[week] += b.Regular
+		}
+		out[i] = b
+	}
+	return out
+}
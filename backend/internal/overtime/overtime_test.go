@@ -0,0 +1,128 @@
+package overtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+const h = 3600
+
+func TestWeekStart(t *testing.T) {
+	tests := map[string]string{
+		"2026-03-02": "2026-03-02", // Monday
+		"2026-03-05": "2026-03-02",
+		"2026-03-08": "2026-03-02", // Sunday ends the week
+		"2026-03-09": "2026-03-09",
+		"2026-01-01": "2025-12-29", // across the year
+		"not a date": "not a date",
+	}
+	for date, want := range tests {
+		if got := WeekStart(date); got != want {
+			t.Errorf("WeekStart(%q) = %q, want %q", date, got, want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	california := Rules{DailyOvertime: 8 * h, DailyDoubleTime: 12 * h, WeeklyOvertime: 40 * h}
+
+	tests := []struct {
+		name  string
+		rules Rules
+		work  []Work
+		want  []Buckets
+	}{
+		{
+			name:  "under every threshold",
+			rules: california,
+			work:  []Work{{"2026-03-02", 6 * h}},
+			want:  []Buckets{{Regular: 6 * h}},
+		},
+		{
+			name:  "daily overtime",
+			rules: california,
+			work:  []Work{{"2026-03-02", 10 * h}},
+			want:  []Buckets{{Regular: 8 * h, Overtime: 2 * h}},
+		},
+		{
+			name:  "daily double time",
+			rules: california,
+			work:  []Work{{"2026-03-02", 13 * h}},
+			want:  []Buckets{{Regular: 8 * h, Overtime: 4 * h, DoubleTime: 1 * h}},
+		},
+		{
+			name:  "entries in a day share its thresholds",
+			rules: california,
+			work:  []Work{{"2026-03-02", 5 * h}, {"2026-03-02", 5 * h}},
+			want:  []Buckets{{Regular: 5 * h}, {Regular: 3 * h, Overtime: 2 * h}},
+		},
+		{
+			name:  "entry starting past double time",
+			rules: california,
+			work:  []Work{{"2026-03-02", 12 * h}, {"2026-03-02", 1 * h}},
+			want:  []Buckets{{Regular: 8 * h, Overtime: 4 * h}, {DoubleTime: 1 * h}},
+		},
+		{
+			name:  "double time without daily overtime",
+			rules: Rules{DailyDoubleTime: 12 * h},
+			work:  []Work{{"2026-03-02", 13 * h}},
+			want:  []Buckets{{Regular: 12 * h, DoubleTime: 1 * h}},
+		},
+		{
+			name:  "sixth day past the weekly threshold",
+			rules: california,
+			work: []Work{
+				{"2026-03-02", 8 * h}, {"2026-03-03", 8 * h}, {"2026-03-04", 8 * h},
+				{"2026-03-05", 8 * h}, {"2026-03-06", 8 * h}, {"2026-03-07", 4 * h},
+			},
+			want: []Buckets{
+				{Regular: 8 * h}, {Regular: 8 * h}, {Regular: 8 * h},
+				{Regular: 8 * h}, {Regular: 8 * h}, {Overtime: 4 * h},
+			},
+		},
+		{
+			name:  "daily overtime doesn't count towards the weekly threshold",
+			rules: california,
+			work: []Work{
+				{"2026-03-02", 9 * h}, {"2026-03-03", 9 * h}, {"2026-03-04", 9 * h},
+				{"2026-03-05", 9 * h}, {"2026-03-06", 10 * h}, {"2026-03-07", 3 * h},
+			},
+			want: []Buckets{
+				{Regular: 8 * h, Overtime: 1 * h}, {Regular: 8 * h, Overtime: 1 * h}, {Regular: 8 * h, Overtime: 1 * h},
+				{Regular: 8 * h, Overtime: 1 * h}, {Regular: 8 * h, Overtime: 2 * h}, {Overtime: 3 * h},
+			},
+		},
+		{
+			name:  "weekly threshold resets on Monday",
+			rules: Rules{WeeklyOvertime: 10 * h},
+			work:  []Work{{"2026-03-07", 8 * h}, {"2026-03-08", 4 * h}, {"2026-03-09", 4 * h}},
+			want:  []Buckets{{Regular: 8 * h}, {Regular: 2 * h, Overtime: 2 * h}, {Regular: 4 * h}},
+		},
+		{
+			name:  "no rules",
+			rules: Rules{},
+			work:  []Work{{"2026-03-02", 16 * h}},
+			want:  []Buckets{{Regular: 16 * h}},
+		},
+		{
+			name:  "no work",
+			rules: california,
+			work:  nil,
+			want:  []Buckets{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.rules, tt.work)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Classify() = %+v, want %+v", got, tt.want)
+			}
+			for i, b := range got {
+				if sum := b.Regular + b.Overtime + b.DoubleTime; sum != tt.work[i].Seconds {
+					t.Errorf("entry %d buckets sum to %d, want %d", i, sum, tt.work[i].Seconds)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Package payperiod works out which pay period a day falls in under an org's
+// schedule: weekly or biweekly counted from an anchor date, semi-monthly (1st
+// to 15th and 16th to month end), or monthly from the anchor's day of month.
+package payperiod
+
+import "time"
+
+type Kind string
+
+const (
+	Weekly      Kind = "weekly"
+	Biweekly    Kind = "biweekly"
+	SemiMonthly Kind = "semimonthly"
+	Monthly     Kind = "monthly"
+)
+
+// DefaultAnchor is a Monday, so weekly periods run Monday to Sunday
+const DefaultAnchor = "2024-01-01"
+
+// MaxAnchorDay keeps monthly periods starting on a day every month has
+const MaxAnchorDay = 28
+
+// ValidKind reports whether kind is a known schedule
+func ValidKind(kind string) bool {
+	switch Kind(kind) {
+	case Weekly, Biweekly, SemiMonthly, Monthly:
+		return true
+	}
+	return false
+}
+
+// Schedule is a pay period kind and the first day of any one of its periods
+type Schedule struct {
+	Kind   Kind
+	Anchor time.Time
+}
+
+// Containing returns the first and last day (inclusive) of the period holding
+// date. Only date's calendar day matters; results are midnight UTC.
+func (s Schedule) Containing(date time.Time) (time.Time, time.Time) {
+	day := calendarDay(date)
+	anchor := calendarDay(s.Anchor)
+
+	switch s.Kind {
+	case SemiMonthly:
+		y, m, d := day.Date()
+		if d <= 15 {
+			return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC), time.Date(y, m, 15, 0, 0, 0, 0, time.UTC)
+		}
+		return time.Date(y, m, 16, 0, 0, 0, 0, time.UTC), time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC)
+
+	case Monthly:
+		y, m, d := day.Date()
+		startDay := min(anchor.Day(), MaxAnchorDay)
+		if d < startDay {
+			m--
+		}
+		start := time.Date(y, m, startDay, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+
+	default:
+		length := 7
+		if s.Kind == Biweekly {
+			length = 14
+		}
+		days := int(day.Sub(anchor).Hours() / 24)
+		offset := days % length
+		if offset < 0 {
+			offset += length
+		}
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, length-1)
+	}
+}
+
+func calendarDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,72 @@
+package payperiod
+
+import (
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+func TestContaining(t *testing.T) {
+	anchor := day(2024, 1, 1) // DefaultAnchor, a Monday
+
+	tests := []struct {
+		name       string
+		schedule   Schedule
+		date       time.Time
+		start, end time.Time
+	}{
+		{"weekly", Schedule{Weekly, anchor}, day(2026, 3, 4), day(2026, 3, 2), day(2026, 3, 8)},
+		{"weekly on the anchor", Schedule{Weekly, anchor}, day(2024, 1, 1), day(2024, 1, 1), day(2024, 1, 7)},
+		{"weekly before the anchor", Schedule{Weekly, anchor}, day(2023, 12, 31), day(2023, 12, 25), day(2023, 12, 31)},
+		{"empty kind is weekly", Schedule{"", anchor}, day(2026, 3, 8), day(2026, 3, 2), day(2026, 3, 8)},
+
+		{"biweekly last day", Schedule{Biweekly, anchor}, day(2024, 1, 14), day(2024, 1, 1), day(2024, 1, 14)},
+		{"biweekly next period", Schedule{Biweekly, anchor}, day(2024, 1, 15), day(2024, 1, 15), day(2024, 1, 28)},
+		{"biweekly day before the anchor", Schedule{Biweekly, anchor}, day(2023, 12, 31), day(2023, 12, 18), day(2023, 12, 31)},
+		{"biweekly periods before the anchor", Schedule{Biweekly, anchor}, day(2023, 12, 17), day(2023, 12, 4), day(2023, 12, 17)},
+
+		{"semimonthly first half", Schedule{SemiMonthly, anchor}, day(2026, 2, 15), day(2026, 2, 1), day(2026, 2, 15)},
+		{"semimonthly end of February", Schedule{SemiMonthly, anchor}, day(2026, 2, 16), day(2026, 2, 16), day(2026, 2, 28)},
+		{"semimonthly leap February", Schedule{SemiMonthly, anchor}, day(2024, 2, 20), day(2024, 2, 16), day(2024, 2, 29)},
+		{"semimonthly 31-day month", Schedule{SemiMonthly, anchor}, day(2026, 1, 31), day(2026, 1, 16), day(2026, 1, 31)},
+		{"semimonthly end of year", Schedule{SemiMonthly, anchor}, day(2026, 12, 20), day(2026, 12, 16), day(2026, 12, 31)},
+
+		{"monthly on the anchor day", Schedule{Monthly, day(2024, 1, 10)}, day(2026, 3, 10), day(2026, 3, 10), day(2026, 4, 9)},
+		{"monthly before the anchor day", Schedule{Monthly, day(2024, 1, 10)}, day(2026, 3, 9), day(2026, 2, 10), day(2026, 3, 9)},
+		{"monthly across the year", Schedule{Monthly, day(2024, 1, 10)}, day(2026, 1, 5), day(2025, 12, 10), day(2026, 1, 9)},
+		{"monthly date before the anchor", Schedule{Monthly, day(2024, 1, 10)}, day(2023, 6, 20), day(2023, 6, 10), day(2023, 7, 9)},
+		{"monthly anchor past the 28th", Schedule{Monthly, day(2024, 1, 31)}, day(2026, 2, 28), day(2026, 2, 28), day(2026, 3, 27)},
+		{"monthly anchor past the 28th, earlier day", Schedule{Monthly, day(2024, 1, 31)}, day(2026, 2, 27), day(2026, 1, 28), day(2026, 2, 27)},
+
+		{"only the calendar day counts", Schedule{Weekly, anchor}, time.Date(2026, 3, 8, 23, 30, 0, 0, time.FixedZone("", 5*3600)), day(2026, 3, 2), day(2026, 3, 8)},
+		{"anchor time of day is ignored", Schedule{Weekly, time.Date(2024, 1, 1, 18, 0, 0, 0, time.FixedZone("", -8*3600))}, day(2026, 3, 2), day(2026, 3, 2), day(2026, 3, 8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := tt.schedule.Containing(tt.date)
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("Containing(%s) = %s..%s, want %s..%s", tt.date.Format(time.DateOnly),
+					start.Format(time.DateOnly), end.Format(time.DateOnly),
+					tt.start.Format(time.DateOnly), tt.end.Format(time.DateOnly))
+			}
+			if start.Location() != time.UTC || end.Location() != time.UTC {
+				t.Errorf("Containing(%s) returned a non-UTC bound", tt.date)
+			}
+		})
+	}
+}
+
+func TestValidKind(t *testing.T) {
+	for _, k := range []string{"weekly", "biweekly", "semimonthly", "monthly"} {
+		if !ValidKind(k) {
+			t.Errorf("ValidKind(%q) = false", k)
+		}
+	}
+	for _, k := range []string{"", "Weekly", "fortnightly"} {
+		if ValidKind(k) {
+			t.Errorf("ValidKind(%q) = true", k)
+		}
+	}
+}
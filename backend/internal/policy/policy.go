@@ -0,0 +1,105 @@
+// Package policy defines the named permissions that gate TeamPulse's API and
+// the default grants of the built-in roles. Custom roles add permissions on
+// top of a user's built-in role.
+package policy
+
+import (
+	"sort"
+
+	"teampulse/internal/models"
+)
+
+type Permission string
+
+const (
+	EmployeeView   Permission = "employee:view"   // list users in scope
+	EmployeeManage Permission = "employee:manage" // create, edit, deactivate, reset credentials
+	DashboardView  Permission = "dashboard:view"
+	SessionView    Permission = "session:view"  // work sessions of others
+	TimelineView   Permission = "timeline:view" // activity segments of others
+	ActivityView   Permission = "activity:view" // activity stats, agent monitor, app usage
+	TaskWrite      Permission = "task:write"    // create/edit tasks assigned to others
+	TaskDelete     Permission = "task:delete"
+	KPIWrite       Permission = "kpi:write"
+	KPIDelete      Permission = "kpi:delete"
+	StandupDelete  Permission = "standup:delete"
+	TimeApprove    Permission = "time:approve"    // review time entry corrections
+	ScheduleManage Permission = "schedule:manage" // create shifts and shift templates for others
+	SettingsManage Permission = "settings:manage"
+	RoleManage     Permission = "role:manage"
+
+	// ScopeAll widens every permission above from the holder's reporting
+	// line to the whole organisation
+	ScopeAll Permission = "scope:all"
+)
+
+// All lists every known permission
+var All = []Permission{
+	EmployeeView, EmployeeManage, DashboardView, SessionView, TimelineView, ActivityView,
+	TaskWrite, TaskDelete, KPIWrite, KPIDelete, StandupDelete, TimeApprove, ScheduleManage, SettingsManage, RoleManage, ScopeAll,
+}
+
+// Builtin holds the grants of the fixed roles. Employees need no permission
+// for their own records; ownership is checked in the handlers.
+var Builtin = map[models.Role][]Permission{
+	models.RoleAdmin:    All,
+	models.RoleManager:  {EmployeeView, DashboardView, SessionView, TimelineView, ActivityView, TaskWrite, TaskDelete, KPIWrite, TimeApprove, ScheduleManage},
+	models.RoleEmployee: {},
+}
+
+// Set is a resolved permission set for one user
+type Set map[Permission]bool
+
+// Has reports whether every permission in perms is granted
+func (s Set) Has(perms ...Permission) bool {
+	for _, p := range perms {
+		if !s[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// Covers reports whether s grants everything other does
+func (s Set) Covers(other Set) bool {
+	for p, granted := range other {
+		if granted && !s[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns the granted permissions in a stable order
+func (s Set) List() []string {
+	out := make([]string, 0, len(s))
+	for p := range s {
+		out = append(out, string(p))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Resolve combines a built-in role with a custom role's extra permissions
+func Resolve(role models.Role, extra []string) Set {
+	set := Set{}
+	for _, p := range Builtin[role] {
+		set[p] = true
+	}
+	for _, p := range extra {
+		if Valid(p) {
+			set[Permission(p)] = true
+		}
+	}
+	return set
+}
+
+// Valid reports whether name is a known permission
+func Valid(name string) bool {
+	for _, p := range All {
+		if string(p) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package policy
+
+import "strings"
+
+// APIScope limits which endpoints a personal API key may call. A key never
+// grants more than its owner's permissions; scopes only narrow them.
+type APIScope string
+
+const (
+	ReportsRead    APIScope = "reports:read" // time entries, sessions, hours, timesheets, dashboard, activity, timelines, employees
+	TasksRead      APIScope = "tasks:read"   // tasks, KPIs and standups
+	TasksWrite     APIScope = "tasks:write"  // create/edit tasks and run task timers
+	KPIsWrite      APIScope = "kpis:write"
+	StandupsWrite  APIScope = "standups:write"
+	ClockWrite     APIScope = "clock:write" // clock in and out, breaks
+	EmployeesWrite APIScope = "employees:write"
+)
+
+// AllScopes lists every API key scope
+var AllScopes = []APIScope{ReportsRead, TasksRead, TasksWrite, KPIsWrite, StandupsWrite, ClockWrite, EmployeesWrite}
+
+type scopeRule struct {
+	write  bool     // any method on routes; otherwise GET only
+	routes []string // route prefixes as registered with echo
+	exact  []string // "METHOD /route" pairs, matched as is
+}
+
+var scopeRules = map[APIScope]scopeRule{
+	ReportsRead: {routes: []string{
+		"/api/clock", "/api/hours", "/api/timesheets", "/api/payroll/export", "/api/shifts", "/api/attendance",
+		"/api/dashboard", "/api/activity", "/api/aggregations", "/api/segments", "/api/employee", "/api/employees",
+		"/api/agent/monitor", "/api/agent/app-usage",
+	}},
+	TasksRead:      {routes: []string{"/api/tasks", "/api/kpis", "/api/standups"}},
+	TasksWrite:     {write: true, routes: []string{"/api/tasks"}},
+	KPIsWrite:      {write: true, routes: []string{"/api/kpis"}},
+	StandupsWrite:  {write: true, routes: []string{"/api/standups"}},
+	ClockWrite:     {write: true, routes: []string{"/api/clock/in", "/api/clock/out", "/api/clock/break"}},
+	EmployeesWrite: {exact: []string{"POST /api/employees", "PUT /api/employees/:id", "DELETE /api/employees/:id"}},
+}
+
+// keyDenied are routes no API key may call whatever its scopes: they mint,
+// list or revoke someone's credentials and sessions, or reset their MFA
+var keyDenied = []string{
+	"/api/employees/:id/setup-code", "/api/employees/:id/devices", "/api/employees/:id/api-keys",
+	"/api/employees/:id/logout-all", "/api/employees/:id/mfa",
+}
+
+// ValidScope reports whether name is a known API key scope
+func ValidScope(name string) bool {
+	_, ok := scopeRules[APIScope(name)]
+	return ok
+}
+
+// ScopesAllow reports whether any of scopes admits method on route (the
+// echo route pattern, e.g. /api/tasks/:id). Every key may call GET /api/auth/me.
+func ScopesAllow(scopes []string, method, route string) bool {
+	if method == "GET" && route == "/api/auth/me" {
+		return true
+	}
+	for _, prefix := range keyDenied {
+		if underRoute(route, prefix) {
+			return false
+		}
+	}
+	for _, name := range scopes {
+		rule, ok := scopeRules[APIScope(name)]
+		if !ok {
+			continue
+		}
+		for _, pair := range rule.exact {
+			if pair == method+" "+route {
+				return true
+			}
+		}
+		if !rule.write && method != "GET" {
+			continue
+		}
+		for _, prefix := range rule.routes {
+			if underRoute(route, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func underRoute(route, prefix string) bool {
+	return route == prefix || strings.HasPrefix(route, prefix+"/")
+}
@@ -0,0 +1,59 @@
+package policy
+
+import "testing"
+
+func TestScopesAllow(t *testing.T) {
+	all := make([]string, len(AllScopes))
+	for i, s := range AllScopes {
+		all[i] = string(s)
+	}
+	employees := []string{string(EmployeesWrite)}
+	reports := []string{string(ReportsRead)}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		method string
+		route  string
+		want   bool
+	}{
+		{"me needs no scope", nil, "GET", "/api/auth/me", true},
+		{"unknown scope", []string{"everything"}, "GET", "/api/tasks", false},
+
+		{"read scope reads", reports, "GET", "/api/clock/entries", true},
+		{"read scope reads sub-routes", reports, "GET", "/api/timesheets/:id", true},
+		{"read scope can't write", reports, "POST", "/api/clock/in", false},
+		{"prefix must end at a segment", []string{string(TasksRead)}, "GET", "/api/tasksets", false},
+		{"write scope", []string{string(TasksWrite)}, "PUT", "/api/tasks/:id", true},
+		{"clock scope", []string{string(ClockWrite)}, "POST", "/api/clock/break/start", true},
+		{"clock scope doesn't cover corrections", []string{string(ClockWrite)}, "POST", "/api/clock/entries/:id/corrections", false},
+
+		{"create employee", employees, "POST", "/api/employees", true},
+		{"edit employee", employees, "PUT", "/api/employees/:id", true},
+		{"deactivate employee", employees, "DELETE", "/api/employees/:id", true},
+		{"employees write is not read", employees, "GET", "/api/employees", false},
+		{"no other methods", employees, "PATCH", "/api/employees/:id", false},
+		{"setup code", employees, "POST", "/api/employees/:id/setup-code", false},
+		{"revoke device", employees, "DELETE", "/api/employees/:id/devices/:deviceId", false},
+		{"revoke api key", employees, "DELETE", "/api/employees/:id/api-keys/:keyId", false},
+		{"log out everywhere", employees, "POST", "/api/employees/:id/logout-all", false},
+		{"reset mfa", employees, "DELETE", "/api/employees/:id/mfa", false},
+
+		{"list employees", reports, "GET", "/api/employees", true},
+		{"list devices", all, "GET", "/api/employees/:id/devices", false},
+		{"list api keys", all, "GET", "/api/employees/:id/api-keys", false},
+		{"every scope still can't mint setup codes", all, "POST", "/api/employees/:id/setup-code", false},
+		{"every scope still can't reset mfa", all, "DELETE", "/api/employees/:id/mfa", false},
+		{"own keys", all, "POST", "/api/keys", false},
+		{"password", all, "POST", "/api/auth/password", false},
+		{"settings", all, "PUT", "/api/settings", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopesAllow(tt.scopes, tt.method, tt.route); got != tt.want {
+				t.Errorf("ScopesAllow(%v, %s, %s) = %v, want %v", tt.scopes, tt.method, tt.route, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Package rounding applies payroll rounding to clock punches: each punch
+// moves to a multiple of an interval counted from local midnight, to the
+// nearest one or always up or down, except that a punch within the grace
+// window of a boundary snaps to that boundary.
+package rounding
+
+import "time"
+
+type Mode string
+
+const (
+	Nearest Mode = "nearest"
+	Up      Mode = "up"
+	Down    Mode = "down"
+)
+
+func ValidMode(m string) bool {
+	return m == string(Nearest) || m == string(Up) || m == string(Down)
+}
+
+// Rule rounds one kind of punch. A zero Interval leaves punches as they are.
+type Rule struct {
+	Interval time.Duration
+	Mode     Mode
+	Grace    time.Duration
+}
+
+// Apply rounds t, placing interval boundaries from midnight in loc
+func (r Rule) Apply(t time.Time, loc *time.Location) time.Time {
+	if r.Interval <= 0 {
+		return t
+	}
+	local := t.In(loc)
+	y, m, d := local.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	since := local.Sub(midnight)
+	down := midnight.Add(since - since%r.Interval)
+	if down.Equal(local) {
+		return t
+	}
+	up := down.Add(r.Interval)
+
+	past, short := local.Sub(down), up.Sub(local)
+	switch {
+	case past <= r.Grace && past <= short:
+		return down.In(t.Location())
+	case short <= r.Grace:
+		return up.In(t.Location())
+	}
+
+	switch r.Mode {
+	case Up:
+		return up.In(t.Location())
+	case Down:
+		return down.In(t.Location())
+	}
+	if past < short {
+		return down.In(t.Location())
+	}
+	return up.In(t.Location())
+}
@@ -0,0 +1,73 @@
+package rounding
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestApply(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(h, m, s int) time.Time { return time.Date(2026, 3, 2, h, m, s, 0, time.UTC) }
+	quarter := 15 * time.Minute
+
+	tests := []struct {
+		name string
+		rule Rule
+		in   time.Time
+		loc  *time.Location
+		want time.Time
+	}{
+		{"no interval", Rule{Mode: Up}, at(9, 7, 0), time.UTC, at(9, 7, 0)},
+		{"on a boundary", Rule{Interval: quarter, Mode: Up}, at(9, 15, 0), time.UTC, at(9, 15, 0)},
+
+		{"nearest down", Rule{Interval: quarter, Mode: Nearest}, at(9, 7, 0), time.UTC, at(9, 0, 0)},
+		{"nearest up", Rule{Interval: quarter, Mode: Nearest}, at(9, 8, 0), time.UTC, at(9, 15, 0)},
+		{"nearest tie goes up", Rule{Interval: quarter, Mode: Nearest}, at(9, 7, 30), time.UTC, at(9, 15, 0)},
+		{"empty mode is nearest", Rule{Interval: quarter}, at(9, 2, 0), time.UTC, at(9, 0, 0)},
+		{"up", Rule{Interval: quarter, Mode: Up}, at(9, 1, 0), time.UTC, at(9, 15, 0)},
+		{"down", Rule{Interval: quarter, Mode: Down}, at(9, 14, 59), time.UTC, at(9, 0, 0)},
+
+		{"grace snaps up mode back", Rule{Interval: quarter, Mode: Up, Grace: 5 * time.Minute}, at(9, 3, 0), time.UTC, at(9, 0, 0)},
+		{"grace edge is inclusive", Rule{Interval: quarter, Mode: Up, Grace: 5 * time.Minute}, at(9, 5, 0), time.UTC, at(9, 0, 0)},
+		{"past grace uses the mode", Rule{Interval: quarter, Mode: Up, Grace: 5 * time.Minute}, at(9, 5, 1), time.UTC, at(9, 15, 0)},
+		{"grace snaps down mode forward", Rule{Interval: quarter, Mode: Down, Grace: 5 * time.Minute}, at(9, 12, 0), time.UTC, at(9, 15, 0)},
+		{"grace wider than half picks the closer boundary", Rule{Interval: quarter, Mode: Down, Grace: 10 * time.Minute}, at(9, 9, 0), time.UTC, at(9, 15, 0)},
+
+		{"up past midnight", Rule{Interval: quarter, Mode: Nearest}, at(23, 55, 0), time.UTC, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"boundaries follow the local day", Rule{Interval: time.Hour, Mode: Down}, time.Date(2026, 3, 2, 14, 50, 0, 0, time.UTC), ny, time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)},
+
+		// Spring forward: 02:00 EST jumps to 03:00 EDT on 8 March 2026
+		{"after spring forward", Rule{Interval: quarter, Mode: Nearest}, time.Date(2026, 3, 8, 3, 7, 0, 0, ny), ny, time.Date(2026, 3, 8, 3, 0, 0, 0, ny)},
+		// Fall back: 01:20 EST (06:20 UTC) is the second 01:20 of 1 November 2026
+		{"after fall back", Rule{Interval: time.Hour, Mode: Nearest}, time.Date(2026, 11, 1, 6, 20, 0, 0, time.UTC), ny, time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rule.Apply(tt.in, tt.loc)
+			if !got.Equal(tt.want) {
+				t.Errorf("Apply(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+			if got.Location() != tt.in.Location() {
+				t.Errorf("Apply(%s) returned location %s, want %s", tt.in, got.Location(), tt.in.Location())
+			}
+		})
+	}
+}
+
+func TestValidMode(t *testing.T) {
+	for _, m := range []string{"nearest", "up", "down"} {
+		if !ValidMode(m) {
+			t.Errorf("ValidMode(%q) = false", m)
+		}
+	}
+	for _, m := range []string{"", "Up", "ceil"} {
+		if ValidMode(m) {
+			t.Errorf("ValidMode(%q) = true", m)
+		}
+	}
+}
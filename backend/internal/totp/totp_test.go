@@ -0,0 +1,123 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed from RFC 6238 appendix B, base32 encoded
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+// RFC 6238 appendix B SHA1 vectors, cut to the last six of their eight digits
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},          // 94287082
+	{1111111109, "081804"},  // 07081804
+	{1111111111, "050471"},  // 14050471
+	{1234567890, "005924"},  // 89005924
+	{2000000000, "279037"},  // 69279037
+	{20000000000, "353130"}, // 65353130
+}
+
+func TestCode(t *testing.T) {
+	for _, v := range rfcVectors {
+		got, err := Code(rfcSecret, time.Unix(v.unix, 0))
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+		if got != v.code {
+			t.Errorf("Code(T=%d) = %s, want %s", v.unix, got, v.code)
+		}
+	}
+
+	if got, _ := Code(strings.ToLower(rfcSecret), time.Unix(59, 0)); got != "287082" {
+		t.Errorf("Code() with a lower-case secret = %s, want 287082", got)
+	}
+	if _, err := Code("not base32!", time.Unix(59, 0)); err == nil {
+		t.Error("Code() with a bad secret returned no error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code := func(s int64) string { return codeAt(mustKey(t), s) }
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		step   int64
+		ok     bool
+	}{
+		{"RFC vector", rfcSecret, "050471", step, true},
+		{"previous step", rfcSecret, code(step - 1), step - 1, true},
+		{"next step", rfcSecret, code(step + 1), step + 1, true},
+		{"two steps back", rfcSecret, code(step - 2), 0, false},
+		{"two steps ahead", rfcSecret, code(step + 2), 0, false},
+		{"spaces are ignored", rfcSecret, " 050 471 ", step, true},
+		{"lower-case secret", strings.ToLower(rfcSecret), "050471", step, true},
+		{"wrong code", rfcSecret, "123456", 0, false},
+		{"too short", rfcSecret, "50471", 0, false},
+		{"eight digits", rfcSecret, "14050471", 0, false},
+		{"bad secret", "not base32!", "050471", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(tt.secret, tt.code, now)
+			if step != tt.step || ok != tt.ok {
+				t.Errorf("Validate(%q) = %d, %v, want %d, %v", tt.code, step, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
+
+func mustKey(t *testing.T) []byte {
+	key, err := encoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestStep(t *testing.T) {
+	tests := map[int64]int64{0: 0, 29: 0, 30: 1, 59: 1, 1111111111: 37037037}
+	for unix, want := range tests {
+		if got := Step(time.Unix(unix, 0)); got != want {
+			t.Errorf("Step(%d) = %d, want %d", unix, got, want)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, b := GenerateSecret(), GenerateSecret()
+	if a == b {
+		t.Errorf("GenerateSecret() returned %s twice", a)
+	}
+	key, err := encoding.DecodeString(a)
+	if err != nil || len(key) != 20 {
+		t.Errorf("GenerateSecret() = %s, decodes to %d bytes, %v", a, len(key), err)
+	}
+}
+
+func TestURL(t *testing.T) {
+	u, err := url.Parse(URL("TeamPulse", "ana@example.com", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/TeamPulse:ana@example.com" {
+		t.Errorf("URL() = %s", u)
+	}
+	q := u.Query()
+	want := map[string]string{"secret": rfcSecret, "issuer": "TeamPulse", "algorithm": "SHA1", "digits": "6", "period": "30"}
+	for k, v := range want {
+		if q.Get(k) != v {
+			t.Errorf("URL() %s = %q, want %q", k, q.Get(k), v)
+		}
+	}
+}
@@ -1,4 +1,6 @@
 const fetch = require('node-fetch');
+const os = require('os');
+const crypto = require('crypto');
 
 class ApiClient {
   constructor(store) {
@@ -10,8 +12,8 @@ class ApiClient {
     return this.store.get('token');
   }
 
-  async request(method, path, body = null) {
-    const headers = { 'Content-Type': 'application/json' };
+  async request(method, path, body = null, retried = false, extraHeaders = {}) {
+    const headers = { 'Content-Type': 'application/json', ...extraHeaders };
     if (this.token) headers['Authorization'] = `Bearer ${this.token}`;
 
     const opts = { method, headers };
@@ -20,23 +22,98 @@ class ApiClient {
     const res = await fetch(`${this.baseUrl}${path}`, opts);
     const data = await res.json();
 
+    // Access tokens are short-lived — renew once and retry
+    if (res.status === 401 && !retried && this.store.get('refreshToken')) {
+      await this.refreshAccessToken();
+      return this.request(method, path, body, true, extraHeaders);
+    }
+
     if (!res.ok) throw new Error(data.error || 'Request failed');
     return data;
   }
 
+  saveDeviceCredentials(data) {
+    this.store.set('token', data.token);
+    this.store.set('refreshToken', data.refresh_token);
+    this.store.set('deviceId', data.device_id);
+    this.store.set('user', data.user);
+  }
+
+  // Email/password sign-ins hold a web session rather than device credentials
+  saveSession(data) {
+    this.store.set('token', data.token);
+    this.store.set('refreshToken', data.refresh_token);
+    this.store.delete('deviceId');
+    this.store.set('user', data.user);
+  }
+
+  async refreshAccessToken() {
+    return this.store.get('deviceId') ? this.refreshDeviceToken() : this.refreshSession();
+  }
+
+  async refreshSession() {
+    // Session refresh secrets rotate on every use as well
+    const res = await fetch(`${this.baseUrl}/auth/refresh`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refresh_token: this.store.get('refreshToken') }),
+    });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || 'Session expired');
+    this.saveSession(data);
+    return data;
+  }
+
+  async refreshDeviceToken() {
+    // Refresh secrets rotate on every use, so persist the new one immediately
+    const res = await fetch(`${this.baseUrl}/agent/token`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({
+        device_id: this.store.get('deviceId'),
+        refresh_token: this.store.get('refreshToken'),
+      }),
+    });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || 'Device credentials rejected');
+    this.saveDeviceCredentials(data);
+    return data;
+  }
+
   async login(email, password) {
-    return this.request('POST', '/auth/login', { email, password });
+    // MFA accounts get { mfa_required, mfa_token } instead of a session
+    const data = await this.postPublic('/auth/login', { email, password }, 'Login failed');
+    if (!data.mfa_required) this.saveSession(data);
+    return data;
+  }
+
+  async verifyMFA(mfaToken, code) {
+    const data = await this.postPublic('/auth/mfa/verify', { mfa_token: mfaToken, code }, 'Invalid code');
+    this.saveSession(data);
+    return data;
+  }
+
+  async postPublic(path, body, fallbackError) {
+    const res = await fetch(`${this.baseUrl}${path}`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(body),
+    });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || fallbackError);
+    return data;
   }
 
   async authWithCode(code) {
-    // Exchange a 6-char setup code for a JWT — no email/password needed
+    // Exchange a 6-char setup code for device credentials — no email/password needed
     const res = await fetch(`${this.baseUrl}/agent/auth-code`, {
       method: 'POST',
       headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ code }),
+      body: JSON.stringify({ code, device_name: os.hostname(), platform: process.platform }),
     });
     const data = await res.json();
     if (!res.ok) throw new Error(data.error || 'Invalid code');
+    this.saveDeviceCredentials(data);
     return data;
   }
 
@@ -48,8 +125,17 @@ class ApiClient {
     return this.request('POST', '/agent/heartbeat', data);
   }
 
+  // The Idempotency-Key is derived from the segments' client_ids, so a retry
+  // of the same batch carries the same key. Segments queued before client_ids
+  // existed are sent without one and deduplicated by the server.
   async sendSegments(segments) {
-    return this.request('POST', '/agent/segments', segments);
+    const headers = {};
+    if (segments.length > 0 && segments.every(s => s.client_id)) {
+      headers['Idempotency-Key'] = crypto.createHash('sha256')
+        .update(segments.map(s => s.client_id).join(','))
+        .digest('hex');
+    }
+    return this.request('POST', '/agent/segments', segments, false, headers);
   }
 
 }
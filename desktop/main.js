@@ -121,16 +121,28 @@ function updateTrayMenu() {
 
 async function login(email, password) {
   const result = await apiClient.login(email, password);
-  store.set('token', result.token);
-  store.set('user', result.user);
+  // MFA accounts come back with a challenge; the window asks for the code
+  if (result.mfa_required) return result;
+  onSignedIn();
+  return result;
+}
+
+async function verifyMFA(mfaToken, code) {
+  const result = await apiClient.verifyMFA(mfaToken, code);
+  onSignedIn();
+  return result;
+}
+
+function onSignedIn() {
   updateTrayMenu();
   startClockCheck();
-  return result;
 }
 
 function logout() {
   stopTracking();
   store.delete('token');
+  store.delete('refreshToken');
+  store.delete('deviceId');
   store.delete('user');
   isClockedIn = false;
   updateTrayMenu();
@@ -272,6 +284,10 @@ ipcMain.handle('login', async (_event, { email, password }) => {
   return login(email, password);
 });
 
+ipcMain.handle('verify-mfa', async (_event, { mfaToken, code }) => {
+  return verifyMFA(mfaToken, code);
+});
+
 ipcMain.handle('set-api-url', async (_event, { url }) => {
   // Normalize: strip trailing slashes and /api suffix, then re-add /api
   let clean = url.trim().replace(/\/+$/, '').replace(/\/api$/i, '');
@@ -288,8 +304,6 @@ ipcMain.handle('get-api-url', async () => {
 
 ipcMain.handle('auth-code', async (_event, { code }) => {
   const result = await apiClient.authWithCode(code);
-  store.set('token', result.token);
-  store.set('user', result.user);
   updateTrayMenu();
   startClockCheck();
   if (mainWindow) mainWindow.hide();
@@ -4,6 +4,7 @@ contextBridge.exposeInMainWorld('teampulse', {
   setApiUrl: (url) => ipcRenderer.invoke('set-api-url', { url }),
   getApiUrl: () => ipcRenderer.invoke('get-api-url'),
   login: (email, password) => ipcRenderer.invoke('login', { email, password }),
+  verifyMFA: (mfaToken, code) => ipcRenderer.invoke('verify-mfa', { mfaToken, code }),
   authWithCode: (code) => ipcRenderer.invoke('auth-code', { code }),
   logout: () => ipcRenderer.invoke('logout'),
   getStatus: () => ipcRenderer.invoke('get-status'),
@@ -13,6 +13,9 @@ const codeBtn = document.getElementById('code-btn');
 const emailInput = document.getElementById('email');
 const passwordInput = document.getElementById('password');
 const loginBtn = document.getElementById('login-btn');
+const mfaForm = document.getElementById('mfa-form');
+const mfaCodeInput = document.getElementById('mfa-code');
+const mfaBtn = document.getElementById('mfa-btn');
 const errorEl = document.getElementById('error');
 const urlErrorEl = document.getElementById('url-error');
 const userNameEl = document.getElementById('user-name');
@@ -89,6 +92,7 @@ backBtn.addEventListener('click', () => {
 
 window.switchTab = function(tab) {
   errorEl.textContent = '';
+  hideMFA();
   if (tab === 'code') {
     codeForm.classList.remove('hidden');
     emailForm.classList.add('hidden');
@@ -180,7 +184,11 @@ loginBtn.addEventListener('click', async () => {
 
   try {
     const result = await window.teampulse.login(email, password);
-    showStatus(result.user, false);
+    if (result.mfa_required) {
+      showMFA(result.mfa_token);
+    } else {
+      showStatus(result.user, false);
+    }
   } catch (err) {
     errorEl.textContent = err.message || 'Login failed. Check your email and password.';
   }
@@ -193,6 +201,51 @@ passwordInput.addEventListener('keydown', (e) => {
   if (e.key === 'Enter') loginBtn.click();
 });
 
+// ─── Two-Factor Code ────────────────────────────────────────
+
+let mfaToken = null;
+
+function showMFA(token) {
+  mfaToken = token;
+  emailForm.classList.add('hidden');
+  mfaForm.classList.remove('hidden');
+  mfaCodeInput.value = '';
+  mfaCodeInput.focus();
+}
+
+function hideMFA() {
+  mfaToken = null;
+  mfaForm.classList.add('hidden');
+}
+
+mfaBtn.addEventListener('click', async () => {
+  errorEl.textContent = '';
+
+  const code = mfaCodeInput.value.trim();
+  if (!code) {
+    errorEl.textContent = 'Please enter your code.';
+    return;
+  }
+
+  mfaBtn.disabled = true;
+  mfaBtn.textContent = 'Verifying...';
+
+  try {
+    const result = await window.teampulse.verifyMFA(mfaToken, code);
+    hideMFA();
+    showStatus(result.user, false);
+  } catch (err) {
+    errorEl.textContent = err.message || 'Invalid code.';
+  }
+
+  mfaBtn.disabled = false;
+  mfaBtn.textContent = 'Verify';
+});
+
+mfaCodeInput.addEventListener('keydown', (e) => {
+  if (e.key === 'Enter') mfaBtn.click();
+});
+
 // ─── Logout ─────────────────────────────────────────────────
 
 logoutBtn.addEventListener('click', async () => {
@@ -200,6 +253,7 @@ logoutBtn.addEventListener('click', async () => {
   setupCodeInput.value = '';
   emailInput.value = '';
   passwordInput.value = '';
+  switchTab('code');
   showLogin();
   showStep(1);
 });
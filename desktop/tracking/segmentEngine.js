@@ -7,6 +7,8 @@
  * Idle threshold: 120 seconds of no input → close active segment, open idle segment
  */
 
+const crypto = require('crypto');
+
 const IDLE_THRESHOLD_MS = 120_000; // 2 minutes
 
 class SegmentEngine {
@@ -116,7 +118,10 @@ class SegmentEngine {
       }
     }
 
+    // client_id lets the server recognise a segment it already has when a
+    // batch is retried
     const segments = this._completedSegments.map(seg => ({
+      client_id: crypto.randomUUID(),
       start_time: new Date(seg.startTime).toISOString(),
       end_time: new Date(seg.endTime).toISOString(),
       segment_type: seg.segmentType,
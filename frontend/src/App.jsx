@@ -3,6 +3,8 @@ import LoginPage from './pages/LoginPage';
 import AdminView from './pages/AdminView';
 import EmployeeView from './pages/EmployeeView';
 import EmployeeDetail from './pages/EmployeeDetail';
+import PasswordPage from './pages/PasswordPage';
+import MFASetupPage from './pages/MFASetupPage';
 import { api } from './hooks/api';
 import { colors, Logo, Sidebar } from './components/UI';
 
@@ -17,6 +19,18 @@ const adminNavItems = [
   { key: 'monitoring', label: 'Monitoring' },
 ];
 
+// Managers (or custom roles granting dashboard:view) get the team views,
+// scoped server-side to their reporting line
+const managerNavItems = [
+  { key: 'clock', label: 'Time Clock' },
+  { key: 'dashboard', label: 'My Team' },
+  { key: 'timeline', label: 'Timeline' },
+  { key: 'sessions', label: 'Work Sessions' },
+  { key: 'tasks', label: 'Tasks' },
+  { key: 'kpis', label: 'KPIs' },
+  { key: 'feedback', label: 'Daily Feedback' },
+];
+
 const employeeNavItems = [
   { key: 'clock', label: 'Time Clock' },
   { key: 'sessions', label: 'My Sessions' },
@@ -30,6 +44,15 @@ export default function App() {
   const [loading, setLoading] = useState(true);
   const [section, setSection] = useState(null);
   const [employeeDetailId, setEmployeeDetailId] = useState(null);
+  const [resetToken, setResetToken] = useState(() => new URLSearchParams(window.location.search).get('reset_token'));
+  const [sso] = useState(() => {
+    // The ticket comes in the fragment so it stays out of server logs and Referer headers
+    const ticket = new URLSearchParams(window.location.hash.slice(1)).get('sso_ticket');
+    const error = new URLSearchParams(window.location.search).get('sso_error');
+    if (ticket || error) window.history.replaceState(null, '', window.location.pathname);
+    return { ticket, error };
+  });
+  const [forgotPassword, setForgotPassword] = useState(false);
 
   useEffect(() => {
     // Verify token on mount
@@ -48,7 +71,7 @@ export default function App() {
   // Set default section based on role
   useEffect(() => {
     if (user && !section) {
-      setSection(user.role === 'admin' ? 'dashboard' : 'clock');
+      setSection(user.role === 'employee' ? 'clock' : 'dashboard');
     }
   }, [user, section]);
 
@@ -76,12 +99,37 @@ export default function App() {
     );
   }
 
+  if (resetToken) {
+    return (
+      <PasswordPage mode="reset" resetToken={resetToken} onDone={() => {
+        window.history.replaceState(null, '', window.location.pathname);
+        setResetToken(null);
+      }} />
+    );
+  }
+
   if (!user) {
-    return <LoginPage onLogin={setUser} />;
+    if (forgotPassword) {
+      return <PasswordPage mode="forgot" onDone={() => setForgotPassword(false)} />;
+    }
+    return (
+      <LoginPage onLogin={setUser} onForgotPassword={() => setForgotPassword(true)}
+        ssoTicket={sso.ticket} ssoError={sso.error} />
+    );
+  }
+
+  if (user.must_change_password) {
+    return <PasswordPage mode="change" onDone={setUser} />;
+  }
+
+  if (user.mfa_enrollment_required) {
+    return <MFASetupPage user={user} onDone={setUser} onLogout={handleLogout} />;
   }
 
   const isAdmin = user.role === 'admin';
-  const navItems = isAdmin ? adminNavItems : employeeNavItems;
+  const isManager = user.role === 'manager' || (user.permissions || []).includes('dashboard:view');
+  const navItems = isAdmin ? adminNavItems : isManager ? managerNavItems : employeeNavItems;
+  const teamView = isAdmin || (isManager && section !== 'clock');
 
   return (
     <div style={{ minHeight: '100vh', background: colors.bg, color: colors.text, fontFamily: "'Inter', system-ui, sans-serif", display: 'flex' }}>
@@ -102,7 +150,7 @@ export default function App() {
               employeeId={employeeDetailId}
               onBack={() => setEmployeeDetailId(null)}
             />
-          ) : isAdmin ? (
+          ) : teamView ? (
             <AdminView section={section} onViewEmployee={handleViewEmployee} />
           ) : (
             <EmployeeView section={section} />
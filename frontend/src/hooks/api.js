@@ -16,15 +16,24 @@ class ApiClient {
     this.listeners.forEach(fn => fn({ token: this.token, user: this.user }));
   }
 
-  setAuth(token, user) {
+  setAuth(token, user, refreshToken) {
     this.token = token;
     this.user = user;
     localStorage.setItem('tp_token', token);
     localStorage.setItem('tp_user', JSON.stringify(user));
+    if (refreshToken) localStorage.setItem('tp_refresh', refreshToken);
     this.notify();
   }
 
   logout() {
+    // Revoke the server-side session; don't wait on it
+    if (this.token) {
+      fetch(`${API_BASE}/auth/logout`, {
+        method: 'POST',
+        headers: { 'Authorization': `Bearer ${this.token}` },
+      }).catch(() => {});
+    }
+    localStorage.removeItem('tp_refresh');
     this.token = null;
     this.user = null;
     localStorage.removeItem('tp_token');
@@ -40,7 +49,28 @@ class ApiClient {
     return this.user?.role === 'admin';
   }
 
-  async request(method, path, body = null) {
+  // Access tokens are short-lived; trade the refresh secret for a new one.
+  // Concurrent callers share one in-flight refresh since the secret rotates.
+  refresh() {
+    if (!this.refreshing) {
+      this.refreshing = fetch(`${API_BASE}/auth/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: localStorage.getItem('tp_refresh') }),
+      })
+        .then(async res => {
+          if (!res.ok) return false;
+          const data = await res.json();
+          this.setAuth(data.token, data.user, data.refresh_token);
+          return true;
+        })
+        .catch(() => false)
+        .finally(() => { this.refreshing = null; });
+    }
+    return this.refreshing;
+  }
+
+  async request(method, path, body = null, retried = false) {
     const headers = { 'Content-Type': 'application/json' };
     if (this.token) headers['Authorization'] = `Bearer ${this.token}`;
 
@@ -50,6 +80,9 @@ class ApiClient {
     const res = await fetch(`${API_BASE}${path}`, opts);
 
     if (res.status === 401) {
+      if (!retried && this.token && localStorage.getItem('tp_refresh') && await this.refresh()) {
+        return this.request(method, path, body, true);
+      }
       this.logout();
       throw new Error('Session expired');
     }
@@ -62,6 +95,22 @@ class ApiClient {
   // Auth
   login(email, password) { return this.request('POST', '/auth/login', { email, password }); }
   getMe() { return this.request('GET', '/auth/me'); }
+  getSSOConfig() { return fetch('/api/auth/oidc/config').then(r => r.json()); }
+  exchangeSSOTicket(ticket) { return this.request('POST', '/auth/oidc/exchange', { ticket }); }
+  verifyMFA(mfaToken, code) { return this.request('POST', '/auth/mfa/verify', { mfa_token: mfaToken, code }); }
+  enrollMFA() { return this.request('POST', '/auth/mfa/enroll'); }
+  activateMFA(code) { return this.request('POST', '/auth/mfa/activate', { code }); }
+  disableMFA(code) { return this.request('POST', '/auth/mfa/disable', { code }); }
+  logoutEverywhere() { return this.request('POST', '/auth/logout-all'); }
+  changePassword(currentPassword, newPassword) {
+    return this.request('POST', '/auth/password', { current_password: currentPassword, new_password: newPassword });
+  }
+  forgotPassword(email) { return this.request('POST', '/auth/forgot-password', { email }); }
+  resetPassword(token, newPassword) { return this.request('POST', '/auth/reset-password', { token, new_password: newPassword }); }
+  listAPIKeys() { return this.request('GET', '/keys'); }
+  createAPIKey(data) { return this.request('POST', '/keys', data); }
+  revokeAPIKey(id) { return this.request('DELETE', `/keys/${id}`); }
+  setMyPIN(pin) { return this.request('POST', '/auth/pin', { pin }); }
 
   // Employees (admin)
   listEmployees() { return this.request('GET', '/employees'); }
@@ -69,10 +118,29 @@ class ApiClient {
   updateEmployee(id, data) { return this.request('PUT', `/employees/${id}`, data); }
   deleteEmployee(id) { return this.request('DELETE', `/employees/${id}`); }
   hardDeleteEmployee(id) { return this.request('DELETE', `/employees/${id}?hard=true`); }
+  resetEmployeeMFA(id) { return this.request('DELETE', `/employees/${id}/mfa`); }
+  logoutEmployeeEverywhere(id) { return this.request('POST', `/employees/${id}/logout-all`); }
+  listEmployeeAPIKeys(id) { return this.request('GET', `/employees/${id}/api-keys`); }
+  revokeEmployeeAPIKey(id, keyId) { return this.request('DELETE', `/employees/${id}/api-keys/${keyId}`); }
 
   // Clock
   clockIn() { return this.request('POST', '/clock/in'); }
   clockOut() { return this.request('POST', '/clock/out'); }
+  startBreak(type = 'unpaid') { return this.request('POST', '/clock/break/start', { type }); }
+  endBreak() { return this.request('POST', '/clock/break/end'); }
+  requestCorrection(entryId, data) { return this.request('POST', `/clock/entries/${entryId}/corrections`, data); }
+  getEntryRevisions(entryId) { return this.request('GET', `/clock/entries/${entryId}/revisions`); }
+  listMyCorrections() { return this.request('GET', '/clock/corrections/me'); }
+  getMyTimesheet(date) { return this.request('GET', `/timesheets/me${date ? `?date=${date}` : ''}`); }
+  getTimesheet(id) { return this.request('GET', `/timesheets/${id}`); }
+  submitTimesheet(id) { return this.request('POST', `/timesheets/${id}/submit`); }
+  listTimesheets(status = 'submitted') { return this.request('GET', `/timesheets?status=${status}`); }
+  approveTimesheet(id, note) { return this.request('POST', `/timesheets/${id}/approve`, { note }); }
+  rejectTimesheet(id, note) { return this.request('POST', `/timesheets/${id}/reject`, { note }); }
+  reopenTimesheet(id, note) { return this.request('POST', `/timesheets/${id}/reopen`, { note }); }
+  listCorrections(status = 'pending') { return this.request('GET', `/corrections?status=${status}`); }
+  approveCorrection(id, note) { return this.request('POST', `/corrections/${id}/approve`, { note }); }
+  rejectCorrection(id, note) { return this.request('POST', `/corrections/${id}/reject`, { note }); }
   getClockStatus() { return this.request('GET', '/clock/status'); }
   getTimeEntries(date) { return this.request('GET', `/clock/entries${date ? `?date=${date}` : ''}`); }
   getClockSessions(date) { return this.request('GET', `/clock/sessions${date ? `?date=${date}` : ''}`); }
@@ -111,6 +179,23 @@ class ApiClient {
   createStandup(data) { return this.request('POST', '/standups', data); }
   deleteStandup(id) { return this.request('DELETE', `/standups/${id}`); }
 
+  // Org settings (admin)
+  getSettings() { return this.request('GET', '/settings'); }
+  updateSettings(data) { return this.request('PUT', '/settings', data); }
+  getMyOrganization() { return this.request('GET', '/org'); }
+  listOrganizations() { return this.request('GET', '/orgs'); }
+  createOrganization(data) { return this.request('POST', '/orgs', data); }
+  updateOrganization(id, data) { return this.request('PUT', `/orgs/${id}`, data); }
+  listRoles() { return this.request('GET', '/roles'); }
+  createRole(data) { return this.request('POST', '/roles', data); }
+  updateRole(id, data) { return this.request('PUT', `/roles/${id}`, data); }
+  deleteRole(id) { return this.request('DELETE', `/roles/${id}`); }
+  listKiosks() { return this.request('GET', '/kiosks'); }
+  createKiosk(name) { return this.request('POST', '/kiosks', { name }); }
+  revokeKiosk(id) { return this.request('DELETE', `/kiosks/${id}`); }
+  generateSCIMToken() { return this.request('POST', '/settings/scim-token'); }
+  revokeSCIMToken() { return this.request('DELETE', '/settings/scim-token'); }
+
   // Dashboard (admin)
   getDashboard() { return this.request('GET', '/dashboard'); }
 
@@ -36,7 +36,7 @@ export default function AdminView({ section = 'dashboard', onViewEmployee }) {
   const [showAddKPI, setShowAddKPI] = useState(false);
   const [showSetupCode, setShowSetupCode] = useState(false);
   const [setupCodeData, setSetupCodeData] = useState(null);
-  const [empForm, setEmpForm] = useState({ name: '', email: '', password: '', title: '', role: 'employee' });
+  const [empForm, setEmpForm] = useState({ name: '', email: '', password: '', title: '', role: 'employee', manager_id: '' });
   const [taskForm, setTaskForm] = useState({ title: '', description: '', assignee_id: null, priority: 'medium', due_date: '' });
   const [kpiForm, setKpiForm] = useState({ user_id: null, metric: '', target: 0, current: 0, unit: '' });
 
@@ -130,8 +130,8 @@ export default function AdminView({ section = 'dashboard', onViewEmployee }) {
 
   const addEmployee = async () => {
     try {
-      await api.createEmployee(empForm);
-      setEmpForm({ name: '', email: '', password: '', title: '', role: 'employee' });
+      await api.createEmployee({ ...empForm, manager_id: empForm.manager_id ? Number(empForm.manager_id) : null });
+      setEmpForm({ name: '', email: '', password: '', title: '', role: 'employee', manager_id: '' });
       setShowAddEmployee(false);
       refresh();
     } catch (err) {
@@ -1037,8 +1037,13 @@ export default function AdminView({ section = 'dashboard', onViewEmployee }) {
           <Input label="Job Title" value={empForm.title} onChange={e => setEmpForm({ ...empForm, title: e.target.value })} placeholder="Developer" />
           <Input label="Role" type="select" value={empForm.role} onChange={e => setEmpForm({ ...empForm, role: e.target.value })}>
             <option value="employee">Employee</option>
+            <option value="manager">Manager</option>
             <option value="admin">Admin</option>
           </Input>
+          <Input label="Reports To" type="select" value={empForm.manager_id} onChange={e => setEmpForm({ ...empForm, manager_id: e.target.value })}>
+            <option value="">No manager</option>
+            {employees.filter(e => e.role !== 'employee').map(e => <option key={e.id} value={e.id}>{e.name}</option>)}
+          </Input>
           <div style={{ display: 'flex', gap: '8px', justifyContent: 'flex-end' }}>
             <Btn variant="secondary" onClick={() => setShowAddEmployee(false)}>Cancel</Btn>
             <Btn onClick={addEmployee}>Add Employee</Btn>
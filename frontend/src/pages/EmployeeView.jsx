@@ -170,6 +170,15 @@ export default function EmployeeView({ section }) {
     refresh();
   };
 
+  const handleBreak = async () => {
+    if (clockStatus.on_break) {
+      await api.endBreak();
+    } else {
+      await api.startBreak('unpaid');
+    }
+    refresh();
+  };
+
   const handleTaskTimer = async (taskId) => {
     if (activeTimer?.active && activeTimer.task_time.task_id === taskId) {
       await api.stopTaskTimer();
@@ -284,7 +293,7 @@ export default function EmployeeView({ section }) {
                 fontSize: '12px', color: clockStatus.clocked_in ? colors.green : colors.textDim,
                 marginBottom: '12px', textTransform: 'uppercase', letterSpacing: '2px', fontWeight: 700,
               }}>
-                {clockStatus.clocked_in ? '● Session Active' : 'Ready to Clock In'}
+                {clockStatus.on_break ? '☕ On Break' : clockStatus.clocked_in ? '● Session Active' : 'Ready to Clock In'}
               </div>
               <div style={{
                 fontSize: '56px', fontWeight: 800, fontVariantNumeric: 'tabular-nums', marginBottom: '28px', lineHeight: 1,
@@ -301,6 +310,15 @@ export default function EmployeeView({ section }) {
               >
                 {clockStatus.clocked_in ? '⏹ Clock Out' : '▶ Clock In'}
               </Btn>
+              {clockStatus.clocked_in && (
+                <Btn
+                  variant="secondary"
+                  onClick={handleBreak}
+                  style={{ marginLeft: '12px', padding: '16px 28px', fontSize: '16px', fontWeight: 700, borderRadius: '12px' }}
+                >
+                  {clockStatus.on_break ? '↩ End Break' : '☕ Break'}
+                </Btn>
+              )}
             </div>
           </Card>
 
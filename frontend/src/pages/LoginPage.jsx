@@ -1,21 +1,46 @@
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
 import { Btn, Input, Logo, colors } from '../components/UI';
 import { api } from '../hooks/api';
 
-export default function LoginPage({ onLogin }) {
+export default function LoginPage({ onLogin, onForgotPassword, ssoTicket, ssoError }) {
   const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
-  const [error, setError] = useState('');
+  const [mfaToken, setMfaToken] = useState(null);
+  const [code, setCode] = useState('');
+  const [error, setError] = useState(ssoError || '');
   const [loading, setLoading] = useState(false);
+  const [ssoEnabled, setSsoEnabled] = useState(false);
+
+  useEffect(() => {
+    api.getSSOConfig().then(cfg => setSsoEnabled(cfg.enabled)).catch(() => {});
+  }, []);
+
+  // Returning from the SSO provider: trade the ticket for a session
+  useEffect(() => {
+    if (!ssoTicket) return;
+    setLoading(true);
+    api.exchangeSSOTicket(ssoTicket)
+      .then(finishLogin)
+      .catch(err => setError(err.message || 'SSO login failed'))
+      .finally(() => setLoading(false));
+  }, [ssoTicket]);
+
+  const finishLogin = (res) => {
+    if (res.mfa_required) {
+      setMfaToken(res.mfa_token);
+      return;
+    }
+    api.setAuth(res.token, res.user, res.refresh_token);
+    onLogin(res.user);
+  };
 
   const handleLogin = async (e) => {
     e.preventDefault();
     setError('');
     setLoading(true);
     try {
-      const res = await api.login(email, password);
-      api.setAuth(res.token, res.user);
-      onLogin(res.user);
+      const res = mfaToken ? await api.verifyMFA(mfaToken, code) : await api.login(email, password);
+      finishLogin(res);
     } catch (err) {
       setError(err.message || 'Login failed');
     } finally {
@@ -45,8 +70,14 @@ export default function LoginPage({ onLogin }) {
         </div>
 
         <form onSubmit={handleLogin}>
-          <Input label="Email" type="email" value={email} onChange={e => setEmail(e.target.value)} placeholder="you@company.com" />
-          <Input label="Password" type="password" value={password} onChange={e => setPassword(e.target.value)} placeholder="&#x2022;&#x2022;&#x2022;&#x2022;&#x2022;&#x2022;&#x2022;&#x2022;" />
+          {mfaToken ? (
+            <Input label="Authenticator or Recovery Code" value={code} onChange={e => setCode(e.target.value)} placeholder="123456" autoFocus />
+          ) : (
+            <>
+              <Input label="Email" type="email" value={email} onChange={e => setEmail(e.target.value)} placeholder="you@company.com" />
+              <Input label="Password" type="password" value={password} onChange={e => setPassword(e.target.value)} placeholder="&#x2022;&#x2022;&#x2022;&#x2022;&#x2022;&#x2022;&#x2022;&#x2022;" />
+            </>
+          )}
 
           {error && (
             <div style={{
@@ -61,10 +92,23 @@ export default function LoginPage({ onLogin }) {
             width: '100%', padding: '14px', fontSize: '15px', fontWeight: 700,
             borderRadius: '10px', letterSpacing: '0.3px',
           }} disabled={loading}>
-            {loading ? 'Signing in...' : 'Sign In'}
+            {loading ? 'Signing in...' : mfaToken ? 'Verify' : 'Sign In'}
           </Btn>
         </form>
 
+        {ssoEnabled && !mfaToken && (
+          <Btn variant="secondary" type="button" onClick={() => { window.location.href = '/api/auth/oidc/login'; }} style={{
+            width: '100%', padding: '14px', fontSize: '15px', marginTop: '12px', borderRadius: '10px',
+          }}>
+            Sign in with SSO
+          </Btn>
+        )}
+
+        <div style={{ textAlign: 'center', marginTop: '16px' }}>
+          <a href="#" onClick={e => { e.preventDefault(); onForgotPassword(); }}
+            style={{ fontSize: '13px', color: colors.textDim }}>Forgot password?</a>
+        </div>
+
         <div style={{ textAlign: 'center', marginTop: '28px' }}>
           <span style={{ fontSize: '11px', color: colors.textDimmer, letterSpacing: '0.5px' }}>POWERED BY TEAMPULSE</span>
         </div>
@@ -0,0 +1,99 @@
+import React, { useEffect, useState } from 'react';
+import { Btn, Input, Logo, colors } from '../components/UI';
+import { api } from '../hooks/api';
+
+// Shown when org policy requires MFA and the user hasn't enrolled yet
+export default function MFASetupPage({ user, onDone, onLogout }) {
+  const [enrollment, setEnrollment] = useState(null);
+  const [code, setCode] = useState('');
+  const [recoveryCodes, setRecoveryCodes] = useState(null);
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  useEffect(() => {
+    api.enrollMFA().then(setEnrollment).catch(err => setError(err.message));
+  }, []);
+
+  const handleActivate = async (e) => {
+    e.preventDefault();
+    setError('');
+    setLoading(true);
+    try {
+      const res = await api.activateMFA(code);
+      setRecoveryCodes(res.recovery_codes);
+    } catch (err) {
+      setError(err.message || 'Invalid code');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const finish = () => onDone({ ...user, mfa_enabled: true, mfa_enrollment_required: false });
+
+  return (
+    <div style={{
+      minHeight: '100vh', display: 'flex', alignItems: 'center', justifyContent: 'center',
+      background: colors.bg,
+    }}>
+      <div className="fade-in" style={{
+        width: '480px', maxWidth: '90vw', background: colors.card, borderRadius: '16px',
+        padding: '48px 44px', border: `1px solid ${colors.border}`,
+        boxShadow: '0 32px 64px rgba(0,0,0,0.4)',
+      }}>
+        <div style={{ textAlign: 'center', marginBottom: '28px' }}>
+          <div style={{ display: 'inline-block', marginBottom: '16px' }}>
+            <Logo size={64} />
+          </div>
+          <h1 style={{ fontSize: '22px', fontWeight: 800, color: colors.text }}>Set up two-factor authentication</h1>
+          <p style={{ fontSize: '13px', color: colors.textDim, marginTop: '8px' }}>
+            Your organisation requires an authenticator app for admin accounts.
+          </p>
+        </div>
+
+        {recoveryCodes ? (
+          <>
+            <p style={{ fontSize: '13px', color: colors.textDim, marginBottom: '12px' }}>
+              Save these recovery codes somewhere safe. Each works once if you lose your device.
+            </p>
+            <pre style={{
+              background: colors.bg, padding: '16px', borderRadius: '8px', color: colors.accent,
+              fontSize: '14px', lineHeight: 1.8, marginBottom: '24px', textAlign: 'center',
+            }}>{recoveryCodes.join('\n')}</pre>
+            <Btn onClick={finish} style={{ width: '100%', padding: '14px' }}>I've saved them</Btn>
+          </>
+        ) : (
+          <form onSubmit={handleActivate}>
+            {enrollment && (
+              <div style={{ marginBottom: '20px' }}>
+                <p style={{ fontSize: '13px', color: colors.textDim, marginBottom: '8px' }}>
+                  Add this key to your authenticator app, or <a href={enrollment.otpauth_url} style={{ color: colors.accent }}>open it directly</a>:
+                </p>
+                <code style={{
+                  display: 'block', background: colors.bg, padding: '12px', borderRadius: '8px',
+                  color: colors.accent, fontSize: '14px', wordBreak: 'break-all', textAlign: 'center',
+                }}>{enrollment.secret}</code>
+              </div>
+            )}
+            <Input label="6-digit code from the app" value={code} onChange={e => setCode(e.target.value)} placeholder="123456" />
+
+            {error && (
+              <div style={{
+                padding: '10px 14px', borderRadius: '8px', background: '#2a0f0f',
+                border: '1px solid #4c1717', color: colors.red, fontSize: '13px', marginBottom: '16px',
+              }}>
+                {error}
+              </div>
+            )}
+
+            <Btn type="submit" style={{ width: '100%', padding: '14px', fontWeight: 700 }} disabled={loading || !enrollment}>
+              {loading ? 'Verifying...' : 'Enable'}
+            </Btn>
+            <Btn variant="secondary" type="button" onClick={onLogout} style={{ width: '100%', marginTop: '10px' }}>
+              Sign Out
+            </Btn>
+          </form>
+        )}
+      </div>
+    </div>
+  );
+}
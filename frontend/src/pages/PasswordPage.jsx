@@ -0,0 +1,115 @@
+import React, { useState } from 'react';
+import { Btn, Input, Logo, colors } from '../components/UI';
+import { api } from '../hooks/api';
+
+// mode: 'change' (forced change after first login), 'forgot' (request a reset link),
+// 'reset' (set a new password from an emailed link)
+export default function PasswordPage({ mode, resetToken, onDone }) {
+  const [email, setEmail] = useState('');
+  const [current, setCurrent] = useState('');
+  const [next, setNext] = useState('');
+  const [confirm, setConfirm] = useState('');
+  const [error, setError] = useState('');
+  const [message, setMessage] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setError('');
+    if (mode !== 'forgot' && next !== confirm) {
+      setError('Passwords do not match');
+      return;
+    }
+    setLoading(true);
+    try {
+      if (mode === 'change') {
+        const user = await api.changePassword(current, next);
+        api.setAuth(api.token, user);
+        onDone(user);
+      } else if (mode === 'forgot') {
+        const res = await api.forgotPassword(email);
+        setMessage(res.status);
+      } else {
+        await api.resetPassword(resetToken, next);
+        setMessage('Password updated. You can now sign in.');
+      }
+    } catch (err) {
+      setError(err.message || 'Request failed');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const titles = {
+    change: 'Choose a new password',
+    forgot: 'Reset your password',
+    reset: 'Set a new password',
+  };
+
+  return (
+    <div style={{
+      minHeight: '100vh', display: 'flex', alignItems: 'center', justifyContent: 'center',
+      background: colors.bg,
+    }}>
+      <div className="fade-in" style={{
+        width: '440px', maxWidth: '90vw', background: colors.card, borderRadius: '16px',
+        padding: '56px 44px', border: `1px solid ${colors.border}`,
+        boxShadow: '0 32px 64px rgba(0,0,0,0.4)',
+      }}>
+        <div style={{ textAlign: 'center', marginBottom: '32px' }}>
+          <div style={{ display: 'inline-block', marginBottom: '16px' }}>
+            <Logo size={64} />
+          </div>
+          <h1 style={{ fontSize: '22px', fontWeight: 800, color: colors.text }}>{titles[mode]}</h1>
+          {mode === 'change' && (
+            <p style={{ fontSize: '13px', color: colors.textDim, marginTop: '8px' }}>
+              Your account was created with a temporary password.
+            </p>
+          )}
+        </div>
+
+        {message ? (
+          <div style={{ textAlign: 'center' }}>
+            <p style={{ fontSize: '14px', color: colors.textDim, marginBottom: '24px' }}>{message}</p>
+            <Btn onClick={() => onDone(null)} style={{ width: '100%', padding: '14px' }}>Back to Sign In</Btn>
+          </div>
+        ) : (
+          <form onSubmit={handleSubmit}>
+            {mode === 'forgot' && (
+              <Input label="Email" type="email" value={email} onChange={e => setEmail(e.target.value)} placeholder="you@company.com" />
+            )}
+            {mode === 'change' && (
+              <Input label="Current Password" type="password" value={current} onChange={e => setCurrent(e.target.value)} />
+            )}
+            {mode !== 'forgot' && (
+              <>
+                <Input label="New Password" type="password" value={next} onChange={e => setNext(e.target.value)} placeholder="At least 8 characters" />
+                <Input label="Confirm New Password" type="password" value={confirm} onChange={e => setConfirm(e.target.value)} />
+              </>
+            )}
+
+            {error && (
+              <div style={{
+                padding: '10px 14px', borderRadius: '8px', background: '#2a0f0f',
+                border: '1px solid #4c1717', color: colors.red, fontSize: '13px', marginBottom: '16px',
+              }}>
+                {error}
+              </div>
+            )}
+
+            <Btn type="submit" style={{
+              width: '100%', padding: '14px', fontSize: '15px', fontWeight: 700, borderRadius: '10px',
+            }} disabled={loading}>
+              {loading ? 'Saving...' : mode === 'forgot' ? 'Send Reset Link' : 'Update Password'}
+            </Btn>
+            {mode !== 'change' && (
+              <Btn variant="secondary" type="button" onClick={() => onDone(null)} style={{ width: '100%', marginTop: '10px' }}>
+                Back to Sign In
+              </Btn>
+            )}
+          </form>
+        )}
+      </div>
+    </div>
+  );
+}